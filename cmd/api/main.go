@@ -2,48 +2,146 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
 	"github.com/arkishshah/go-infra-provisioner/internal/apirouter" // This should match your router file location
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/blueprints"
 	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
+	"github.com/arkishshah/go-infra-provisioner/internal/provisioner"
+	"github.com/arkishshah/go-infra-provisioner/internal/server"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/internal/tracing"
 	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
 	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
 )
 
 func main() {
+	// Load configuration
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		logger.NewLogger().Fatal("Failed to load config", logger.Err(err))
+	}
+
 	// Initialize logger
-	logger := logger.NewLogger()
+	log, err := logger.New(cfg.LogLevel, cfg.LogFormat, os.Stdout)
+	if err != nil {
+		logger.NewLogger().Fatal("Failed to initialize logger", logger.Err(err))
+	}
+	// Libraries logging through log/slog write to the same output
+	slog.SetDefault(log.Slog())
+	log.Info("Configuration loaded", "config", cfg)
 
-	// Load configuration
-	cfg, err := config.Load()
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingFile, cfg.Environment)
 	if err != nil {
-		logger.Fatal("Failed to load config:", err)
+		log.Fatal("Failed to initialize tracing", logger.Err(err))
 	}
 
-	// Initialize AWS client
-	awsClient, err := awsclient.NewAWSClient(context.Background())
+	// Initialize metrics
+	m := metrics.New()
+
+	// Initialize AWS clients, built per region as clients are provisioned
+	// there; their calls are traced and measured
+	awsOpts := awsclient.Options{
+		Region:          cfg.AWSRegion,
+		EndpointURL:     cfg.AWSEndpointURL,
+		Endpoints:       cfg.AWSEndpoints,
+		S3UsePathStyle:  cfg.AWSS3PathStyle,
+		AccessKeyID:     cfg.AWSAccessKeyID,
+		SecretAccessKey: cfg.AWSSecretAccessKey,
+		SessionToken:    cfg.AWSSessionToken,
+	}
+	awsClients := awsclient.NewFactory(awsOpts, m.ObserveAWSCall)
+	awsClient, err := awsClients.Client(context.Background(), cfg.AWSRegion)
 	if err != nil {
-		logger.Fatal("Failed to initialize AWS client:", err)
+		log.Fatal("Failed to initialize AWS client", logger.Err(err))
+	}
+
+	// Initialize the other accounts clients can be provisioned in; their
+	// roles are assumed with the service's own credentials
+	var registry *accounts.Registry
+	if cfg.AccountsFile != "" {
+		accountList, err := accounts.Load(cfg.AccountsFile)
+		if err != nil {
+			log.Fatal("Failed to load accounts", logger.Err(err))
+		}
+		registry = accounts.NewRegistry(accountList, accounts.AssumeRole(awsClient.STSClient, awsOpts, m.ObserveAWSCall))
+		log.Info("Loaded accounts", "accounts", registry.IDs())
+	}
+
+	// Initialize the tiers clients can be provisioned with, checked against
+	// the resources the provisioner knows how to build
+	var tiers *blueprints.Registry
+	if cfg.BlueprintsDir != "" {
+		blueprintList, err := blueprints.Load(cfg.BlueprintsDir)
+		if err != nil {
+			log.Fatal("Failed to load blueprints", logger.Err(err))
+		}
+		if err := provisioner.ValidateBlueprints(blueprintList); err != nil {
+			log.Fatal("Invalid blueprints", logger.Err(err))
+		}
+		tiers = blueprints.NewRegistry(blueprintList)
+		if _, err := tiers.Get(cfg.DefaultTier); err != nil {
+			log.Fatal("Default tier has no blueprint", logger.Err(err))
+		}
+		log.Info("Loaded blueprints", "tiers", tiers.Names(), "default_tier", cfg.DefaultTier)
+	}
+
+	// Initialize provisioning state store
+	store, err := state.New(cfg.StateStore, cfg.StateDir)
+	if err != nil {
+		log.Fatal("Failed to initialize state store", logger.Err(err))
+	}
+
+	// Initialize audit log
+	auditLog, err := audit.New(cfg.AuditLog, cfg.AuditLogPath)
+	if err != nil {
+		log.Fatal("Failed to initialize audit log", logger.Err(err))
+	}
+
+	// Start background job workers
+	jobManager := jobs.NewManager(cfg.JobWorkers, cfg.JobQueueSize, cfg.JobRetention, m, log)
+
+	// Initialize authentication
+	var authenticator auth.Authenticator = auth.Disabled{}
+	if cfg.AuthDisabled {
+		log.Warn("Authentication is disabled, every request is treated as admin")
+	} else {
+		authenticator, err = auth.New(cfg.APIKeys, cfg.AuthTokenSecret, cfg.AuthTokenIssuer)
+		if err != nil {
+			log.Fatal("Failed to initialize authentication", logger.Err(err))
+		}
 	}
 
 	// Initialize router
-	router := apirouter.NewRouter(cfg, awsClient, logger)
+	router := apirouter.NewRouter(cfg, awsClients, awsClient, registry, tiers, store, jobManager, authenticator, auditLog, m, log)
 
 	// Configure server
-	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: router,
+	srv, err := server.New(cfg, router, log)
+	if err != nil {
+		log.Fatal("Failed to configure server", logger.Err(err))
 	}
 
 	// Start server
 	go func() {
-		logger.Info("Starting server on :8080")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal("Failed to start server:", err)
+		log.Info("Starting server", "addr", srv.Addr, "tls", srv.TLSConfig != nil)
+		if err := server.ListenAndServe(srv); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server", logger.Err(err))
 		}
 	}()
 
@@ -52,13 +150,27 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	log.Info("Shutting down server", "grace", cfg.ShutdownGrace.String())
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown:", err)
+		log.Error("Server forced to shutdown", logger.Err(err))
+	}
+
+	// Let queued and running jobs finish within the grace period; the ones
+	// still running after it are interrupted and record where they stopped
+	if err := jobManager.Shutdown(ctx); err != nil {
+		log.Error("Jobs interrupted before finishing", logger.Err(err))
+	}
+
+	// Flush the spans of the last requests and jobs, even if the grace period
+	// ran out
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Error("Failed to flush traces", logger.Err(err))
 	}
 
-	logger.Info("Server exited properly")
+	log.Info("Server exited properly")
 }
@@ -0,0 +1,48 @@
+// Command token creates credentials for the provisioner API: bearer tokens
+// signed with AUTH_TOKEN_SECRET, and the hashes API keys are configured by.
+//
+//	AUTH_TOKEN_SECRET=... go run ./cmd/token -subject acme-ci -scopes provision:read,provision:write -clients acme -ttl 24h
+//	go run ./cmd/token -hash-key "$API_KEY"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+)
+
+func main() {
+	subject := flag.String("subject", "", "caller the token identifies")
+	scopes := flag.String("scopes", auth.ScopeProvisionRead, "comma-separated scopes to grant")
+	clients := flag.String("clients", "", "comma-separated client IDs the token is bound to; admins need none")
+	ttl := flag.Duration("ttl", time.Hour, "how long the token is valid")
+	hashKey := flag.String("hash-key", "", "print the hash of this API key instead of creating a token")
+	flag.Parse()
+
+	if *hashKey != "" {
+		fmt.Println(auth.HashAPIKey(*hashKey))
+		return
+	}
+
+	secret := os.Getenv("AUTH_TOKEN_SECRET")
+	if secret == "" || *subject == "" {
+		fmt.Fprintln(os.Stderr, "AUTH_TOKEN_SECRET and -subject are required")
+		os.Exit(2)
+	}
+
+	tokens := auth.NewTokens([]byte(secret), os.Getenv("AUTH_TOKEN_ISSUER"))
+	var clientIDs []string
+	if *clients != "" {
+		clientIDs = strings.Split(*clients, ",")
+	}
+	token, err := tokens.Sign(*subject, strings.Split(*scopes, ","), clientIDs, *ttl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to sign token:", err)
+		os.Exit(1)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,134 @@
+// Package accounts lets the provisioner work in AWS accounts other than the
+// service's own. Each target account is reached through an IAM role there
+// that the service assumes with STS.
+package accounts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownAccount is returned for accounts that are not in the registry.
+var ErrUnknownAccount = errors.New("accounts: account is not registered")
+
+// maxSessionTags is the most session tags STS accepts on AssumeRole.
+const maxSessionTags = 50
+
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// Account is a target account and the role the service assumes in it.
+type Account struct {
+	ID      string `yaml:"account_id"`
+	RoleARN string `yaml:"role_arn"`
+	// ExternalID is passed to AssumeRole when the role's trust policy
+	// requires one
+	ExternalID string `yaml:"external_id"`
+	// SessionTags are attached to the role session, so the role's policies
+	// and CloudTrail can tell the service's sessions apart
+	SessionTags map[string]string `yaml:"session_tags"`
+}
+
+// Load reads the accounts in the YAML or JSON file at path, written as
+//
+//	accounts:
+//	  - account_id: "210987654321"
+//	    role_arn: arn:aws:iam::210987654321:role/go-infra-provisioner
+//	    external_id: 5d0c1bd2
+//	    session_tags:
+//	      team: platform
+func Load(path string) ([]Account, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+
+	var file struct {
+		Accounts []Account `yaml:"accounts"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts file %s: %w", path, err)
+	}
+
+	var errs []error
+	seen := make(map[string]bool, len(file.Accounts))
+	for i, account := range file.Accounts {
+		if err := account.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("account %d: %w", i+1, err))
+			continue
+		}
+		if seen[account.ID] {
+			errs = append(errs, fmt.Errorf("account %s is listed twice", account.ID))
+		}
+		seen[account.ID] = true
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid accounts file %s: %w", path, errors.Join(errs...))
+	}
+	return file.Accounts, nil
+}
+
+func (a Account) validate() error {
+	if !accountIDPattern.MatchString(a.ID) {
+		return fmt.Errorf("account_id %q must be 12 digits", a.ID)
+	}
+	// The role must be in the account it is used for, so a typo cannot send
+	// a client's resources to another account
+	if prefix := fmt.Sprintf("arn:aws:iam::%s:role/", a.ID); !strings.HasPrefix(a.RoleARN, prefix) || a.RoleARN == prefix {
+		return fmt.Errorf("role_arn %q of account %s must be a role in that account", a.RoleARN, a.ID)
+	}
+	if len(a.SessionTags) > maxSessionTags {
+		return fmt.Errorf("account %s has %d session tags, at most %d are allowed", a.ID, len(a.SessionTags), maxSessionTags)
+	}
+	return nil
+}
+
+// ConnectFunc returns the factory of the AWS clients acting in an account.
+type ConnectFunc func(Account) *awsclient.Factory
+
+// Registry holds the AWS client factories of every target account. A nil
+// Registry has no accounts.
+type Registry struct {
+	clients map[string]*awsclient.Factory
+}
+
+// NewRegistry connects to every account with connect.
+func NewRegistry(accounts []Account, connect ConnectFunc) *Registry {
+	r := &Registry{clients: make(map[string]*awsclient.Factory, len(accounts))}
+	for _, account := range accounts {
+		r.clients[account.ID] = connect(account)
+	}
+	return r
+}
+
+// Clients returns the client factory of the account, or ErrUnknownAccount.
+func (r *Registry) Clients(accountID string) (*awsclient.Factory, error) {
+	if r != nil {
+		if clients, ok := r.clients[accountID]; ok {
+			return clients, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, accountID)
+}
+
+// IDs returns the registered account IDs in order.
+func (r *Registry) IDs() []string {
+	if r == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(r.clients))
+	for id := range r.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,137 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+)
+
+const role = "arn:aws:iam::210987654321:role/go-infra-provisioner"
+
+func writeAccounts(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "accounts.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	var manyTags strings.Builder
+	for i := range maxSessionTags + 1 {
+		fmt.Fprintf(&manyTags, "      tag%d: value\n", i)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []Account
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `
+accounts:
+  - account_id: "210987654321"
+    role_arn: ` + role + `
+    external_id: 5d0c1bd2
+    session_tags:
+      team: platform
+  - account_id: "111111111111"
+    role_arn: arn:aws:iam::111111111111:role/provisioner
+`,
+			want: []Account{
+				{ID: "210987654321", RoleARN: role, ExternalID: "5d0c1bd2", SessionTags: map[string]string{"team": "platform"}},
+				{ID: "111111111111", RoleARN: "arn:aws:iam::111111111111:role/provisioner"},
+			},
+		},
+		{name: "json", content: `{"accounts": [{"account_id": "210987654321", "role_arn": "` + role + `"}]}`, want: []Account{{ID: "210987654321", RoleARN: role}}},
+		{name: "no accounts", content: "accounts: []\n", want: []Account{}},
+		{name: "unknown field", content: "accounts:\n  - account_id: \"210987654321\"\n    role: " + role + "\n", wantErr: "field role not found"},
+		{name: "short account ID", content: "accounts:\n  - account_id: \"2109\"\n    role_arn: " + role + "\n", wantErr: "account 1: account_id \"2109\" must be 12 digits"},
+		{name: "role in another account", content: "accounts:\n  - account_id: \"111111111111\"\n    role_arn: " + role + "\n", wantErr: "must be a role in that account"},
+		{name: "role without name", content: "accounts:\n  - account_id: \"210987654321\"\n    role_arn: arn:aws:iam::210987654321:role/\n", wantErr: "must be a role in that account"},
+		{
+			name:    "listed twice",
+			content: "accounts:\n  - account_id: \"210987654321\"\n    role_arn: " + role + "\n  - account_id: \"210987654321\"\n    role_arn: " + role + "\n",
+			wantErr: "account 210987654321 is listed twice",
+		},
+		{
+			name:    "too many session tags",
+			content: "accounts:\n  - account_id: \"210987654321\"\n    role_arn: " + role + "\n    session_tags:\n" + manyTags.String(),
+			wantErr: "at most 50 are allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(writeAccounts(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "accounts.yaml")); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	factories := map[string]*awsclient.Factory{}
+	registry := NewRegistry([]Account{{ID: "210987654321"}, {ID: "111111111111"}}, func(account Account) *awsclient.Factory {
+		factories[account.ID] = awsclient.NewFactory(awsclient.Options{})
+		return factories[account.ID]
+	})
+
+	if got, want := registry.IDs(), []string{"111111111111", "210987654321"}; !slices.Equal(got, want) {
+		t.Errorf("IDs() = %v, want %v", got, want)
+	}
+	tests := []struct {
+		name      string
+		registry  *Registry
+		accountID string
+		wantErr   bool
+	}{
+		{name: "registered", registry: registry, accountID: "210987654321"},
+		{name: "not registered", registry: registry, accountID: "999999999999", wantErr: true},
+		{name: "nil registry", registry: nil, accountID: "210987654321", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients, err := tt.registry.Clients(tt.accountID)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownAccount) || !strings.Contains(err.Error(), tt.accountID) {
+					t.Errorf("Clients(%s) error = %v, want ErrUnknownAccount naming the account", tt.accountID, err)
+				}
+				return
+			}
+			if err != nil || clients != factories[tt.accountID] {
+				t.Errorf("Clients(%s) = %p, %v, want the account's factory", tt.accountID, clients, err)
+			}
+		})
+	}
+
+	var none *Registry
+	if ids := none.IDs(); ids != nil {
+		t.Errorf("nil registry IDs() = %v, want none", ids)
+	}
+}
@@ -0,0 +1,59 @@
+package accounts
+
+import (
+	"sort"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// SessionName names the role sessions the service starts, as seen in the
+// target account's CloudTrail.
+const SessionName = "go-infra-provisioner"
+
+// refreshWindow is how long before they expire assumed role credentials are
+// refreshed, so no call is made with credentials about to expire.
+const refreshWindow = 5 * time.Minute
+
+// AssumeRole connects to accounts by assuming their role with the service's
+// own STS client. The clients are otherwise built like the service's own,
+// from opts, and report their calls to recorders. Credentials are fetched on
+// first use, cached, refreshed before they expire, and shared by the clients
+// of every region.
+func AssumeRole(stsClient awsclient.STSAPI, opts awsclient.Options, recorders ...awsclient.CallRecorder) ConnectFunc {
+	return func(account Account) *awsclient.Factory {
+		provider := stscreds.NewAssumeRoleProvider(stsClient, account.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = SessionName
+			if account.ExternalID != "" {
+				o.ExternalID = aws.String(account.ExternalID)
+			}
+			o.Tags = sessionTags(account.SessionTags)
+		})
+
+		accountOpts := opts
+		accountOpts.Credentials = aws.NewCredentialsCache(provider, func(o *aws.CredentialsCacheOptions) {
+			o.ExpiryWindow = refreshWindow
+		})
+		return awsclient.NewFactory(accountOpts, recorders...)
+	}
+}
+
+func sessionTags(tags map[string]string) []types.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]types.Tag, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package accounts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// recordingSTS is the service's own STS, recording the roles assumed with it.
+type recordingSTS struct {
+	awsclient.STSAPI
+
+	mu      sync.Mutex
+	assumed []*sts.AssumeRoleInput
+}
+
+func (s *recordingSTS) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	s.mu.Lock()
+	s.assumed = append(s.assumed, params)
+	s.mu.Unlock()
+	return s.STSAPI.AssumeRole(ctx, params, optFns...)
+}
+
+func TestAssumeRole(t *testing.T) {
+	// Keep the developer's AWS configuration out of the test
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+
+	// The account's STS, answering with the identity of the assumed role
+	var mu sync.Mutex
+	var signed []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		signed = append(signed, r.Header.Get("Authorization")+" token="+r.Header.Get("X-Amz-Security-Token"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">` +
+			`<GetCallerIdentityResult><Arn>arn:aws:sts::210987654321:assumed-role/go-infra-provisioner/go-infra-provisioner</Arn>` +
+			`<UserId>AROAFAKE</UserId><Account>210987654321</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`))
+	}))
+	defer target.Close()
+
+	own := &recordingSTS{STSAPI: fake.New("123456789012", "us-east-1").Client().STSClient}
+	connect := AssumeRole(own, awsclient.Options{EndpointURL: target.URL})
+	clients := connect(Account{
+		ID:          "210987654321",
+		RoleARN:     role,
+		ExternalID:  "5d0c1bd2",
+		SessionTags: map[string]string{"team": "platform", "env": "dev"},
+	})
+	if len(own.assumed) != 0 {
+		t.Fatalf("role assumed %d times before the account was used", len(own.assumed))
+	}
+
+	ctx := context.Background()
+	for _, region := range []string{"us-east-1", "eu-west-1", "us-east-1"} {
+		client, err := clients.Client(ctx, region)
+		if err != nil {
+			t.Fatalf("Client(%s) error = %v", region, err)
+		}
+		identity, err := client.STSClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			t.Fatalf("GetCallerIdentity() in %s error = %v", region, err)
+		}
+		if aws.ToString(identity.Account) != "210987654321" {
+			t.Errorf("acting in account %s, want 210987654321", aws.ToString(identity.Account))
+		}
+	}
+
+	// The credentials are cached and shared by every region
+	if len(own.assumed) != 1 {
+		t.Fatalf("role assumed %d times, want 1", len(own.assumed))
+	}
+	in := own.assumed[0]
+	if aws.ToString(in.RoleArn) != role || aws.ToString(in.RoleSessionName) != SessionName || aws.ToString(in.ExternalId) != "5d0c1bd2" {
+		t.Errorf("AssumeRole(%s, session %s, external ID %s), want %s as %s with the external ID",
+			aws.ToString(in.RoleArn), aws.ToString(in.RoleSessionName), aws.ToString(in.ExternalId), role, SessionName)
+	}
+	var tags []string
+	for _, tag := range in.Tags {
+		tags = append(tags, aws.ToString(tag.Key)+"="+aws.ToString(tag.Value))
+	}
+	if got := strings.Join(tags, ","); got != "env=dev,team=platform" {
+		t.Errorf("session tags = %s, want env=dev,team=platform in order", got)
+	}
+	for _, s := range signed {
+		if !strings.Contains(s, "Credential=ASIAFAKE/") || !strings.HasSuffix(s, "token=fake") {
+			t.Errorf("request signed with %q, want the assumed role's credentials", s)
+		}
+	}
+}
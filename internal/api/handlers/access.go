@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// canAccess reports whether the caller of r may act on clientID's resources.
+func canAccess(r *http.Request, clientID string) bool {
+	id, ok := auth.FromContext(r.Context())
+	return ok && id.CanAccess(clientID)
+}
+
+// authorizeClient writes a 403 and returns false if the caller of r may not
+// act on clientID's resources.
+func authorizeClient(w http.ResponseWriter, r *http.Request, log *logger.Logger, clientID string) bool {
+	audit.SetClientID(r.Context(), clientID)
+	if canAccess(r, clientID) {
+		return true
+	}
+
+	log.Warn("Access denied to client", logger.ClientID, clientID)
+	writeError(w, log, "", models.NewProvisionError(models.ErrCodeForbidden, fmt.Sprintf("not allowed to access client %s", clientID), nil))
+	return false
+}
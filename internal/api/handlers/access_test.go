@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+)
+
+// provisionClients provisions each client as admin and returns their jobs'
+// IDs.
+func (a *testAPI) provisionClients(t *testing.T, clientIDs ...string) map[string]string {
+	t.Helper()
+	jobIDs := map[string]string{}
+	for _, clientID := range clientIDs {
+		w := a.do(t, "POST", "/api/v1/provision", `{"client_id": "`+clientID+`", "client_name": "Test"}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("POST %s = %d %s, want 202", clientID, w.Code, w.Body)
+		}
+		var accepted models.JobAcceptedResponse
+		decode(t, w, &accepted)
+		a.waitJob(t, accepted.JobID)
+		jobIDs[clientID] = accepted.JobID
+	}
+	return jobIDs
+}
+
+func TestClientAccess(t *testing.T) {
+	a := newTestAPI(t, 1)
+	jobIDs := a.provisionClients(t, "acme", "globex")
+	a.identity = &auth.Identity{
+		Subject:   "acme-ci",
+		Method:    auth.MethodAPIKey,
+		Scopes:    []string{auth.ScopeProvisionRead, auth.ScopeProvisionWrite},
+		ClientIDs: []string{"acme"},
+	}
+
+	tests := []struct {
+		name, method, path, body string
+		wantStatus               int
+	}{
+		{"status of own client", "GET", "/api/v1/provision/acme", "", http.StatusOK},
+		{"status of another client", "GET", "/api/v1/provision/globex", "", http.StatusForbidden},
+		{"provision another client", "POST", "/api/v1/provision", `{"client_id": "globex", "client_name": "Globex"}`, http.StatusForbidden},
+		{"dry run for another client", "POST", "/api/v1/provision?dry_run=true", `{"client_id": "globex", "client_name": "Globex"}`, http.StatusForbidden},
+		{"tear down another client", "DELETE", "/api/v1/provision/globex", "", http.StatusForbidden},
+		{"own job", "GET", "/api/v1/jobs/" + jobIDs["acme"], "", http.StatusOK},
+		{"job of another client", "GET", "/api/v1/jobs/" + jobIDs["globex"], "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := a.do(t, tt.method, tt.path, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s %s = %d %s, want %d", tt.method, tt.path, w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+
+	if _, ok := a.cloud.S3.Bucket("dev-globex-bucket"); !ok {
+		t.Error("another client's bucket was torn down")
+	}
+
+	w := a.do(t, "GET", "/api/v1/provision", "")
+	var list struct {
+		Clients []*state.Record `json:"clients"`
+	}
+	decode(t, w, &list)
+	if len(list.Clients) != 1 || list.Clients[0].ClientID != "acme" {
+		t.Errorf("GET /api/v1/provision listed %d clients, want only acme", len(list.Clients))
+	}
+}
+
+// A caller bound to a client sees only that client's audit events, and the
+// limit counts only those.
+func TestAuditQueryVisibility(t *testing.T) {
+	a := newTestAPI(t, 1)
+	a.provisionClients(t, "acme", "globex")
+	bound := &auth.Identity{Subject: "acme-ci", Scopes: []string{auth.ScopeAuditRead}, ClientIDs: []string{"acme"}}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		query      string
+		wantEvents int
+		wantOnly   string
+	}{
+		{"admin sees every client", admin, "?limit=1000", len(a.audit.Query(audit.Filter{})), ""},
+		{"bound caller sees own client", bound, "?limit=1000", len(a.audit.Query(audit.Filter{ClientID: "acme"})), "acme"},
+		{"limit counts visible events", bound, "?limit=3", 3, "acme"},
+		{"asking for another client", bound, "?client_id=globex", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a.identity = tt.identity
+			w := a.do(t, "GET", "/api/v1/audit"+tt.query, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /api/v1/audit%s = %d %s, want 200", tt.query, w.Code, w.Body)
+			}
+			var body struct {
+				Events []audit.Event `json:"events"`
+			}
+			decode(t, w, &body)
+			if len(body.Events) != tt.wantEvents {
+				t.Errorf("GET /api/v1/audit%s returned %d events, want %d", tt.query, len(body.Events), tt.wantEvents)
+			}
+			for _, e := range body.Events {
+				if tt.wantOnly != "" && e.ClientID != tt.wantOnly {
+					t.Errorf("event %d of client %q returned, want only %s", e.Seq, e.ClientID, tt.wantOnly)
+				}
+			}
+		})
+	}
+}
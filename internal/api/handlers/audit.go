@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+type AuditHandler struct {
+	audit  *audit.Log
+	logger *logger.Logger
+}
+
+func NewAuditHandler(auditLog *audit.Log, logger *logger.Logger) *AuditHandler {
+	return &AuditHandler{audit: auditLog, logger: logger}
+}
+
+// Handle returns the audit events matching the query parameters client_id,
+// actor, kind, action, outcome, since, until (RFC 3339) and limit. Callers
+// only see events of the clients they may access; events not tied to a
+// client are for admins only. With format=jsonl the events are written as
+// JSON lines for export.
+func (h *AuditHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, err.Error(), nil))
+		return
+	}
+
+	// Checked as part of the query, so that the limit counts only the events
+	// the caller can see
+	id, _ := auth.FromContext(r.Context())
+	if !id.HasScope(auth.ScopeAdmin) {
+		filter.Visible = func(clientID string) bool {
+			return clientID != "" && id.CanAccess(clientID)
+		}
+	}
+	events := h.audit.Query(filter)
+	if events == nil {
+		events = []audit.Event{}
+	}
+
+	if r.URL.Query().Get("format") == "jsonl" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				log.Error("Failed to encode audit event", logger.Err(err))
+				return
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"events": events}); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
+	}
+}
+
+// HandleVerify checks the hash chain of the whole audit log.
+func (h *AuditHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	if err := h.audit.Verify(); err != nil {
+		log.Error("Audit log verification failed", logger.Err(err))
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInternal, "audit log verification failed", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"valid": true}); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
+	}
+}
+
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	filter := audit.Filter{
+		ClientID: q.Get("client_id"),
+		Actor:    q.Get("actor"),
+		Kind:     q.Get("kind"),
+		Action:   q.Get("action"),
+		Outcome:  q.Get("outcome"),
+	}
+
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 time", name)
+			}
+			*t = parsed
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// errorStatuses maps error codes to the HTTP status they are returned with.
+var errorStatuses = map[string]int{
+	models.ErrCodeInvalidRequest:   http.StatusBadRequest,
+	models.ErrCodePayloadTooLarge:  http.StatusRequestEntityTooLarge,
+	models.ErrCodeUnauthenticated:  http.StatusUnauthorized,
+	models.ErrCodeForbidden:        http.StatusForbidden,
+	models.ErrCodeNotFound:         http.StatusNotFound,
+	models.ErrCodeConflict:         http.StatusConflict,
+	models.ErrCodeAlreadyExists:    http.StatusConflict,
+	models.ErrCodeThrottled:        http.StatusTooManyRequests,
+	models.ErrCodeAccessDenied:     http.StatusForbidden,
+	models.ErrCodeLimitExceeded:    http.StatusUnprocessableEntity,
+	models.ErrCodeInvalidName:      http.StatusUnprocessableEntity,
+	models.ErrCodeDependencyFailed: http.StatusBadGateway,
+	models.ErrCodeUnavailable:      http.StatusServiceUnavailable,
+	models.ErrCodeInternal:         http.StatusInternalServerError,
+}
+
+// errorStatus returns the HTTP status for err: the status of its code if it
+// is a ProvisionError and 500 otherwise.
+func errorStatus(err error) int {
+	var perr *models.ProvisionError
+	if errors.As(err, &perr) {
+		if status, ok := errorStatuses[perr.Code]; ok {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError writes err as a JSON error body. Errors that are not
+// ProvisionErrors are reported as internal errors with the given message, so
+// their details are not exposed.
+func writeError(w http.ResponseWriter, log *logger.Logger, message string, err error) {
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) {
+		perr = &models.ProvisionError{Code: models.ErrCodeInternal, Message: message}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errorStatus(perr))
+	if err := json.NewEncoder(w).Encode(models.ErrorResponse{Error: perr}); err != nil {
+		log.Error("Failed to encode error response", logger.Err(err))
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+func TestWriteError(t *testing.T) {
+	log, err := logger.New("error", "text", io.Discard)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"invalid request", models.NewProvisionError(models.ErrCodeInvalidRequest, "bad", nil), http.StatusBadRequest, models.ErrCodeInvalidRequest, "bad"},
+		{"throttled", models.NewProvisionError(models.ErrCodeThrottled, "slow down", nil), http.StatusTooManyRequests, models.ErrCodeThrottled, "slow down"},
+		{"dependency failed", models.NewProvisionError(models.ErrCodeDependencyFailed, "aws", nil), http.StatusBadGateway, models.ErrCodeDependencyFailed, "aws"},
+		{"unknown code", models.NewProvisionError("SOMETHING_ELSE", "odd", nil), http.StatusInternalServerError, "SOMETHING_ELSE", "odd"},
+		{"plain error", errors.New("secret detail"), http.StatusInternalServerError, models.ErrCodeInternal, "failed to do it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, log, "failed to do it", tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("writeError() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body models.ErrorResponse
+			decode(t, w, &body)
+			if body.Error == nil || body.Error.Code != tt.wantCode || body.Error.Message != tt.wantMessage {
+				t.Errorf("writeError() body = %s, want code %q and message %q", w.Body, tt.wantCode, tt.wantMessage)
+			}
+			if strings.Contains(w.Body.String(), "secret detail") {
+				t.Errorf("writeError() exposed the underlying error: %s", w.Body)
+			}
+		})
+	}
+}
+
+// The causes of failures, such as AWS errors naming accounts, are logged but
+// not served, whether in jobs, records or status.
+func TestErrorCausesNotServed(t *testing.T) {
+	const secret = "210987654321"
+	cause := errors.New("User arn:aws:iam::" + secret + ":user/ops is not authorized")
+
+	a := newTestAPI(t, 1)
+	steps := []struct {
+		name         string
+		failOn       string
+		method, path string
+		body         string
+	}{
+		{"provision", "lambda:CreateFunction", "POST", "/api/v1/provision", `{"client_id": "acme", "client_name": "Acme"}`},
+		{"status", "s3:HeadBucket", "GET", "/api/v1/provision/acme", ""},
+		{"teardown", "sns:DeleteTopic", "DELETE", "/api/v1/provision/acme", ""},
+		{"list", "", "GET", "/api/v1/provision", ""},
+	}
+	for _, step := range steps {
+		a.cloud.ClearFailures()
+		if step.failOn != "" {
+			a.cloud.FailOn(step.failOn, cause)
+		}
+		w := a.do(t, step.method, step.path, step.body)
+		if strings.Contains(w.Body.String(), secret) {
+			t.Errorf("%s: response carries the cause: %s", step.name, w.Body)
+		}
+		if w.Code != http.StatusAccepted {
+			continue
+		}
+
+		var accepted models.JobAcceptedResponse
+		decode(t, w, &accepted)
+		job := a.waitJob(t, accepted.JobID)
+		if job.Status != jobs.StatusFailed || job.Error == "" {
+			t.Errorf("%s: job = %s with error %q, want it to fail", step.name, job.Status, job.Error)
+		}
+		w = a.do(t, "GET", accepted.StatusURL, "")
+		if strings.Contains(w.Body.String(), secret) {
+			t.Errorf("%s: job carries the cause: %s", step.name, w.Body)
+		}
+	}
+}
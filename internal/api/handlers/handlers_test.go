@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"github.com/gorilla/mux"
+)
+
+var admin = &auth.Identity{Subject: "ops", Method: auth.MethodAPIKey, Scopes: []string{auth.ScopeAdmin}}
+
+// testAPI serves the handlers over fakes, as the caller identity.
+type testAPI struct {
+	cloud    *fake.Cloud
+	store    state.Store
+	jobs     *jobs.Manager
+	audit    *audit.Log
+	router   *mux.Router
+	identity *auth.Identity
+}
+
+// newTestAPI returns the handlers with workers job workers, called by admin
+// unless the test sets identity.
+func newTestAPI(t *testing.T, workers int) *testAPI {
+	t.Helper()
+	cfg := config.Default()
+	cfg.AWSAccountID = "123456789012"
+	log, err := logger.New("error", "text", io.Discard)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+
+	a := &testAPI{
+		cloud:    fake.New("123456789012", "us-east-1"),
+		store:    state.NewMemoryStore(),
+		jobs:     jobs.NewManager(workers, 4, time.Hour, nil, log),
+		audit:    audit.NewMemoryLog(),
+		router:   mux.NewRouter(),
+		identity: admin,
+	}
+	a.cloud.IAM.AddPolicy("go-infra-policy")
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = a.jobs.Shutdown(ctx)
+	})
+
+	provision := NewProvisionHandler(cfg, fake.NewFactory(a.cloud), nil, nil, a.store, a.jobs, a.audit, nil, log)
+	jobsHandler := NewJobsHandler(a.jobs, log)
+	auditHandler := NewAuditHandler(a.audit, log)
+	a.router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), a.identity)))
+		})
+	})
+	a.router.HandleFunc("/api/v1/provision", provision.Handle).Methods("POST")
+	a.router.HandleFunc("/api/v1/provision", provision.HandleList).Methods("GET")
+	a.router.HandleFunc("/api/v1/provision/{client_id}", provision.HandleStatus).Methods("GET")
+	a.router.HandleFunc("/api/v1/provision/{client_id}", provision.HandleDelete).Methods("DELETE")
+	a.router.HandleFunc("/api/v1/jobs/{id}", jobsHandler.Handle).Methods("GET")
+	a.router.HandleFunc("/api/v1/audit", auditHandler.Handle).Methods("GET")
+	a.router.HandleFunc("/api/v1/audit/verify", auditHandler.HandleVerify).Methods("GET")
+	return a
+}
+
+func (a *testAPI) do(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// decode decodes the JSON body of w into v.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode %q: %v", w.Body.String(), err)
+	}
+}
+
+// errorCode returns the error code of an error response.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	decode(t, w, &body)
+	return body.Error.Code
+}
+
+// waitJob returns the job once it is done.
+func (a *testAPI) waitJob(t *testing.T, id string) *jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := a.jobs.Get(id)
+		if !ok {
+			t.Fatalf("job %s is unknown", id)
+		}
+		if job.Status == jobs.StatusSucceeded || job.Status == jobs.StatusFailed {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
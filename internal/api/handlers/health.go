@@ -3,19 +3,52 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/health"
 	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
 )
 
 type HealthHandler struct {
-	logger *logger.Logger
+	checker *health.Checker
+	logger  *logger.Logger
 }
 
-func NewHealthHandler(logger *logger.Logger) *HealthHandler {
-	return &HealthHandler{logger: logger}
+func NewHealthHandler(checker *health.Checker, logger *logger.Logger) *HealthHandler {
+	return &HealthHandler{checker: checker, logger: logger}
 }
 
-func (h *HealthHandler) Handle(w http.ResponseWriter, r *http.Request) {
+// HandleLive reports that the process is up and serving requests.
+func (h *HealthHandler) HandleLive(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
 }
+
+// HandleReady reports the status of every readiness check, with a 503 if any
+// of them failed. Why a check failed is only logged.
+func (h *HealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+
+		var failed []string
+		for name, result := range report.Checks {
+			if result.Status != health.StatusOK {
+				failed = append(failed, name)
+			}
+		}
+		sort.Strings(failed)
+		log := logger.FromContext(r.Context(), h.logger)
+		log.Warn("Service not ready", "failed_checks", failed)
+		for _, name := range failed {
+			result := report.Checks[name]
+			log.Warn("Readiness check failed", "check", name, logger.ErrorKey, result.Error, "duration_ms", result.DurationMS)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
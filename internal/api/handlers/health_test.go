@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/health"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkErr   error
+		wantStatus int
+		wantReady  string
+	}{
+		{"ready", nil, http.StatusOK, health.StatusReady},
+		{"not ready", errors.New("credentials belong to account 111111111111"), http.StatusServiceUnavailable, health.StatusNotReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log, err := logger.New("warn", "text", &logs)
+			if err != nil {
+				t.Fatalf("logger.New() error = %v", err)
+			}
+			checker := health.NewChecker(0, time.Second, health.Check{Name: "s3", Run: func(context.Context) error { return tt.checkErr }})
+			h := NewHealthHandler(checker, log)
+
+			w := httptest.NewRecorder()
+			h.HandleLive(w, httptest.NewRequest("GET", "/health/live", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("HandleLive() = %d, want 200 whatever the checks say", w.Code)
+			}
+
+			w = httptest.NewRecorder()
+			h.HandleReady(w, httptest.NewRequest("GET", "/health/ready", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("HandleReady() = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var report health.Report
+			decode(t, w, &report)
+			if report.Status != tt.wantReady || report.Checks["s3"].Status == "" {
+				t.Errorf("HandleReady() report = %+v, want %s with the s3 check", report, tt.wantReady)
+			}
+
+			// Readiness needs no authentication, so why a check failed is
+			// only logged
+			if tt.checkErr != nil {
+				if strings.Contains(w.Body.String(), "111111111111") {
+					t.Errorf("HandleReady() served the check error: %s", w.Body)
+				}
+				if !strings.Contains(logs.String(), "111111111111") {
+					t.Errorf("logs %q lack the check error", logs.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"github.com/gorilla/mux"
+)
+
+type JobsHandler struct {
+	jobs   *jobs.Manager
+	logger *logger.Logger
+}
+
+func NewJobsHandler(jobManager *jobs.Manager, logger *logger.Logger) *JobsHandler {
+	return &JobsHandler{jobs: jobManager, logger: logger}
+}
+
+func (h *JobsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	// Jobs of clients the caller may not access are reported as missing
+	job, ok := h.jobs.Get(mux.Vars(r)["id"])
+	if ok {
+		audit.SetClientID(r.Context(), job.ClientID)
+	}
+	if !ok || !canAccess(r, job.ClientID) {
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeNotFound, "job not found", nil))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
+	}
+}
@@ -1,81 +1,318 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
+	"github.com/arkishshah/go-infra-provisioner/internal/api/middleware"
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/blueprints"
 	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
 	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/arkishshah/go-infra-provisioner/internal/provisioner"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
 	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
 	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ProvisionHandler struct {
 	provisioner *provisioner.ResourceProvisioner
+	store       state.Store
+	jobs        *jobs.Manager
 	logger      *logger.Logger
 	config      *config.Config
 }
 
-func NewProvisionHandler(cfg *config.Config, awsClient *awsclient.AWSClient, logger *logger.Logger) *ProvisionHandler {
+func NewProvisionHandler(cfg *config.Config, awsClients *awsclient.Factory, registry *accounts.Registry, tiers *blueprints.Registry, store state.Store, jobManager *jobs.Manager, auditLog *audit.Log, metrics *metrics.Metrics, logger *logger.Logger) *ProvisionHandler {
 	return &ProvisionHandler{
-		provisioner: provisioner.NewResourceProvisioner(cfg, awsClient, logger),
+		provisioner: provisioner.NewResourceProvisioner(cfg, awsClients, registry, tiers, store, auditLog, metrics, logger),
+		store:       store,
+		jobs:        jobManager,
 		logger:      logger,
 		config:      cfg,
 	}
 }
 
 func (h *ProvisionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
 	// Parse request
 	var req models.ProvisionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("Failed to decode request:", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, log, "", models.NewProvisionError(models.ErrCodePayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), err))
+			return
+		}
+		log.Error("Failed to decode request", logger.Err(err))
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, "invalid request body", err))
 		return
 	}
 
 	// Validate request
 	if err := h.validateRequest(&req); err != nil {
-		h.logger.Error("Invalid request:", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Error("Invalid request", logger.Err(err))
+		writeError(w, log, "", err)
+		return
+	}
+
+	if !authorizeClient(w, r, log, req.ClientID) {
 		return
 	}
 
-	// Call provisioner
-	response, err := h.provisioner.ProvisionClientResources(r.Context(), &req)
+	// A dry run only reports what provisioning would do, so it runs inline
+	if r.URL.Query().Get("dry_run") == "true" {
+		if len(req.Regions) > 1 {
+			writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, "a dry run covers only one region", nil))
+			return
+		}
+		h.handlePlan(w, r, &req)
+		return
+	}
+
+	// Queue provisioning as a background job; it runs independently of this
+	// request, so a dropped connection cannot interrupt it
+	h.submitJob(w, r, "provision", req.ClientID, func(ctx context.Context) (interface{}, error) {
+		// Several regions give a result per region, kept even if some failed
+		if len(req.Regions) > 1 {
+			response, err := h.provisioner.ProvisionClientRegions(ctx, &req)
+			if response == nil {
+				return nil, err
+			}
+			return response, err
+		}
+		response, err := h.provisioner.ProvisionClientResources(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		return response, nil
+	})
+}
+
+// submitJob queues run as a background job of jobType for the client, and
+// answers 202 Accepted pointing at the job. The caller's identity, the
+// request ID and the trace go with the job, and the steps it reports are
+// recorded on it.
+func (h *ProvisionHandler) submitJob(w http.ResponseWriter, r *http.Request, jobType, clientID string, run func(ctx context.Context) (interface{}, error)) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	identity, _ := auth.FromContext(r.Context())
+	spanContext := trace.SpanContextFromContext(r.Context())
+	fields := []any{logger.RequestID, middleware.RequestIDFromContext(r.Context())}
+	if identity != nil {
+		fields = append(fields, "subject", identity.Subject)
+	}
+	if spanContext.IsValid() {
+		fields = append(fields, logger.TraceID, spanContext.TraceID().String())
+	}
+	job, err := h.jobs.Submit(jobType, clientID, func(ctx context.Context, progress *jobs.Progress) (interface{}, error) {
+		if identity != nil {
+			ctx = auth.WithIdentity(ctx, identity)
+		}
+		ctx = trace.ContextWithSpanContext(ctx, spanContext)
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx, h.logger).With(fields...))
+		ctx = provisioner.WithStepReporter(ctx, progress)
+		return run(ctx)
+	})
 	if err != nil {
-		h.logger.Error("Failed to provision resources:", err)
-		http.Error(w, "Failed to provision resources", http.StatusInternalServerError)
+		log.Error("Failed to queue job", "job_type", jobType, logger.Err(err))
+		switch {
+		case errors.Is(err, jobs.ErrClientBusy):
+			err = models.NewProvisionError(models.ErrCodeConflict, err.Error(), nil)
+		case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrShutdown):
+			err = models.NewProvisionError(models.ErrCodeUnavailable, err.Error(), nil)
+		}
+		writeError(w, log, fmt.Sprintf("failed to queue %s job", jobType), err)
 		return
 	}
 
-	// Return success response
+	// Return accepted response pointing at the job
+	statusURL := fmt.Sprintf("/api/v1/jobs/%s", job.ID)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode response:", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	w.Header().Set("Location", statusURL)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(models.JobAcceptedResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		StatusURL: statusURL,
+	}); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
 		return
 	}
 
-	h.logger.Info("Successfully provisioned resources for client:", req.ClientID)
+	log.Info("Queued job", "job_type", jobType, logger.JobID, job.ID, logger.ClientID, clientID)
 }
 
-func (h *ProvisionHandler) validateRequest(req *models.ProvisionRequest) error {
-	if req.ClientID == "" {
-		return &models.ProvisionError{
-			Code:    "INVALID_REQUEST",
-			Message: "client_id is required",
+func (h *ProvisionHandler) handlePlan(w http.ResponseWriter, r *http.Request, req *models.ProvisionRequest) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	plan, err := h.provisioner.PlanClientResources(r.Context(), req)
+	if err != nil && plan == nil {
+		log.Error("Failed to plan resources", logger.Err(err))
+		writeError(w, log, "failed to plan resources", err)
+		return
+	}
+
+	// A plan with resources that could not be read is still returned, with
+	// the per-resource errors, but not as a success
+	status := http.StatusOK
+	if err != nil {
+		log.Error("Failed to plan some resources", logger.Err(err))
+		status = errorStatus(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(plan); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
+	}
+}
+
+func (h *ProvisionHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	records, err := h.store.List(r.Context())
+	if err != nil {
+		log.Error("Failed to list state records", logger.Err(err))
+		writeError(w, log, "failed to list clients", err)
+		return
+	}
+
+	// Only list the clients the caller may access
+	visible := make([]*state.Record, 0, len(records))
+	for _, record := range records {
+		if canAccess(r, record.ClientID) {
+			visible = append(visible, record)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"clients": visible}); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
+	}
+}
+
+func (h *ProvisionHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	clientID := mux.Vars(r)["client_id"]
+	if clientID == "" {
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, "client_id is required", nil))
+		return
+	}
+	if !authorizeClient(w, r, log, clientID) {
+		return
+	}
+
+	// Without a region, every region the client is provisioned in is described
+	region := r.URL.Query().Get("region")
+	if region != "" && !config.ValidRegion(region) {
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf("unsupported region %q", region), nil))
+		return
+	}
+	response, err := h.provisioner.DescribeClientResources(r.Context(), clientID, region)
+	status := http.StatusOK
+	switch {
+	case err != nil:
+		log.Error("Failed to describe resources", logger.Err(err))
+		status = errorStatus(err)
+	case response.Status == "not_found":
+		status = http.StatusNotFound
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error("Failed to encode response", logger.Err(err))
+	}
+}
+
+func (h *ProvisionHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context(), h.logger)
+
+	clientID := mux.Vars(r)["client_id"]
+	if clientID == "" {
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, "client_id is required", nil))
+		return
+	}
+	if !authorizeClient(w, r, log, clientID) {
+		return
+	}
+
+	// Without a region, every region the client is provisioned in is torn down
+	region := r.URL.Query().Get("region")
+	if region != "" && !config.ValidRegion(region) {
+		writeError(w, log, "", models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf("unsupported region %q", region), nil))
+		return
+	}
+
+	// A client with no record has nothing to tear down. One whose first
+	// provisioning is still queued is left to the job slot to refuse.
+	if _, active := h.jobs.Active(clientID); !active {
+		_, err := h.store.Get(r.Context(), clientID)
+		switch {
+		case errors.Is(err, state.ErrNotFound):
+			writeError(w, log, "", models.NewProvisionError(models.ErrCodeNotFound, fmt.Sprintf("client %s is not provisioned", clientID), nil))
+			return
+		case err != nil:
+			log.Error("Failed to load state record", logger.Err(err))
+			writeError(w, log, "failed to load state record", err)
+			return
 		}
 	}
 
+	// Tear down as a background job, like provisioning, so that it holds the
+	// client's job slot throughout and a dropped connection cannot stop it
+	// halfway. The result lists the outcome for each resource whether or not
+	// the teardown fully succeeded.
+	h.submitJob(w, r, "deprovision", clientID, func(ctx context.Context) (interface{}, error) {
+		response, err := h.provisioner.DeprovisionClientResources(ctx, clientID, region)
+		if response == nil {
+			return nil, err
+		}
+		return response, err
+	})
+}
+
+func (h *ProvisionHandler) validateRequest(req *models.ProvisionRequest) error {
 	if req.ClientName == "" {
 		return &models.ProvisionError{
-			Code:    "INVALID_REQUEST",
+			Code:    models.ErrCodeInvalidRequest,
 			Message: "client_name is required",
 		}
 	}
 
-	// Add any additional validation as needed
-	return nil
+	seen := make(map[string]bool, len(req.Regions))
+	for _, region := range req.Regions {
+		if !config.ValidRegion(region) {
+			return models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf("unsupported region %q", region), nil)
+		}
+		if seen[region] {
+			return models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf("region %s is listed twice", region), nil)
+		}
+		seen[region] = true
+	}
+
+	// Checked before anything is created, as AWS would only reject names
+	// derived from a bad client ID, or too long in one of the regions, part
+	// way through
+	if err := h.provisioner.ValidateClientID(req.ClientID, req.Regions); err != nil {
+		return err
+	}
+
+	if err := h.provisioner.ValidateTier(req.Tier, req.Options); err != nil {
+		return err
+	}
+
+	// Checked here so that a job is not queued for an unknown account
+	return h.provisioner.ValidateAccount(req.AccountID)
 }
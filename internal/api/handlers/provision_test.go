@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+func TestProvisionAndTeardownJobs(t *testing.T) {
+	a := newTestAPI(t, 1)
+
+	steps := []struct {
+		method, path, body string
+		jobType            string
+		wantBucket         bool
+	}{
+		{"POST", "/api/v1/provision", `{"client_id": "acme", "client_name": "Acme"}`, "provision", true},
+		{"DELETE", "/api/v1/provision/acme", "", "deprovision", false},
+	}
+	for _, step := range steps {
+		w := a.do(t, step.method, step.path, step.body)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("%s %s = %d %s, want 202", step.method, step.path, w.Code, w.Body)
+		}
+		var accepted models.JobAcceptedResponse
+		decode(t, w, &accepted)
+		if accepted.JobID == "" || w.Header().Get("Location") != accepted.StatusURL || accepted.StatusURL != "/api/v1/jobs/"+accepted.JobID {
+			t.Errorf("%s %s: accepted = %+v, Location %q", step.method, step.path, accepted, w.Header().Get("Location"))
+		}
+
+		job := a.waitJob(t, accepted.JobID)
+		if job.Type != step.jobType || job.Status != jobs.StatusSucceeded || job.Result == nil {
+			t.Errorf("%s job = %+v, want a succeeded %s job with a result", step.method, job, step.jobType)
+		}
+		if _, ok := a.cloud.S3.Bucket("dev-acme-bucket"); ok != step.wantBucket {
+			t.Errorf("after %s, bucket exists = %v, want %v", step.method, ok, step.wantBucket)
+		}
+
+		w = a.do(t, "GET", accepted.StatusURL, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", accepted.StatusURL, w.Code)
+		}
+	}
+}
+
+// A queued or running job holds the client's slot against both provisioning
+// and teardown.
+func TestJobsHoldTheClientSlot(t *testing.T) {
+	// Without workers, the first job stays queued
+	a := newTestAPI(t, 0)
+	if w := a.do(t, "POST", "/api/v1/provision", `{"client_id": "acme", "client_name": "Acme"}`); w.Code != http.StatusAccepted {
+		t.Fatalf("POST = %d %s, want 202", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+	}{
+		{"provision", "POST", "/api/v1/provision", `{"client_id": "acme", "client_name": "Acme"}`},
+		{"teardown", "DELETE", "/api/v1/provision/acme", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := a.do(t, tt.method, tt.path, tt.body)
+			if w.Code != http.StatusConflict || errorCode(t, w) != models.ErrCodeConflict {
+				t.Errorf("%s %s = %d %s, want 409 CONFLICT", tt.method, tt.path, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestTeardownKeepsResultOnFailure(t *testing.T) {
+	a := newTestAPI(t, 1)
+	w := a.do(t, "POST", "/api/v1/provision", `{"client_id": "acme", "client_name": "Acme"}`)
+	var accepted models.JobAcceptedResponse
+	decode(t, w, &accepted)
+	a.waitJob(t, accepted.JobID)
+
+	a.cloud.FailOn("lambda:DeleteFunction", errors.New("injected failure"))
+	w = a.do(t, "DELETE", "/api/v1/provision/acme", "")
+	decode(t, w, &accepted)
+	job := a.waitJob(t, accepted.JobID)
+	result, ok := job.Result.(*models.DeprovisionResponse)
+	if job.Status != jobs.StatusFailed || !ok || result.Status != "failed" || len(result.Resources) == 0 {
+		t.Errorf("job = %s with result %#v, want failed with the per-resource result", job.Status, job.Result)
+	}
+}
+
+func TestTeardownUnknownClient(t *testing.T) {
+	a := newTestAPI(t, 1)
+	w := a.do(t, "DELETE", "/api/v1/provision/acme", "")
+	if w.Code != http.StatusNotFound || errorCode(t, w) != models.ErrCodeNotFound {
+		t.Errorf("DELETE = %d %s, want 404 %s", w.Code, w.Body, models.ErrCodeNotFound)
+	}
+	if job, ok := a.jobs.Active("acme"); ok {
+		t.Errorf("DELETE queued job %s", job.ID)
+	}
+	if calls := a.cloud.Calls(); len(calls) != 0 {
+		t.Errorf("AWS was called for an unknown client: %v", calls)
+	}
+
+	// No record is left behind to show in the list
+	w = a.do(t, "GET", "/api/v1/provision", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "acme") {
+		t.Errorf("GET = %d %s, want 200 without acme", w.Code, w.Body)
+	}
+}
+
+func TestProvisionValidatesRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed", `{"client_id": `},
+		{"missing client_id", `{"client_name": "Acme"}`},
+		{"missing client_name", `{"client_id": "acme"}`},
+		{"trailing hyphen", `{"client_id": "c-", "client_name": "C"}`},
+		{"uppercase", `{"client_id": "Acme", "client_name": "Acme"}`},
+		{"too long", `{"client_id": "` + strings.Repeat("a", 51) + `", "client_name": "A"}`},
+		{"too long for a region", `{"client_id": "` + strings.Repeat("a", 40) + `", "client_name": "A", "regions": ["ap-southeast-2"]}`},
+		{"unsupported region", `{"client_id": "acme", "client_name": "Acme", "regions": ["mars-1"]}`},
+		{"region twice", `{"client_id": "acme", "client_name": "Acme", "regions": ["us-east-1", "us-east-1"]}`},
+		{"options out of bounds", `{"client_id": "acme", "client_name": "Acme", "options": {"lambda_memory_mb": 64}}`},
+		{"unknown tier", `{"client_id": "acme", "client_name": "Acme", "tier": "gold"}`},
+		{"unknown account", `{"client_id": "acme", "client_name": "Acme", "account_id": "210987654321"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAPI(t, 1)
+			w := a.do(t, "POST", "/api/v1/provision", tt.body)
+			if w.Code != http.StatusBadRequest || errorCode(t, w) != models.ErrCodeInvalidRequest {
+				t.Errorf("POST %s = %d %s, want 400 INVALID_REQUEST", tt.body, w.Code, w.Body)
+			}
+			if calls := a.cloud.Calls(); len(calls) != 0 {
+				t.Errorf("AWS was called for an invalid request: %v", calls)
+			}
+		})
+	}
+}
+
+func TestProvisionRegions(t *testing.T) {
+	// The test API's fakes serve us-east-1 only, so eu-west-1 fails
+	a := newTestAPI(t, 1)
+	w := a.do(t, "POST", "/api/v1/provision", `{"client_id": "acme", "client_name": "Acme", "regions": ["us-east-1", "eu-west-1"]}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST = %d %s, want 202", w.Code, w.Body)
+	}
+	var accepted models.JobAcceptedResponse
+	decode(t, w, &accepted)
+
+	job := a.waitJob(t, accepted.JobID)
+	result, ok := job.Result.(*models.MultiRegionResponse)
+	if job.Status != jobs.StatusFailed || !ok {
+		t.Fatalf("job = %s with result %#v, want failed with the per-region results", job.Status, job.Result)
+	}
+	if result.Status != "partial" || len(result.Regions) != 2 {
+		t.Fatalf("result = %+v, want partial with 2 regions", result)
+	}
+	if home, abroad := result.Regions[0], result.Regions[1]; home.Region != "us-east-1" || home.Status != "success" ||
+		abroad.Region != "eu-west-1" || abroad.Error == "" {
+		t.Errorf("regions = %+v, want us-east-1 provisioned and eu-west-1 failed with its error", result.Regions)
+	}
+
+	for _, method := range []string{"GET", "DELETE"} {
+		w := a.do(t, method, "/api/v1/provision/acme?region=mars-1", "")
+		if w.Code != http.StatusBadRequest || errorCode(t, w) != models.ErrCodeInvalidRequest {
+			t.Errorf("%s in an unsupported region = %d %s, want 400 INVALID_REQUEST", method, w.Code, w.Body)
+		}
+	}
+	w = a.do(t, "GET", "/api/v1/provision/acme?region=us-east-1", "")
+	var status models.StatusResponse
+	decode(t, w, &status)
+	if w.Code != http.StatusOK || !slices.Equal(status.Regions, []string{"us-east-1"}) {
+		t.Errorf("GET in us-east-1 = %d with regions %v, want 200 in us-east-1", w.Code, status.Regions)
+	}
+}
+
+func TestProvisionRejectsLargeBody(t *testing.T) {
+	a := newTestAPI(t, 1)
+	body := `{"client_id": "acme", "client_name": "` + strings.Repeat("A", 64) + `"}`
+	r := httptest.NewRequest("POST", "/api/v1/provision", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	// As cut off by the body size limit of the router
+	r.Body = http.MaxBytesReader(w, r.Body, 32)
+	a.router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge || errorCode(t, w) != models.ErrCodePayloadTooLarge {
+		t.Errorf("POST = %d %s, want 413 %s", w.Code, w.Body, models.ErrCodePayloadTooLarge)
+	}
+	if _, ok := a.jobs.Active("acme"); ok {
+		t.Error("a job was queued for a request cut off")
+	}
+}
+
+func TestProvisionDryRun(t *testing.T) {
+	a := newTestAPI(t, 1)
+	body := `{"client_id": "acme", "client_name": "Acme"}`
+
+	dryRun := func(wantAction string) {
+		t.Helper()
+		w := a.do(t, "POST", "/api/v1/provision?dry_run=true", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("dry run = %d %s, want 200", w.Code, w.Body)
+		}
+		var plan models.PlanResponse
+		decode(t, w, &plan)
+		if !plan.DryRun || plan.ClientID != "acme" || len(plan.Resources) == 0 {
+			t.Fatalf("plan = %+v, want a dry run plan for acme", plan)
+		}
+		for _, r := range plan.Resources {
+			if r.Action != wantAction {
+				t.Errorf("planned %s %s action = %q, want %q", r.Type, r.Name, r.Action, wantAction)
+			}
+		}
+	}
+
+	dryRun(models.PlanActionCreate)
+	if _, ok := a.cloud.S3.Bucket("dev-acme-bucket"); ok {
+		t.Error("dry run created the bucket")
+	}
+	if job, ok := a.jobs.Active("acme"); ok {
+		t.Errorf("dry run queued job %s", job.ID)
+	}
+
+	w := a.do(t, "POST", "/api/v1/provision", body)
+	var accepted models.JobAcceptedResponse
+	decode(t, w, &accepted)
+	if job := a.waitJob(t, accepted.JobID); job.Status != jobs.StatusSucceeded {
+		t.Fatalf("provision job = %+v, want it to succeed", job)
+	}
+	dryRun(models.PlanActionNone)
+
+	w = a.do(t, "POST", "/api/v1/provision?dry_run=true", `{"client_id": "acme", "client_name": "Acme", "regions": ["us-east-1", "eu-west-1"]}`)
+	if w.Code != http.StatusBadRequest || errorCode(t, w) != models.ErrCodeInvalidRequest {
+		t.Errorf("multi-region dry run = %d %s, want 400 INVALID_REQUEST", w.Code, w.Body)
+	}
+}
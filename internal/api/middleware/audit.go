@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"github.com/gorilla/mux"
+)
+
+// statusWriter remembers the status code and the number of body bytes
+// written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Audit records an audit event for every request once it has been served,
+// including requests rejected by authentication. It must run before Auth.
+func Audit(auditLog *audit.Log, log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, call := audit.WithCall(r.Context())
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			actor, clientID := call()
+			if clientID == "" {
+				clientID = mux.Vars(r)["client_id"]
+			}
+			event := audit.Event{
+				Kind:       audit.KindAPI,
+				Actor:      actor,
+				ClientID:   clientID,
+				Action:     r.Method + " " + r.URL.Path,
+				Outcome:    audit.OutcomeSuccess,
+				HTTPStatus: sw.status,
+				RequestID:  RequestIDFromContext(ctx),
+			}
+			if sw.status >= http.StatusBadRequest {
+				event.Outcome = audit.OutcomeFailure
+			}
+			if event.Actor == "" {
+				event.Actor = "unauthenticated"
+			}
+			if err := auditLog.Record(ctx, event); err != nil {
+				logger.FromContext(ctx, log).Error("Failed to record audit event", logger.Err(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/gorilla/mux"
+)
+
+func TestAudit(t *testing.T) {
+	keys, err := auth.ParseAPIKeys("ci:" + auth.HashAPIKey("ci-key") + ":" + auth.ScopeProvisionRead)
+	if err != nil {
+		t.Fatalf("ParseAPIKeys() error = %v", err)
+	}
+	log := testLogger(t)
+
+	tests := []struct {
+		name        string
+		key         string
+		path        string
+		status      int
+		wantActor   string
+		wantClient  string
+		wantOutcome string
+		wantStatus  int
+	}{
+		{"served", "ci-key", "/api/v1/provision/acme", http.StatusOK, "ci", "acme", audit.OutcomeSuccess, http.StatusOK},
+		{"failed", "ci-key", "/api/v1/provision/acme", http.StatusNotFound, "ci", "acme", audit.OutcomeFailure, http.StatusNotFound},
+		{"unauthenticated", "", "/api/v1/provision/acme", http.StatusOK, "unauthenticated", "acme", audit.OutcomeFailure, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := audit.NewMemoryLog()
+			router := mux.NewRouter()
+			router.Use(Audit(auditLog, log), Auth(keys, log))
+			router.HandleFunc("/api/v1/provision/{client_id}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			r := httptest.NewRequest("GET", tt.path, nil)
+			if tt.key != "" {
+				r.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), r)
+
+			events := auditLog.Query(audit.Filter{})
+			if len(events) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(events))
+			}
+			e := events[0]
+			if e.Kind != audit.KindAPI || e.Action != "GET "+tt.path || e.Actor != tt.wantActor || e.ClientID != tt.wantClient ||
+				e.Outcome != tt.wantOutcome || e.HTTPStatus != tt.wantStatus {
+				t.Errorf("recorded %+v, want %s by %s on %s with status %d", e, tt.wantOutcome, tt.wantActor, tt.wantClient, tt.wantStatus)
+			}
+		})
+	}
+}
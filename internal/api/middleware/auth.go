@@ -1,27 +1,71 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
 )
 
-// Logging middleware to log HTTP requests
-func Logging(logger *logger.Logger) func(http.Handler) http.Handler {
+// Auth authenticates every request with authenticator and stores the
+// caller's identity in the request context. Requests without valid
+// credentials get a 401.
+func Auth(authenticator auth.Authenticator, log *logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger.Info("Request:", r.Method, r.URL.Path)
+			id, err := authenticator.Authenticate(r)
+			if err != nil {
+				logger.FromContext(r.Context(), log).Warn("Authentication failed", logger.Err(err))
+				message := "authentication required"
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					message = "invalid credentials"
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="go-infra-provisioner"`)
+				writeError(w, http.StatusUnauthorized, models.ErrCodeUnauthenticated, message)
+				return
+			}
+
+			audit.SetActor(r.Context(), id.Subject)
+			setSubject(r.Context(), id.Subject)
+
+			// Entries logged for the rest of the request name the caller
+			ctx := auth.WithIdentity(r.Context(), id)
+			ctx = logger.WithContext(ctx, logger.FromContext(ctx, log).With("subject", id.Subject))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects requests whose caller lacks scope with a 403. It must
+// run after Auth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := auth.FromContext(r.Context())
+			if !ok {
+				writeError(w, http.StatusUnauthorized, models.ErrCodeUnauthenticated, "authentication required")
+				return
+			}
+			if !id.HasScope(scope) {
+				writeError(w, http.StatusForbidden, models.ErrCodeForbidden, fmt.Sprintf("scope %s is required", scope))
+				return
+			}
+
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// Auth middleware for authentication (if needed later)
-func Auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// TODO: Add authentication logic here
-		// For now, just pass through
-		next.ServeHTTP(w, r)
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error: &models.ProvisionError{Code: code, Message: message},
 	})
 }
 
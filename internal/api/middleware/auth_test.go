@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("error", "text", io.Discard)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return log
+}
+
+// errorCode returns the error code of an error response.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == nil {
+		t.Fatalf("failed to decode error response %q: %v", w.Body, err)
+	}
+	return body.Error.Code
+}
+
+func TestAuth(t *testing.T) {
+	keys, err := auth.ParseAPIKeys("ci:" + auth.HashAPIKey("ci-key") + ":" + auth.ScopeProvisionRead)
+	if err != nil {
+		t.Fatalf("ParseAPIKeys() error = %v", err)
+	}
+
+	var subject string
+	handler := Auth(keys, testLogger(t))(RequireScope(auth.ScopeProvisionRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = auth.Subject(r.Context())
+	})))
+	writeOnly := Auth(keys, testLogger(t))(RequireScope(auth.ScopeProvisionWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran without the required scope")
+	})))
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		key        string
+		wantStatus int
+		wantCode   string
+	}{
+		{"authenticated", handler, "ci-key", http.StatusOK, ""},
+		{"no credentials", handler, "", http.StatusUnauthorized, models.ErrCodeUnauthenticated},
+		{"invalid credentials", handler, "guess", http.StatusUnauthorized, models.ErrCodeUnauthenticated},
+		{"missing scope", writeOnly, "ci-key", http.StatusForbidden, models.ErrCodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject = ""
+			r := httptest.NewRequest("GET", "/api/v1/provision", nil)
+			if tt.key != "" {
+				r.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" && errorCode(t, w) != tt.wantCode {
+				t.Errorf("error code = %s, want %s", errorCode(t, w), tt.wantCode)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response has no WWW-Authenticate header")
+			}
+			if tt.wantStatus == http.StatusOK && subject != "ci" {
+				t.Errorf("handler saw subject %q, want ci", subject)
+			}
+		})
+	}
+}
+
+func TestRequireScopeWithoutAuth(t *testing.T) {
+	handler := RequireScope(auth.ScopeProvisionRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler ran without an identity")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
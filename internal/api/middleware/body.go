@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// MaxBodySize rejects requests with a body declared larger than limit bytes
+// with a 413. Bodies of unknown length are cut off after limit bytes, which
+// handlers report as a 413 too.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				writeError(w, http.StatusRequestEntityTooLarge, models.ErrCodePayloadTooLarge,
+					fmt.Sprintf("request body exceeds %d bytes", limit))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
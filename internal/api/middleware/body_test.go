@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		wantStatus    int
+		wantRead      string
+		wantTooLarge  bool
+	}{
+		{name: "within the limit", body: "0123456789", wantStatus: http.StatusOK, wantRead: "0123456789"},
+		{name: "declared too large", body: "0123456789abcdef!", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "unknown length within the limit", body: "0123", unknownLength: true, wantStatus: http.StatusOK, wantRead: "0123"},
+		{name: "unknown length too large", body: "0123456789abcdef!", unknownLength: true, wantStatus: http.StatusOK, wantTooLarge: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			var read string
+			var readErr error
+			handler := MaxBodySize(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				data, err := io.ReadAll(r.Body)
+				read, readErr = string(data), err
+			}))
+
+			r := httptest.NewRequest("POST", "/api/v1/provision", strings.NewReader(tt.body))
+			if tt.unknownLength {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge {
+				if called {
+					t.Error("handler called for a body declared too large")
+				}
+				if code := errorCode(t, w); code != models.ErrCodePayloadTooLarge {
+					t.Errorf("error code = %s, want %s", code, models.ErrCodePayloadTooLarge)
+				}
+				return
+			}
+			var tooLarge *http.MaxBytesError
+			if errors.As(readErr, &tooLarge) != tt.wantTooLarge {
+				t.Errorf("reading the body: error = %v, want a MaxBytesError %v", readErr, tt.wantTooLarge)
+			}
+			if !tt.wantTooLarge && read != tt.wantRead {
+				t.Errorf("handler read %q, want %q", read, tt.wantRead)
+			}
+		})
+	}
+}
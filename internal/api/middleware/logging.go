@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from callers.
+const maxRequestIDLength = 128
+
+// requestInfo is filled in as a request passes through the middleware, so the
+// outer middleware can see what the inner middleware learned.
+type requestInfo struct {
+	id      string
+	subject string
+}
+
+type requestInfoKey struct{}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// setSubject records the authenticated caller for the access log.
+func setSubject(ctx context.Context, subject string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.subject = subject
+	}
+}
+
+// RequestID gives every request an ID: the caller's X-Request-ID if it sent a
+// usable one, a new random ID otherwise. The ID is returned in the response
+// header, and the request context carries it along with a logger that tags
+// every entry with it. It must run before Logging and Recover.
+func RequestID(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(r.Context(), requestInfoKey{}, &requestInfo{id: id})
+			ctx = logger.WithContext(ctx, log.With(logger.RequestID, id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so a caller
+// cannot inject anything odd into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Logging writes an access log entry for every request once it has been
+// served, with its status, response size, latency and caller.
+func Logging(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			subject := ""
+			if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+				subject = info.subject
+			}
+			args := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", sw.status,
+				"bytes", sw.bytes,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote_addr", r.RemoteAddr,
+				"subject", subject,
+			}
+
+			reqLog := logger.FromContext(r.Context(), log)
+			if sw.status >= http.StatusInternalServerError {
+				reqLog.Error("Request served", args...)
+			} else {
+				reqLog.Info("Request served", args...)
+			}
+		})
+	}
+}
+
+// Recover turns a panic in a handler into a 500 with a JSON error body that
+// includes the request ID, and logs it with the stack. It must run after
+// RequestID.
+func Recover(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// The server uses this panic to abort a response on purpose
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				logger.FromContext(r.Context(), log).Error("Handler panicked", "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+				if sw.wroteHeader {
+					// Too late for an error response
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(models.ErrorResponse{
+					Error:     &models.ProvisionError{Code: models.ErrCodeInternal, Message: "internal server error"},
+					RequestID: RequestIDFromContext(r.Context()),
+				})
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantKept bool
+	}{
+		{"kept", "req-1", true},
+		{"none sent", "", false},
+		{"with a space", "req 1", false},
+		{"with a newline", "req\n1", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(testLogger(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+			}))
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("response ID %q, handler saw %q, want the same non-empty ID", got, seen)
+			}
+			if (got == tt.header) != tt.wantKept {
+				t.Errorf("request ID = %q for %q, want kept %v", got, tt.header, tt.wantKept)
+			}
+		})
+	}
+}
+
+// accessLog serves one request through RequestID, Logging and Auth with
+// handler, and returns the access log entry.
+func accessLog(t *testing.T, handler http.HandlerFunc) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	log, err := logger.New("info", logger.FormatJSON, &buf)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	keys, err := auth.ParseAPIKeys("ci:" + auth.HashAPIKey("ci-key") + ":" + auth.ScopeProvisionRead)
+	if err != nil {
+		t.Fatalf("ParseAPIKeys() error = %v", err)
+	}
+
+	chain := RequestID(log)(Logging(log)(Auth(keys, log)(handler)))
+	r := httptest.NewRequest("GET", "/api/v1/provision", nil)
+	r.Header.Set(auth.APIKeyHeader, "ci-key")
+	r.Header.Set(RequestIDHeader, "req-1")
+	chain.ServeHTTP(httptest.NewRecorder(), r)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("access log %q is not one JSON entry: %v", buf.String(), err)
+	}
+	return entry
+}
+
+func TestLogging(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantLevel string
+	}{
+		{"ok", http.StatusOK, `{"clients": []}`, "INFO"},
+		{"client error", http.StatusNotFound, "", "INFO"},
+		{"server error", http.StatusBadGateway, "", "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := accessLog(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			want := map[string]any{
+				"level":          tt.wantLevel,
+				"msg":            "Request served",
+				"method":         "GET",
+				"path":           "/api/v1/provision",
+				"status":         float64(tt.status),
+				"bytes":          float64(len(tt.body)),
+				"subject":        "ci",
+				logger.RequestID: "req-1",
+			}
+			for key, value := range want {
+				if entry[key] != value {
+					t.Errorf("entry[%q] = %v, want %v", key, entry[key], value)
+				}
+			}
+			if _, ok := entry["duration_ms"]; !ok {
+				t.Error("entry has no duration_ms")
+			}
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	log := testLogger(t)
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   bool
+	}{
+		{"panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") }, http.StatusInternalServerError, true},
+		{"panic after writing", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("boom")
+		}, http.StatusAccepted, false},
+		{"no panic", func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequestID(log)(Recover(log)(tt.handler))
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set(RequestIDHeader, "req-1")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !tt.wantBody {
+				return
+			}
+			var body models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode %q: %v", w.Body, err)
+			}
+			if body.Error == nil || body.Error.Code != models.ErrCodeInternal || body.RequestID != "req-1" {
+				t.Errorf("body = %s, want INTERNAL_ERROR with request ID req-1", w.Body)
+			}
+			if strings.Contains(w.Body.String(), "boom") {
+				t.Errorf("body exposes the panic: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestRecoverLetsAbortThrough(t *testing.T) {
+	handler := Recover(testLogger(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
+	"github.com/gorilla/mux"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	router := mux.NewRouter()
+	router.Use(Metrics(m))
+	router.HandleFunc("/api/v1/provision/{client_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, clientID := range []string{"acme", "globex"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/provision/"+clientID, nil))
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	want := `provisioner_http_requests_total{code="404",method="GET",route="/api/v1/provision/{client_id}"} 2`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("metrics do not contain %s", want)
+	}
+	if strings.Contains(w.Body.String(), "acme") {
+		t.Error("metrics are labelled with the client ID instead of the route template")
+	}
+}
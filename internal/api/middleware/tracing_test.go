@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanRecorder records the spans of every test in the package. The tracer of
+// the package binds to the first provider set globally, so the provider is
+// set once rather than per test.
+var (
+	spanRecorder      = tracetest.NewSpanRecorder()
+	setTracerProvider sync.Once
+)
+
+// recordSpans returns a function listing the spans ended since it was called.
+func recordSpans() func() []sdktrace.ReadOnlySpan {
+	setTracerProvider.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	before := len(spanRecorder.Ended())
+	return func() []sdktrace.ReadOnlySpan { return spanRecorder.Ended()[before:] }
+}
+
+func TestTracing(t *testing.T) {
+	ended := recordSpans()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var buf bytes.Buffer
+	log, err := logger.New("info", logger.FormatJSON, &buf)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	router := mux.NewRouter()
+	router.Use(RequestID(log), Tracing(log))
+	router.HandleFunc("/api/v1/provision/{client_id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context(), log).Info("Handling")
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest("GET", "/api/v1/provision/acme", nil)
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/v1/provision/{client_id}" {
+		t.Errorf("span name = %q, want the route template", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != traceID || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("span does not continue the caller's trace: %v", span.SpanContext())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want an error for a 502", span.Status())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, a := range span.Attributes() {
+		attrs[a.Key] = a.Value
+	}
+	if attrs["http.response.status_code"].AsInt64() != http.StatusBadGateway || attrs["url.path"].AsString() != "/api/v1/provision/acme" {
+		t.Errorf("span attributes = %v", span.Attributes())
+	}
+	if !strings.Contains(buf.String(), `"trace_id":"`+traceID+`"`) {
+		t.Errorf("log entry %q does not carry the trace ID", buf.String())
+	}
+}
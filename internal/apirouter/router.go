@@ -1,27 +1,66 @@
 package apirouter
 
 import (
+	"net/http"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
 	"github.com/arkishshah/go-infra-provisioner/internal/api/handlers"
 	"github.com/arkishshah/go-infra-provisioner/internal/api/middleware"
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+	"github.com/arkishshah/go-infra-provisioner/internal/blueprints"
 	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/health"
+	"github.com/arkishshah/go-infra-provisioner/internal/jobs"
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
 	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
 	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(cfg *config.Config, awsClient *awsclient.AWSClient, logger *logger.Logger) *mux.Router {
+func NewRouter(cfg *config.Config, awsClients *awsclient.Factory, awsClient *awsclient.AWSClient, registry *accounts.Registry, tiers *blueprints.Registry, store state.Store, jobManager *jobs.Manager, authenticator auth.Authenticator, auditLog *audit.Log, metrics *metrics.Metrics, logger *logger.Logger) *mux.Router {
 	r := mux.NewRouter()
 
 	// Initialize handlers
-	provisionHandler := handlers.NewProvisionHandler(cfg, awsClient, logger)
-	healthHandler := handlers.NewHealthHandler(logger)
+	provisionHandler := handlers.NewProvisionHandler(cfg, awsClients, registry, tiers, store, jobManager, auditLog, metrics, logger)
+	jobsHandler := handlers.NewJobsHandler(jobManager, logger)
+	auditHandler := handlers.NewAuditHandler(auditLog, logger)
+	checker := health.NewChecker(cfg.HealthCacheTTL, cfg.HealthCheckTimeout, health.ReadinessChecks(cfg, awsClient, registry, store)...)
+	healthHandler := handlers.NewHealthHandler(checker, logger)
 
 	// Add middleware
+	r.Use(middleware.RequestID(logger))
+	r.Use(middleware.Tracing(logger))
 	r.Use(middleware.Logging(logger))
+	r.Use(middleware.Metrics(metrics))
+	r.Use(middleware.Recover(logger))
+	r.Use(middleware.MaxBodySize(int64(cfg.MaxBodyBytes)))
 
 	// Routes
-	r.HandleFunc("/health", healthHandler.Handle).Methods("GET")
-	r.HandleFunc("/api/v1/provision", provisionHandler.Handle).Methods("POST")
+	r.HandleFunc("/health", healthHandler.HandleLive).Methods("GET")
+	r.HandleFunc("/health/live", healthHandler.HandleLive).Methods("GET")
+	r.HandleFunc("/health/ready", healthHandler.HandleReady).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Everything under /api/v1 is audited and requires authentication and a
+	// scope per route
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(middleware.Audit(auditLog, logger))
+	api.Use(middleware.Auth(authenticator, logger))
+
+	read := middleware.RequireScope(auth.ScopeProvisionRead)
+	write := middleware.RequireScope(auth.ScopeProvisionWrite)
+	api.Handle("/provision", write(http.HandlerFunc(provisionHandler.Handle))).Methods("POST")
+	api.Handle("/provision", read(http.HandlerFunc(provisionHandler.HandleList))).Methods("GET")
+	api.Handle("/provision/{client_id}", read(http.HandlerFunc(provisionHandler.HandleStatus))).Methods("GET")
+	api.Handle("/provision/{client_id}", write(http.HandlerFunc(provisionHandler.HandleDelete))).Methods("DELETE")
+	api.Handle("/jobs/{id}", read(http.HandlerFunc(jobsHandler.Handle))).Methods("GET")
+
+	auditRead := middleware.RequireScope(auth.ScopeAuditRead)
+	admin := middleware.RequireScope(auth.ScopeAdmin)
+	api.Handle("/audit", auditRead(http.HandlerFunc(auditHandler.Handle))).Methods("GET")
+	api.Handle("/audit/verify", admin(http.HandlerFunc(auditHandler.HandleVerify))).Methods("GET")
 
 	return r
 }
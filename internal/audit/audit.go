@@ -0,0 +1,202 @@
+// Package audit keeps an append-only record of who did what: every API call
+// and every AWS operation the provisioner makes that changes something. Each
+// event carries the hash of the one before it, so removing or editing an
+// event breaks the chain and shows up in Verify.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+)
+
+// Event kinds
+const (
+	KindAPI = "api"
+	KindAWS = "aws"
+)
+
+// Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+type Event struct {
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Actor    string    `json:"actor"`
+	ClientID string    `json:"client_id,omitempty"`
+	// AccountID is the AWS account of AWS operations
+	AccountID string `json:"account_id,omitempty"`
+	// Action is "METHOD /path" for API calls and "service:Operation" for AWS
+	// operations
+	Action       string `json:"action"`
+	ResourceType string `json:"resource_type,omitempty"`
+	Resource     string `json:"resource,omitempty"`
+	ARN          string `json:"arn,omitempty"`
+	Outcome      string `json:"outcome"`
+	HTTPStatus   int    `json:"http_status,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
+	Error        string `json:"error,omitempty"`
+	PrevHash     string `json:"prev_hash"`
+	Hash         string `json:"hash"`
+}
+
+// hash returns the hash of the event's contents, including the previous
+// event's hash but not its own.
+func (e Event) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sink persists events as they are appended.
+type sink interface {
+	append(e Event) error
+}
+
+// Log is an append-only, hash-chained sequence of events. Events are kept in
+// memory for querying and, for file logs, also written to disk.
+type Log struct {
+	mu     sync.RWMutex
+	events []Event
+	sink   sink
+}
+
+// New returns the log named by kind: "file" (the default) appends events to
+// the file at path, "memory" keeps them for the lifetime of the process.
+func New(kind, path string) (*Log, error) {
+	switch kind {
+	case "", "file":
+		return NewFileLog(path)
+	case "memory":
+		return NewMemoryLog(), nil
+	default:
+		return nil, fmt.Errorf("unknown audit log %q", kind)
+	}
+}
+
+func NewMemoryLog() *Log {
+	return &Log{}
+}
+
+// Record appends e to the log. The sequence number, time and hashes are set
+// by the log, and the actor is taken from the identity in ctx unless set.
+func (l *Log) Record(ctx context.Context, e Event) error {
+	if e.Actor == "" {
+		e.Actor = auth.Subject(ctx)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = int64(len(l.events)) + 1
+	e.Time = time.Now().UTC()
+	e.PrevHash = ""
+	if len(l.events) > 0 {
+		e.PrevHash = l.events[len(l.events)-1].Hash
+	}
+	hash, err := e.hash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	if l.sink != nil {
+		if err := l.sink.append(e); err != nil {
+			return err
+		}
+	}
+	l.events = append(l.events, e)
+	return nil
+}
+
+// Filter selects events. Zero fields match everything.
+type Filter struct {
+	ClientID string
+	Actor    string
+	Kind     string
+	Action   string
+	Outcome  string
+	Since    time.Time
+	Until    time.Time
+	// Visible, if set, keeps only the events whose client ID it accepts,
+	// before Limit counts them
+	Visible func(clientID string) bool
+	// Limit keeps only the most recent events
+	Limit int
+}
+
+func (f Filter) matches(e Event) bool {
+	switch {
+	case f.ClientID != "" && e.ClientID != f.ClientID,
+		f.Actor != "" && e.Actor != f.Actor,
+		f.Kind != "" && e.Kind != f.Kind,
+		f.Action != "" && e.Action != f.Action,
+		f.Outcome != "" && e.Outcome != f.Outcome,
+		!f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && !e.Time.Before(f.Until),
+		f.Visible != nil && !f.Visible(e.ClientID):
+		return false
+	}
+	return true
+}
+
+// Query returns the events matching f, oldest first.
+func (l *Log) Query(f Filter) []Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var events []Event
+	for _, e := range l.events {
+		if f.matches(e) {
+			events = append(events, e)
+		}
+	}
+	if f.Limit > 0 && len(events) > f.Limit {
+		events = events[len(events)-f.Limit:]
+	}
+	return events
+}
+
+// Verify checks the hash chain and returns an error naming the first event
+// that does not match it.
+func (l *Log) Verify() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return verify(l.events)
+}
+
+func verify(events []Event) error {
+	prev := ""
+	for i, e := range events {
+		if e.Seq != int64(i)+1 {
+			return fmt.Errorf("audit: event %d has sequence number %d", i+1, e.Seq)
+		}
+		if e.PrevHash != prev {
+			return fmt.Errorf("audit: event %d does not follow event %d", e.Seq, e.Seq-1)
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return err
+		}
+		if hash != e.Hash {
+			return fmt.Errorf("audit: event %d has been modified", e.Seq)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
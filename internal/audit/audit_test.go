@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/auth"
+)
+
+func TestRecord(t *testing.T) {
+	l := NewMemoryLog()
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "ci"})
+
+	events := []Event{
+		{Kind: KindAPI, Action: "POST /api/v1/provision", ClientID: "acme", Outcome: OutcomeSuccess},
+		{Kind: KindAWS, Actor: "job", Action: "s3:CreateBucket", ClientID: "acme", Outcome: OutcomeSuccess},
+	}
+	for _, e := range events {
+		if err := l.Record(ctx, e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	got := l.Query(Filter{})
+	if len(got) != 2 {
+		t.Fatalf("Query() returned %d events, want 2", len(got))
+	}
+	if got[0].Seq != 1 || got[1].Seq != 2 {
+		t.Errorf("sequence numbers = %d, %d, want 1, 2", got[0].Seq, got[1].Seq)
+	}
+	if got[0].Actor != "ci" || got[1].Actor != "job" {
+		t.Errorf("actors = %q, %q, want the caller unless set", got[0].Actor, got[1].Actor)
+	}
+	if got[0].PrevHash != "" || got[1].PrevHash != got[0].Hash || got[0].Hash == "" {
+		t.Errorf("events are not chained: %+v", got)
+	}
+	if got[0].Time.IsZero() {
+		t.Error("Record() did not set the time")
+	}
+	if err := l.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	l := NewMemoryLog()
+	ctx := context.Background()
+	for _, e := range []Event{
+		{Kind: KindAPI, Actor: "ci", ClientID: "acme", Action: "POST /api/v1/provision", Outcome: OutcomeSuccess},
+		{Kind: KindAWS, Actor: "ci", ClientID: "acme", Action: "s3:CreateBucket", Outcome: OutcomeSuccess},
+		{Kind: KindAWS, Actor: "ci", ClientID: "acme", Action: "iam:CreateRole", Outcome: OutcomeFailure},
+		{Kind: KindAPI, Actor: "ops", ClientID: "globex", Action: "DELETE /api/v1/provision/globex", Outcome: OutcomeSuccess},
+		{Kind: KindAPI, Actor: "ops", Action: "GET /api/v1/audit", Outcome: OutcomeSuccess},
+	} {
+		if err := l.Record(ctx, e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	// Spread the events an hour apart; Query does not check the hashes
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range l.events {
+		l.events[i].Time = start.Add(time.Duration(i) * time.Hour)
+	}
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantSeq []int64
+	}{
+		{"everything", Filter{}, []int64{1, 2, 3, 4, 5}},
+		{"client", Filter{ClientID: "acme"}, []int64{1, 2, 3}},
+		{"actor", Filter{Actor: "ops"}, []int64{4, 5}},
+		{"kind", Filter{Kind: KindAWS}, []int64{2, 3}},
+		{"action", Filter{Action: "iam:CreateRole"}, []int64{3}},
+		{"outcome", Filter{Outcome: OutcomeFailure}, []int64{3}},
+		{"since", Filter{Since: start.Add(3 * time.Hour)}, []int64{4, 5}},
+		{"until", Filter{Until: start.Add(2 * time.Hour)}, []int64{1, 2}},
+		{"limit keeps the most recent", Filter{ClientID: "acme", Limit: 2}, []int64{2, 3}},
+		{
+			"visible before limit",
+			Filter{Visible: func(clientID string) bool { return clientID == "acme" }, Limit: 2},
+			[]int64{2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			for _, e := range l.Query(tt.filter) {
+				got = append(got, e.Seq)
+			}
+			if len(got) != len(tt.wantSeq) {
+				t.Fatalf("Query() = events %v, want %v", got, tt.wantSeq)
+			}
+			for i := range got {
+				if got[i] != tt.wantSeq[i] {
+					t.Errorf("Query() = events %v, want %v", got, tt.wantSeq)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(l *Log)
+	}{
+		{"edited", func(l *Log) { l.events[1].Outcome = OutcomeSuccess }},
+		{"removed", func(l *Log) { l.events = append(l.events[:1], l.events[2:]...) }},
+		{"reordered", func(l *Log) { l.events[0], l.events[1] = l.events[1], l.events[0] }},
+		{"rehashed without relinking", func(l *Log) {
+			l.events[1].Outcome = OutcomeSuccess
+			l.events[1].Hash, _ = l.events[1].hash()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewMemoryLog()
+			for _, outcome := range []string{OutcomeSuccess, OutcomeFailure, OutcomeSuccess} {
+				if err := l.Record(context.Background(), Event{Kind: KindAPI, Action: "GET /", Outcome: outcome}); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+			tt.tamper(l)
+			if err := l.Verify(); err == nil {
+				t.Error("Verify() = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		kind    string
+		wantErr bool
+	}{
+		{"", false},
+		{"file", false},
+		{"memory", false},
+		{"database", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			_, err := New(tt.kind, t.TempDir()+"/audit.jsonl")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New(%q) error = %v, wantErr %v", tt.kind, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package audit
+
+import "context"
+
+// call holds what is learnt about an API call while it is served.
+type call struct {
+	actor    string
+	clientID string
+}
+
+type callKey struct{}
+
+// WithCall returns a copy of ctx in which the API call's actor and client can
+// be set with SetActor and SetClientID, and a function returning them.
+func WithCall(ctx context.Context) (context.Context, func() (actor, clientID string)) {
+	c := &call{}
+	return context.WithValue(ctx, callKey{}, c), func() (string, string) { return c.actor, c.clientID }
+}
+
+// SetActor records who made the API call in ctx. It does nothing outside an
+// audited call.
+func SetActor(ctx context.Context, actor string) {
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		c.actor = actor
+	}
+}
+
+// SetClientID records the client the API call in ctx acts on. It does nothing
+// outside an audited call.
+func SetClientID(ctx context.Context, clientID string) {
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		c.clientID = clientID
+	}
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileLogSurvivesReopening(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	ctx := context.Background()
+
+	l, err := NewFileLog(path)
+	if err != nil {
+		t.Fatalf("NewFileLog() error = %v", err)
+	}
+	for _, action := range []string{"POST /api/v1/provision", "DELETE /api/v1/provision/acme"} {
+		if err := l.Record(ctx, Event{Kind: KindAPI, Action: action, Outcome: OutcomeSuccess}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	reopened, err := NewFileLog(path)
+	if err != nil {
+		t.Fatalf("NewFileLog() on reopening error = %v", err)
+	}
+	if got := reopened.Query(Filter{}); len(got) != 2 || got[1].Action != "DELETE /api/v1/provision/acme" {
+		t.Fatalf("reopened log holds %+v, want the two recorded events", got)
+	}
+
+	// The chain carries on from the events on disk
+	if err := reopened.Record(ctx, Event{Kind: KindAPI, Action: "GET /api/v1/audit", Outcome: OutcomeSuccess}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if got := reopened.Query(Filter{}); got[2].Seq != 3 || got[2].PrevHash != got[1].Hash {
+		t.Errorf("event recorded after reopening = %+v, want it chained to event 2", got[2])
+	}
+	if _, err := NewFileLog(path); err != nil {
+		t.Errorf("NewFileLog() after appending error = %v", err)
+	}
+}
+
+func TestFileLogRejectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewFileLog(path)
+	if err != nil {
+		t.Fatalf("NewFileLog() error = %v", err)
+	}
+	for _, outcome := range []string{OutcomeFailure, OutcomeSuccess} {
+		if err := l.Record(context.Background(), Event{Kind: KindAPI, Action: "DELETE /api/v1/provision/acme", Outcome: outcome}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	tampered := strings.Replace(string(data), OutcomeFailure, OutcomeSuccess, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := NewFileLog(path); err == nil {
+		t.Error("NewFileLog() of a tampered log error = nil, want an error")
+	}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader is the request header API keys are sent in.
+const APIKeyHeader = "X-API-Key"
+
+type apiKey struct {
+	name      string
+	hash      []byte
+	scopes    []string
+	clientIDs []string
+}
+
+// APIKeys authenticates requests by API key. Only SHA-256 hashes of the keys
+// are kept, so the configuration never holds a usable key.
+type APIKeys struct {
+	keys []apiKey
+}
+
+// ParseAPIKeys parses a comma-separated list of API keys, each written as
+// name:sha256-hex:scopes, with the scopes separated by "|". Scopes of the form
+// client:<id> bind the key to that client, for example
+//
+//	acme-ci:9f86d081884c7d65...:provision:read|provision:write|client:acme
+func ParseAPIKeys(spec string) (*APIKeys, error) {
+	keys := &APIKeys{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("auth: API key entry %q must be name:sha256-hex:scopes", entry)
+		}
+		hash, err := hex.DecodeString(parts[1])
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("auth: API key %s must have a hex SHA-256 hash", parts[0])
+		}
+
+		key := apiKey{name: parts[0], hash: hash}
+		for _, scope := range strings.Split(parts[2], "|") {
+			if clientID, ok := strings.CutPrefix(scope, ClientScopePrefix); ok {
+				key.clientIDs = append(key.clientIDs, clientID)
+				continue
+			}
+			key.scopes = append(key.scopes, scope)
+		}
+		keys.keys = append(keys.keys, key)
+	}
+
+	if len(keys.keys) == 0 {
+		return nil, fmt.Errorf("auth: no API keys in %q", spec)
+	}
+	return keys, nil
+}
+
+// HashAPIKey returns the hex SHA-256 hash of key, as used in ParseAPIKeys.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (k *APIKeys) Authenticate(r *http.Request) (*Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// Compare against every key so the time taken does not depend on which
+	// one matched
+	sum := sha256.Sum256([]byte(key))
+	var match *apiKey
+	for i := range k.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.keys[i].hash) == 1 {
+			match = &k.keys[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+
+	return &Identity{
+		Subject:   match.name,
+		Method:    MethodAPIKey,
+		Scopes:    append([]string(nil), match.scopes...),
+		ClientIDs: append([]string(nil), match.clientIDs...),
+	}, nil
+}
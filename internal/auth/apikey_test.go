@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	hash := HashAPIKey("secret")
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{"one key", "ci:" + hash + ":provision:read", false},
+		{"several keys", "ci:" + hash + ":provision:read, ops:" + HashAPIKey("other") + ":admin", false},
+		{"empty", " , ", true},
+		{"missing scopes", "ci:" + hash, true},
+		{"missing name", ":" + hash + ":admin", true},
+		{"not hex", "ci:not-a-hash:admin", true},
+		{"short hash", "ci:abcd:admin", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAPIKeys(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAPIKeys(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeysAuthenticate(t *testing.T) {
+	keys, err := ParseAPIKeys("ci:" + HashAPIKey("ci-key") + ":provision:read|provision:write,ops:" + HashAPIKey("ops-key") + ":admin")
+	if err != nil {
+		t.Fatalf("ParseAPIKeys() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		key         string
+		wantSubject string
+		wantScopes  []string
+		wantErr     error
+	}{
+		{"first key", "ci-key", "ci", []string{ScopeProvisionRead, ScopeProvisionWrite}, nil},
+		{"second key", "ops-key", "ops", []string{ScopeAdmin}, nil},
+		{"no key", "", "", nil, ErrNoCredentials},
+		{"unknown key", "guess", "", nil, ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			id, err := keys.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if id.Subject != tt.wantSubject || id.Method != MethodAPIKey || !slices.Equal(id.Scopes, tt.wantScopes) {
+				t.Errorf("Authenticate() = %+v, want subject %s with scopes %v", id, tt.wantSubject, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestAPIKeyBoundToClients(t *testing.T) {
+	keys, err := ParseAPIKeys("acme-ci:" + HashAPIKey("ci-key") + ":provision:read|client:acme|client:globex")
+	if err != nil {
+		t.Fatalf("ParseAPIKeys() error = %v", err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(APIKeyHeader, "ci-key")
+	id, err := keys.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !slices.Equal(id.Scopes, []string{ScopeProvisionRead}) || !slices.Equal(id.ClientIDs, []string{"acme", "globex"}) {
+		t.Errorf("Authenticate() = %+v, want scope provision:read bound to acme and globex", id)
+	}
+}
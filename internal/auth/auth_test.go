@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	keys := "ci:" + HashAPIKey("ci-key") + ":provision:read"
+	token, err := NewTokens([]byte("secret"), "").Sign("ops", []string{ScopeAdmin}, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		apiKeys     string
+		secret      string
+		headers     map[string]string
+		wantSubject string
+		wantErr     error
+		wantNewErr  bool
+	}{
+		{name: "nothing configured", wantNewErr: true},
+		{name: "bad API keys", apiKeys: "ci:nothex:admin", wantNewErr: true},
+		{name: "API key", apiKeys: keys, headers: map[string]string{APIKeyHeader: "ci-key"}, wantSubject: "ci"},
+		{name: "token", apiKeys: keys, secret: "secret", headers: map[string]string{"Authorization": "Bearer " + token}, wantSubject: "ops"},
+		{name: "tokens not configured", apiKeys: keys, headers: map[string]string{"Authorization": "Bearer " + token}, wantErr: ErrNoCredentials},
+		{name: "no credentials", apiKeys: keys, secret: "secret", wantErr: ErrNoCredentials},
+		{
+			name: "invalid API key stops the chain", apiKeys: keys, secret: "secret",
+			headers: map[string]string{APIKeyHeader: "guess", "Authorization": "Bearer " + token},
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := New(tt.apiKeys, tt.secret, "")
+			if (err != nil) != tt.wantNewErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+
+			r := httptest.NewRequest("GET", "/", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			id, err := authenticator.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && id.Subject != tt.wantSubject {
+				t.Errorf("Authenticate() subject = %q, want %q", id.Subject, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{"granted", []string{ScopeProvisionRead}, ScopeProvisionRead, true},
+		{"not granted", []string{ScopeProvisionRead}, ScopeProvisionWrite, false},
+		{"admin grants all", []string{ScopeAdmin}, ScopeAuditRead, true},
+		{"no scopes", nil, ScopeProvisionRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := &Identity{Subject: "ci", Scopes: tt.scopes}
+			if got := id.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	id, err := Disabled{}.Authenticate(httptest.NewRequest("GET", "/", nil))
+	if err != nil || id.Method != MethodNone || !id.HasScope(ScopeProvisionWrite) {
+		t.Errorf("Disabled.Authenticate() = %+v, %v, want an anonymous admin", id, err)
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	tests := []struct {
+		name string
+		id   *Identity
+		want bool
+	}{
+		{"admin", &Identity{Scopes: []string{ScopeAdmin}}, true},
+		{"bound to the client", &Identity{Scopes: []string{ScopeProvisionRead}, ClientIDs: []string{"globex", "acme"}}, true},
+		{"bound to another client", &Identity{Scopes: []string{ScopeProvisionRead}, ClientIDs: []string{"globex"}}, false},
+		{"bound to no client", &Identity{Scopes: []string{ScopeProvisionRead}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.id.CanAccess("acme"); got != tt.want {
+				t.Errorf("CanAccess(acme) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import "context"
+
+// Scopes
+const (
+	ScopeProvisionRead  = "provision:read"
+	ScopeProvisionWrite = "provision:write"
+	ScopeAuditRead      = "audit:read"
+	// ScopeAdmin grants every other scope
+	ScopeAdmin = "admin"
+)
+
+// Authentication methods
+const (
+	MethodAPIKey = "api_key"
+	MethodToken  = "token"
+	MethodNone   = "none"
+)
+
+// ClientScopePrefix marks an API key scope that binds the key to a client,
+// as in "client:acme".
+const ClientScopePrefix = "client:"
+
+// Identity is an authenticated caller. Callers other than admins may only act
+// on the clients in ClientIDs.
+type Identity struct {
+	Subject   string   `json:"subject"`
+	Method    string   `json:"method"`
+	Scopes    []string `json:"scopes"`
+	ClientIDs []string `json:"client_ids,omitempty"`
+}
+
+// HasScope reports whether the identity was granted scope, directly or
+// through the admin scope.
+func (id *Identity) HasScope(scope string) bool {
+	for _, s := range id.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccess reports whether the identity may act on clientID's resources:
+// admins may act on every client, anyone else only on the clients bound to
+// their credentials.
+func (id *Identity) CanAccess(clientID string) bool {
+	if id.HasScope(ScopeAdmin) {
+		return true
+	}
+	for _, c := range id.ClientIDs {
+		if c == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// Subject returns the subject of the identity in ctx, or "" if there is none.
+// It is meant for log lines.
+func Subject(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id.Subject
+	}
+	return ""
+}
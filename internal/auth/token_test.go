@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokensAuthenticate(t *testing.T) {
+	tokens := NewTokens([]byte("secret"), "provisioner")
+	sign := func(signer *Tokens, subject string, ttl time.Duration) string {
+		token, err := signer.Sign(subject, []string{ScopeProvisionRead, ScopeAuditRead}, nil, ttl)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return token
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "ci", Issuer: "provisioner", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr error
+	}{
+		{"valid", "Bearer " + sign(tokens, "ci", time.Hour), nil},
+		{"scheme in lower case", "bearer " + sign(tokens, "ci", time.Hour), nil},
+		{"no header", "", ErrNoCredentials},
+		{"other scheme", "Basic Y2k6c2VjcmV0", ErrNoCredentials},
+		{"expired", "Bearer " + sign(tokens, "ci", -time.Hour), ErrInvalidCredentials},
+		{"other secret", "Bearer " + sign(NewTokens([]byte("other"), "provisioner"), "ci", time.Hour), ErrInvalidCredentials},
+		{"other issuer", "Bearer " + sign(NewTokens([]byte("secret"), "elsewhere"), "ci", time.Hour), ErrInvalidCredentials},
+		{"no subject", "Bearer " + sign(tokens, "", time.Hour), ErrInvalidCredentials},
+		{"unsigned", "Bearer " + unsigned, ErrInvalidCredentials},
+		{"malformed", "Bearer not.a.token", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			id, err := tokens.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if id.Subject != "ci" || id.Method != MethodToken || !slices.Equal(id.Scopes, []string{ScopeProvisionRead, ScopeAuditRead}) {
+				t.Errorf("Authenticate() = %+v, want ci with the signed scopes", id)
+			}
+		})
+	}
+}
+
+func TestTokenBoundToClients(t *testing.T) {
+	tokens := NewTokens([]byte("secret"), "")
+	token, err := tokens.Sign("acme-ci", []string{ScopeProvisionRead}, []string{"acme"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	id, err := tokens.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !slices.Equal(id.ClientIDs, []string{"acme"}) || !id.CanAccess("acme") || id.CanAccess("globex") {
+		t.Errorf("Authenticate() = %+v, want an identity bound to acme", id)
+	}
+}
@@ -0,0 +1,151 @@
+// Package blueprints defines the service tiers clients can be provisioned
+// with. Each blueprint names the resources its clients get and the settings
+// they get by default, and is read from a YAML file of its own.
+package blueprints
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownBlueprint is returned for tiers that have no blueprint.
+var ErrUnknownBlueprint = errors.New("blueprints: unknown tier")
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+
+// Blueprint is a tier: the resources provisioned for its clients, by
+// component name (see provisioner.Components), and the settings they get
+// unless a request overrides them.
+type Blueprint struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	Resources   []string               `yaml:"resources"`
+	Options     models.ResourceOptions `yaml:"options"`
+}
+
+// Includes reports whether the blueprint lists the component.
+func (b Blueprint) Includes(component string) bool {
+	return slices.Contains(b.Resources, component)
+}
+
+// Load reads a blueprint from every .yaml or .yml file in dir, each written
+// as
+//
+//	name: basic
+//	description: Storage and a role to reach it
+//	resources: [s3_bucket, iam_role]
+//	options:
+//	  s3_glacier_days: 180
+//
+// It checks their format only; which resources exist and how they depend on
+// each other is up to the provisioner.
+func Load(dir string) ([]Blueprint, error) {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blueprints: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no blueprints in %s", dir)
+	}
+	sort.Strings(files)
+
+	var list []Blueprint
+	var errs []error
+	seen := make(map[string]string, len(files))
+	for _, file := range files {
+		b, err := loadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if other, ok := seen[b.Name]; ok {
+			errs = append(errs, fmt.Errorf("blueprint %s is defined in both %s and %s", b.Name, other, file))
+			continue
+		}
+		seen[b.Name] = file
+		list = append(list, b)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid blueprints in %s: %w", dir, errors.Join(errs...))
+	}
+	return list, nil
+}
+
+func loadFile(path string) (Blueprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Blueprint{}, fmt.Errorf("failed to read blueprint: %w", err)
+	}
+
+	var b Blueprint
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&b); err != nil {
+		return Blueprint{}, fmt.Errorf("failed to parse blueprint %s: %w", path, err)
+	}
+	if err := b.validate(); err != nil {
+		return Blueprint{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b Blueprint) validate() error {
+	if !namePattern.MatchString(b.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits and dashes", b.Name)
+	}
+	if len(b.Resources) == 0 {
+		return fmt.Errorf("blueprint %s lists no resources", b.Name)
+	}
+	seen := make(map[string]bool, len(b.Resources))
+	for _, r := range b.Resources {
+		if seen[r] {
+			return fmt.Errorf("blueprint %s lists %s twice", b.Name, r)
+		}
+		seen[r] = true
+	}
+	return nil
+}
+
+// Registry holds the blueprint of every tier.
+type Registry struct {
+	blueprints map[string]Blueprint
+}
+
+func NewRegistry(blueprints []Blueprint) *Registry {
+	r := &Registry{blueprints: make(map[string]Blueprint, len(blueprints))}
+	for _, b := range blueprints {
+		r.blueprints[b.Name] = b
+	}
+	return r
+}
+
+// Get returns the blueprint of the tier, or ErrUnknownBlueprint.
+func (r *Registry) Get(tier string) (Blueprint, error) {
+	if b, ok := r.blueprints[tier]; ok {
+		return b, nil
+	}
+	return Blueprint{}, fmt.Errorf("%w: %s", ErrUnknownBlueprint, tier)
+}
+
+// Names returns the tiers in order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.blueprints))
+	for name := range r.blueprints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,122 @@
+package blueprints
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// writeBlueprints writes files, by name, to a new directory and returns it.
+func writeBlueprints(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	const basic = "name: basic\nresources: [s3_bucket, iam_role]\noptions:\n  s3_glacier_days: 60\n"
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name: "yaml and yml",
+			files: map[string]string{
+				"basic.yaml":  basic,
+				"premium.yml": "name: premium\ndescription: Everything\nresources: [s3_bucket, dashboard]\n",
+				"README.md":   "not a blueprint",
+				"notes.yaml~": "not a blueprint either",
+			},
+			wantNames: []string{"basic", "premium"},
+		},
+		{name: "no blueprints", files: map[string]string{"README.md": "nothing here"}, wantErr: "no blueprints in"},
+		{name: "unknown field", files: map[string]string{"basic.yaml": basic + "tier: gold\n"}, wantErr: "field tier not found"},
+		{name: "unknown option", files: map[string]string{"basic.yaml": basic + "  lambda_memory: 256\n"}, wantErr: "field lambda_memory not found"},
+		{name: "bad name", files: map[string]string{"basic.yaml": "name: Basic Tier\nresources: [s3_bucket]\n"}, wantErr: "must be lowercase letters"},
+		{name: "no resources", files: map[string]string{"basic.yaml": "name: basic\n"}, wantErr: "lists no resources"},
+		{name: "resource twice", files: map[string]string{"basic.yaml": "name: basic\nresources: [s3_bucket, s3_bucket]\n"}, wantErr: "lists s3_bucket twice"},
+		{
+			name:    "defined twice",
+			files:   map[string]string{"basic.yaml": basic, "copy.yaml": basic},
+			wantErr: "blueprint basic is defined in both",
+		},
+		{
+			// Every problem is reported, not just the first
+			name:    "several files invalid",
+			files:   map[string]string{"a.yaml": "name: a\n", "b.yaml": "name: b\nresources: [s3_bucket, s3_bucket]\n"},
+			wantErr: "blueprint a lists no resources\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Load(writeBlueprints(t, tt.files))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			var names []string
+			for _, b := range list {
+				names = append(names, b.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("Load() blueprints = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestLoadReadsSettings(t *testing.T) {
+	list, err := Load(writeBlueprints(t, map[string]string{"basic.yaml": `
+name: basic
+description: Storage only
+resources:
+  - s3_bucket
+  - iam_role
+options:
+  s3_glacier_days: 60
+  error_rate_alarm_threshold: 2.5
+`}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	b := list[0]
+	want := models.ResourceOptions{S3GlacierDays: 60, ErrorRateAlarmThreshold: 2.5}
+	if b.Description != "Storage only" || !slices.Equal(b.Resources, []string{"s3_bucket", "iam_role"}) || b.Options != want {
+		t.Errorf("Load() = %+v, want the file's settings", b)
+	}
+	if !b.Includes("iam_role") || b.Includes("dashboard") {
+		t.Errorf("Includes() does not match the resources %v", b.Resources)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry([]Blueprint{{Name: "standard"}, {Name: "basic"}})
+
+	if got, want := r.Names(), []string{"basic", "standard"}; !slices.Equal(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if b, err := r.Get("basic"); err != nil || b.Name != "basic" {
+		t.Errorf("Get(basic) = %+v, %v", b, err)
+	}
+	if _, err := r.Get("gold"); !errors.Is(err, ErrUnknownBlueprint) || !strings.Contains(err.Error(), "gold") {
+		t.Errorf("Get(gold) error = %v, want ErrUnknownBlueprint naming the tier", err)
+	}
+}
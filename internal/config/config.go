@@ -1,8 +1,16 @@
+// Package config loads the service configuration. Every setting has a
+// default and can be overridden, in increasing order of precedence, by a
+// YAML or JSON config file, by an environment variable and by a command-line
+// flag. The loaded configuration is validated as a whole, so a bad value
+// fails startup instead of the first request that needs it.
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,37 +20,275 @@ type Config struct {
 	AWSAccountID string
 	Environment  string
 	LogLevel     string
+	LogFormat    string
+	StateStore   string
+	StateDir     string
+	AuditLog     string
+	AuditLogPath string
+	JobWorkers   int
+	JobQueueSize int
+	JobRetention time.Duration
+
+	// AWS connection, for running against an emulator such as LocalStack:
+	// an endpoint for every service, per-service endpoint overrides (see
+	// awsclient.ParseEndpoints), path-style S3 addressing, and static
+	// credentials used instead of the default credential chain
+	AWSEndpointURL     string
+	AWSEndpoints       string
+	AWSS3PathStyle     bool
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+
+	// AccountsFile lists the other accounts clients can be provisioned in,
+	// and the role assumed in each (see accounts.Load)
+	AccountsFile string
+
+	// BlueprintsDir holds the blueprints of the tiers clients can be
+	// provisioned with (see blueprints.Load), and DefaultTier is the tier of
+	// requests that name none. Without blueprints only the default tier
+	// exists, with every resource of the standard stack.
+	BlueprintsDir string
+	DefaultTier   string
+
+	// HTTP server: the address to listen on, the TLS certificate and key
+	// (reloaded when they change) and the CA that client certificates must
+	// be signed by for mutual TLS, the server timeouts, the largest request
+	// body accepted, and how long shutdown waits for requests and jobs
+	ListenAddr        string
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxBodyBytes      int
+	ShutdownGrace     time.Duration
+
+	// IAM propagation handling: how long to wait for a new role to become
+	// visible, and how Lambda function creation is retried while the role
+	// cannot yet be assumed
+	IAMRoleWaitTimeout     time.Duration
+	LambdaCreateAttempts   int
+	LambdaCreateBackoff    time.Duration
+	LambdaCreateMaxBackoff time.Duration
+
+	// Authentication: hashed API keys (see auth.ParseAPIKeys) and the HMAC
+	// secret and expected issuer of bearer tokens. AuthDisabled turns
+	// authentication off for local development.
+	APIKeys         string
+	AuthTokenSecret string
+	AuthTokenIssuer string
+	AuthDisabled    bool
+
+	// Tracing: the span exporter, one of none, otlp, stdout or file, and the
+	// file the file exporter writes to
+	TracingExporter string
+	TracingFile     string
+
+	// Readiness: how long readiness check results are reused, and how long
+	// each check may take
+	HealthCacheTTL     time.Duration
+	HealthCheckTimeout time.Duration
+
+	// ConfigFile is the file the configuration was read from, if any
+	ConfigFile string
 }
 
-func Load() (*Config, error) {
-	env := os.Getenv("APP_ENV")
+// Default returns the configuration used for anything not set explicitly.
+func Default() *Config {
+	return &Config{
+		AWSRegion:    "us-east-1",
+		Environment:  "dev",
+		LogLevel:     "info",
+		LogFormat:    "text",
+		StateStore:   "file",
+		StateDir:     "data/state",
+		AuditLog:     "file",
+		AuditLogPath: "data/audit/audit.jsonl",
+		JobWorkers:   4,
+		JobQueueSize: 100,
+		JobRetention: 24 * time.Hour,
+
+		DefaultTier: "standard",
+
+		ListenAddr:        ":8080",
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxBodyBytes:      1 << 20,
+		ShutdownGrace:     5 * time.Minute,
+
+		IAMRoleWaitTimeout:     2 * time.Minute,
+		LambdaCreateAttempts:   8,
+		LambdaCreateBackoff:    time.Second,
+		LambdaCreateMaxBackoff: 20 * time.Second,
+
+		TracingExporter: "none",
+		TracingFile:     "data/traces/traces.jsonl",
+
+		HealthCacheTTL:     30 * time.Second,
+		HealthCheckTimeout: 5 * time.Second,
+	}
+}
+
+// Load builds the configuration from the defaults, the settings in .env and
+// configs/<environment>/app.env, the config file named by the -config flag or
+// CONFIG_FILE, the environment and the command-line arguments args, each
+// overriding the ones before, and validates it. Other variables in the .env
+// files, such as those read by the AWS SDK, are added to the environment if
+// they are not already set.
+//
+// For -h it returns flag.ErrHelp after printing the usage.
+func Load(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("provisioner", flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML or JSON configuration file (env CONFIG_FILE)")
+	values := make(map[string]*flagValue, len(settings))
+	defaults := Default()
+	for _, s := range settings {
+		v := &flagValue{isBool: s.isBool, defaultValue: s.get(defaults)}
+		values[s.key] = v
+		flags.Var(v, s.flagName(), fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	// The environment's .env file is picked before anything else is read, so
+	// only the flag and APP_ENV can choose it
+	env := values["environment"].value
+	if env == "" {
+		env = os.Getenv("APP_ENV")
+	}
 	if env == "" {
-		env = "dev"
+		env = defaults.Environment
+	}
+	fromFiles, err := readEnvFiles(".env", fmt.Sprintf("configs/%s/app.env", env))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	var errs []error
+
+	for _, s := range settings {
+		if value := fromFiles.values[s.env]; value != "" {
+			if err := s.set(cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s in %s: %w", s.env, fromFiles.source[s.env], err))
+			}
+		}
+	}
+
+	cfg.ConfigFile = *configFile
+	if cfg.ConfigFile == "" {
+		cfg.ConfigFile = os.Getenv("CONFIG_FILE")
+	}
+	if cfg.ConfigFile == "" {
+		cfg.ConfigFile = fromFiles.values["CONFIG_FILE"]
+	}
+	if cfg.ConfigFile != "" {
+		if err := cfg.loadFile(cfg.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			if err := s.set(cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	for _, s := range settings {
+		if given[s.flagName()] {
+			if err := s.set(cfg, values[s.key].value); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", s.flagName(), err))
+			}
+		}
 	}
 
-	// Load environment-specific .env file
-	envFile := fmt.Sprintf("configs/%s/app.env", env)
-	if err := godotenv.Load(envFile); err != nil {
-		return nil, fmt.Errorf("error loading %s: %w", envFile, err)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
 
-	config := &Config{
-		AWSRegion:    getEnvOrDefault("AWS_REGION", "us-east-1"),
-		AWSAccountID: os.Getenv("AWS_ACCOUNT_ID"),
-		Environment:  env,
-		LogLevel:     getEnvOrDefault("LOG_LEVEL", "info"),
+// envFiles holds the variables read from .env files, with the file each
+// came from.
+type envFiles struct {
+	values map[string]string
+	source map[string]string
+}
+
+// readEnvFiles reads the variables in whichever of files exist, the first
+// file to set a variable winning. Settings and CONFIG_FILE are kept for Load
+// to apply below the config file; any other variable is added to the
+// environment unless it is already set.
+func readEnvFiles(files ...string) (*envFiles, error) {
+	known := map[string]bool{"CONFIG_FILE": true}
+	for _, s := range settings {
+		known[s.env] = true
 	}
 
-	if config.AWSAccountID == "" {
-		return nil, fmt.Errorf("AWS_ACCOUNT_ID is required")
+	read := &envFiles{values: make(map[string]string), source: make(map[string]string)}
+	for _, file := range files {
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		values, err := godotenv.Read(file)
+		if err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", file, err)
+		}
+		for key, value := range values {
+			if _, ok := read.values[key]; ok {
+				continue
+			}
+			read.values[key], read.source[key] = value, file
+			if _, set := os.LookupEnv(key); !known[key] && !set {
+				if err := os.Setenv(key, value); err != nil {
+					return nil, fmt.Errorf("error loading %s: %w", file, err)
+				}
+			}
+		}
 	}
+	return read, nil
+}
 
-	return config, nil
+// flagValue holds a flag's raw value until it is applied on top of the
+// other sources.
+type flagValue struct {
+	value        string
+	defaultValue string // only shown in the usage
+	isBool       bool
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
 	}
-	return defaultValue
+	if v.value == "" {
+		return v.defaultValue
+	}
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given as a bare -flag.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
 }
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every setting's environment variable for the test, so the
+// environment the tests run in does not leak into them.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+}
+
+// writeFile writes a config file named name and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	clearEnv(t)
+	file := writeFile(t, "config.yaml", `
+aws_account_id: "123456789012"
+auth_disabled: true
+aws_region: eu-west-1
+job_workers: 2
+job_retention: 12h
+log_level: debug
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("JOB_WORKERS", "6")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := Load([]string{"-log-level", "error"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"default", cfg.JobQueueSize, 100},
+		{"file over default", cfg.AWSRegion, "eu-west-1"},
+		{"file duration", cfg.JobRetention, 12 * time.Hour},
+		{"env over file", cfg.JobWorkers, 6},
+		{"flag over env", cfg.LogLevel, "error"},
+		{"config file recorded", cfg.ConfigFile, file},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// settingEnvs returns the environment variables of the settings.
+func settingEnvs() []string {
+	envs := make([]string, 0, len(settings))
+	for _, s := range settings {
+		envs = append(envs, s.env)
+	}
+	return envs
+}
+
+// chdir changes the working directory to dir for the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// The settings in .env files rank below the config file, while the other
+// variables in them are added to the environment for the AWS SDK.
+func TestLoadEnvFiles(t *testing.T) {
+	clearEnv(t)
+	// Unset rather than empty, as they are where .env files matter
+	t.Setenv("AWS_PROFILE", "")
+	for _, env := range append([]string{"AWS_PROFILE", "CONFIG_FILE"}, settingEnvs()...) {
+		os.Unsetenv(env)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "configs", "dev"), 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	envFiles := map[string]string{
+		".env":                "JOB_QUEUE_SIZE=50\nJOB_WORKERS=3\nAWS_PROFILE=sandbox\n",
+		"configs/dev/app.env": "JOB_QUEUE_SIZE=70\nLOG_LEVEL=debug\nAWS_REGION=us-west-2\nBLUEPRINTS_DIR=configs/blueprints\n",
+	}
+	for name, content := range envFiles {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	chdir(t, dir)
+	file := writeFile(t, "config.yaml", `
+aws_account_id: "123456789012"
+auth_disabled: true
+aws_region: eu-west-1
+blueprints_dir: /etc/provisioner/blueprints
+`)
+	t.Setenv("JOB_WORKERS", "6")
+
+	cfg, err := Load([]string{"-config", file})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"env file over default", cfg.LogLevel, "debug"},
+		{".env over app.env", cfg.JobQueueSize, 50},
+		{"file over env file", cfg.AWSRegion, "eu-west-1"},
+		{"file over env file", cfg.BlueprintsDir, "/etc/provisioner/blueprints"},
+		{"env over env file", cfg.JobWorkers, 6},
+		{"other variables exported", os.Getenv("AWS_PROFILE"), "sandbox"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// A bad value names the file it came from
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("JOB_WORKERS=many\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("JOB_WORKERS", "")
+	if _, err := Load([]string{"-config", file}); err == nil || !strings.Contains(err.Error(), "JOB_WORKERS in .env") {
+		t.Errorf("Load() error = %v, want one naming JOB_WORKERS in .env", err)
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"yaml", "config.yml", "aws_account_id: \"123456789012\"\nauth_disabled: true\n", ""},
+		{"json", "config.json", `{"aws_account_id": "123456789012", "auth_disabled": true, "job_workers": 3}`, ""},
+		{"unknown setting", "config.yaml", "aws_account_id: \"123456789012\"\nauth_disabled: true\njob_wrokers: 3\n", `unknown setting "job_wrokers"`},
+		{"not a single value", "config.yaml", "aws_account_id: \"123456789012\"\nauth_disabled: true\naws_region: [us-east-1]\n", "must be a single value"},
+		{"bad value", "config.json", `{"aws_account_id": "123456789012", "auth_disabled": true, "job_workers": 0}`, "must be a positive integer"},
+		{"unsupported extension", "config.toml", "", "must be .yaml, .yml or .json"},
+		{"malformed", "config.json", `{"aws_region": `, "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			_, err := Load([]string{"-config", writeFile(t, tt.file, tt.content)})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{"bad env value", map[string]string{"JOB_RETENTION": "forever"}, nil, "JOB_RETENTION: must be a positive duration"},
+		{"bad flag value", nil, []string{"-auth-disabled=maybe"}, "-auth-disabled: must be true or false"},
+		{"stray argument", nil, []string{"serve"}, "unexpected arguments"},
+		{"invalid as a whole", map[string]string{"AWS_REGION": "mars-1"}, nil, `aws_region "mars-1" is not a supported AWS region`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("AWS_ACCOUNT_ID", "123456789012")
+			t.Setenv("AUTH_DISABLED", "true")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, err := Load(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	clearEnv(t)
+	// Keep the usage out of the test output
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer devNull.Close()
+	stderr := os.Stderr
+	os.Stderr = devNull
+	defer func() { os.Stderr = stderr }()
+
+	if _, err := Load([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Load(-h) error = %v, want flag.ErrHelp", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"missing account", func(c *Config) { c.AWSAccountID = "" }, "aws_account_id (AWS_ACCOUNT_ID) is required"},
+		{"short account", func(c *Config) { c.AWSAccountID = "1234" }, "must be 12 digits"},
+		{"unsupported region", func(c *Config) { c.AWSRegion = "us-gov-west-1" }, "is not a supported AWS region"},
+		{"bad environment", func(c *Config) { c.Environment = "Prod" }, "environment"},
+		{"bad log level", func(c *Config) { c.LogLevel = "loud" }, "log_level"},
+		{"bad log format", func(c *Config) { c.LogFormat = "xml" }, "log_format"},
+		{"bad state store", func(c *Config) { c.StateStore = "redis" }, "state_store"},
+		{"state dir missing", func(c *Config) { c.StateDir = "" }, "state_dir is required"},
+		{"no authentication", func(c *Config) { c.AuthDisabled = false }, "api_keys (API_KEYS) or auth_token_secret"},
+		{"backoff above maximum", func(c *Config) { c.LambdaCreateBackoff = time.Minute }, "lambda_create_backoff"},
+		{"listen address without port", func(c *Config) { c.ListenAddr = "localhost" }, "listen_addr"},
+		{"TLS", func(c *Config) { c.TLSCertFile, c.TLSKeyFile, c.TLSClientCAFile = "tls.crt", "tls.key", "ca.crt" }, ""},
+		{"TLS certificate without key", func(c *Config) { c.TLSCertFile = "tls.crt" }, "must be set together"},
+		{"client CA without TLS", func(c *Config) { c.TLSClientCAFile = "ca.crt" }, "tls_client_ca_file requires"},
+		{"emulator", func(c *Config) {
+			c.AWSEndpointURL, c.AWSEndpoints = "http://localhost:4566", "sts=http://localhost:4592"
+			c.AWSAccessKeyID, c.AWSSecretAccessKey, c.AWSSessionToken = "test", "test", "test"
+		}, ""},
+		{"bad endpoint url", func(c *Config) { c.AWSEndpointURL = "localhost:4566" }, "aws_endpoint_url"},
+		{"endpoint of an unknown service", func(c *Config) { c.AWSEndpoints = "dynamodb=http://localhost:4566" }, "aws_endpoints"},
+		{"access key without secret", func(c *Config) { c.AWSAccessKeyID = "test" }, "must be set together"},
+		{"session token alone", func(c *Config) { c.AWSSessionToken = "test" }, "aws_session_token requires"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.AWSAccountID = "123456789012"
+			cfg.AuthDisabled = true
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.AWSRegion = "mars-1"
+	cfg.LogFormat = "xml"
+	err := cfg.Validate()
+	for _, want := range []string{"aws_region", "aws_account_id", "log_format", "api_keys"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.AWSSecretAccessKey = "hunter2"
+	cfg.AuthTokenSecret = "s3cret"
+
+	summary := cfg.LogValue().String()
+	for _, secret := range []string{"hunter2", "s3cret"} {
+		if strings.Contains(summary, secret) {
+			t.Errorf("summary %q exposes a secret", summary)
+		}
+	}
+	if !strings.Contains(summary, "auth_token_secret="+redacted) || !strings.Contains(summary, "aws_region=us-east-1") {
+		t.Errorf("summary %q does not show the settings", summary)
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is one configuration value and the names it goes by in each
+// source: key in the config file, key with dashes as the flag, and env.
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	secret bool
+	isBool bool
+	set    func(c *Config, value string) error
+	get    func(c *Config) string
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+// settings lists every setting, in the order the startup summary shows them.
+var settings = []setting{
+	stringSetting("aws_region", "AWS_REGION", "AWS region of the service, and to provision in by default", func(c *Config) *string { return &c.AWSRegion }),
+	stringSetting("aws_account_id", "AWS_ACCOUNT_ID", "AWS account to provision in", func(c *Config) *string { return &c.AWSAccountID }),
+	stringSetting("environment", "APP_ENV", "environment name, used as a prefix of resource names", func(c *Config) *string { return &c.Environment }),
+	stringSetting("log_level", "LOG_LEVEL", "debug, info, warn or error", func(c *Config) *string { return &c.LogLevel }),
+	stringSetting("log_format", "LOG_FORMAT", "text or json", func(c *Config) *string { return &c.LogFormat }),
+	stringSetting("state_store", "STATE_STORE", "file or memory", func(c *Config) *string { return &c.StateStore }),
+	stringSetting("state_dir", "STATE_DIR", "directory of the file state store", func(c *Config) *string { return &c.StateDir }),
+	stringSetting("audit_log", "AUDIT_LOG", "file or memory", func(c *Config) *string { return &c.AuditLog }),
+	stringSetting("audit_log_path", "AUDIT_LOG_PATH", "file of the file audit log", func(c *Config) *string { return &c.AuditLogPath }),
+	intSetting("job_workers", "JOB_WORKERS", "number of jobs run at once", func(c *Config) *int { return &c.JobWorkers }),
+	intSetting("job_queue_size", "JOB_QUEUE_SIZE", "number of jobs that can wait for a worker", func(c *Config) *int { return &c.JobQueueSize }),
+	durationSetting("job_retention", "JOB_RETENTION", "how long finished jobs can be looked up", func(c *Config) *time.Duration { return &c.JobRetention }),
+	stringSetting("aws_endpoint_url", "AWS_ENDPOINT_URL", "endpoint of every AWS service, e.g. of LocalStack", func(c *Config) *string { return &c.AWSEndpointURL }),
+	stringSetting("aws_endpoints", "AWS_ENDPOINTS", "per-service endpoints as service=url,...", func(c *Config) *string { return &c.AWSEndpoints }),
+	boolSetting("aws_s3_path_style", "AWS_S3_PATH_STYLE", "address S3 buckets by path instead of by host name", func(c *Config) *bool { return &c.AWSS3PathStyle }),
+	stringSetting("aws_access_key_id", "AWS_ACCESS_KEY_ID", "static AWS access key, used instead of the default credential chain", func(c *Config) *string { return &c.AWSAccessKeyID }),
+	secretSetting("aws_secret_access_key", "AWS_SECRET_ACCESS_KEY", "secret of the static AWS access key", func(c *Config) *string { return &c.AWSSecretAccessKey }),
+	secretSetting("aws_session_token", "AWS_SESSION_TOKEN", "session token of temporary static AWS credentials", func(c *Config) *string { return &c.AWSSessionToken }),
+	stringSetting("accounts_file", "ACCOUNTS_FILE", "YAML or JSON file of other AWS accounts to provision in and the role to assume in each", func(c *Config) *string { return &c.AccountsFile }),
+	stringSetting("blueprints_dir", "BLUEPRINTS_DIR", "directory of YAML blueprints of the tiers clients can be provisioned with", func(c *Config) *string { return &c.BlueprintsDir }),
+	stringSetting("default_tier", "DEFAULT_TIER", "tier of requests that name none", func(c *Config) *string { return &c.DefaultTier }),
+	stringSetting("listen_addr", "LISTEN_ADDR", "address the HTTP server listens on", func(c *Config) *string { return &c.ListenAddr }),
+	stringSetting("tls_cert_file", "TLS_CERT_FILE", "PEM certificate to serve HTTPS with, reloaded when it changes", func(c *Config) *string { return &c.TLSCertFile }),
+	stringSetting("tls_key_file", "TLS_KEY_FILE", "PEM private key of the certificate", func(c *Config) *string { return &c.TLSKeyFile }),
+	stringSetting("tls_client_ca_file", "TLS_CLIENT_CA_FILE", "PEM CA bundle client certificates must be signed by, enabling mutual TLS", func(c *Config) *string { return &c.TLSClientCAFile }),
+	durationSetting("read_header_timeout", "READ_HEADER_TIMEOUT", "how long a client may take to send request headers", func(c *Config) *time.Duration { return &c.ReadHeaderTimeout }),
+	durationSetting("read_timeout", "READ_TIMEOUT", "how long a client may take to send a whole request", func(c *Config) *time.Duration { return &c.ReadTimeout }),
+	durationSetting("write_timeout", "WRITE_TIMEOUT", "how long writing a response may take", func(c *Config) *time.Duration { return &c.WriteTimeout }),
+	durationSetting("idle_timeout", "IDLE_TIMEOUT", "how long an idle keep-alive connection is kept open", func(c *Config) *time.Duration { return &c.IdleTimeout }),
+	intSetting("max_body_bytes", "MAX_BODY_BYTES", "largest request body accepted, in bytes", func(c *Config) *int { return &c.MaxBodyBytes }),
+	durationSetting("shutdown_grace", "SHUTDOWN_GRACE", "how long shutdown waits for requests and running jobs before interrupting them", func(c *Config) *time.Duration { return &c.ShutdownGrace }),
+	durationSetting("iam_role_wait_timeout", "IAM_ROLE_WAIT_TIMEOUT", "how long to wait for a new IAM role to become visible", func(c *Config) *time.Duration { return &c.IAMRoleWaitTimeout }),
+	intSetting("lambda_create_attempts", "LAMBDA_CREATE_ATTEMPTS", "attempts at creating a Lambda function while its role propagates", func(c *Config) *int { return &c.LambdaCreateAttempts }),
+	durationSetting("lambda_create_backoff", "LAMBDA_CREATE_BACKOFF", "first delay between Lambda creation attempts", func(c *Config) *time.Duration { return &c.LambdaCreateBackoff }),
+	durationSetting("lambda_create_max_backoff", "LAMBDA_CREATE_MAX_BACKOFF", "longest delay between Lambda creation attempts", func(c *Config) *time.Duration { return &c.LambdaCreateMaxBackoff }),
+	secretSetting("api_keys", "API_KEYS", "hashed API keys", func(c *Config) *string { return &c.APIKeys }),
+	secretSetting("auth_token_secret", "AUTH_TOKEN_SECRET", "HMAC secret of bearer tokens", func(c *Config) *string { return &c.AuthTokenSecret }),
+	stringSetting("auth_token_issuer", "AUTH_TOKEN_ISSUER", "expected issuer of bearer tokens", func(c *Config) *string { return &c.AuthTokenIssuer }),
+	boolSetting("auth_disabled", "AUTH_DISABLED", "treat every request as an admin, for local development only", func(c *Config) *bool { return &c.AuthDisabled }),
+	stringSetting("tracing_exporter", "TRACING_EXPORTER", "none, otlp, stdout or file", func(c *Config) *string { return &c.TracingExporter }),
+	stringSetting("tracing_file", "TRACING_FILE", "file of the file trace exporter", func(c *Config) *string { return &c.TracingFile }),
+	durationSetting("health_cache_ttl", "HEALTH_CACHE_TTL", "how long readiness check results are reused", func(c *Config) *time.Duration { return &c.HealthCacheTTL }),
+	durationSetting("health_check_timeout", "HEALTH_CHECK_TIMEOUT", "how long each readiness check may take", func(c *Config) *time.Duration { return &c.HealthCheckTimeout }),
+}
+
+func stringSetting(key, env, usage string, field func(*Config) *string) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		usage: usage,
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		get: func(c *Config) string { return *field(c) },
+	}
+}
+
+// secretSetting is a string setting the startup summary redacts.
+func secretSetting(key, env, usage string, field func(*Config) *string) setting {
+	s := stringSetting(key, env, usage, field)
+	s.secret = true
+	return s
+}
+
+func intSetting(key, env, usage string, field func(*Config) *int) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		usage: usage,
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("must be a positive integer, got %q", value)
+			}
+			*field(c) = n
+			return nil
+		},
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+	}
+}
+
+func durationSetting(key, env, usage string, field func(*Config) *time.Duration) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		usage: usage,
+		set: func(c *Config, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("must be a positive duration such as 30s or 1h, got %q", value)
+			}
+			*field(c) = d
+			return nil
+		},
+		get: func(c *Config) string { return field(c).String() },
+	}
+}
+
+func boolSetting(key, env, usage string, field func(*Config) *bool) setting {
+	return setting{
+		key:    key,
+		env:    env,
+		usage:  usage,
+		isBool: true,
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("must be true or false, got %q", value)
+			}
+			*field(c) = b
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "log/slog"
+
+// redacted replaces the value of secret settings in the startup summary.
+const redacted = "[REDACTED]"
+
+// LogValue summarizes the configuration for the startup log, with secrets
+// redacted.
+func (c *Config) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(settings)+1)
+	if c.ConfigFile != "" {
+		attrs = append(attrs, slog.String("config_file", c.ConfigFile))
+	}
+	for _, s := range settings {
+		value := s.get(c)
+		if s.secret && value != "" {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(s.key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// validRegions are the commercial AWS regions. The provisioner builds ARNs
+// in the aws partition, so GovCloud and China regions are not supported.
+var validRegions = map[string]bool{
+	"us-east-1":      true,
+	"us-east-2":      true,
+	"us-west-1":      true,
+	"us-west-2":      true,
+	"af-south-1":     true,
+	"ap-east-1":      true,
+	"ap-south-1":     true,
+	"ap-south-2":     true,
+	"ap-southeast-1": true,
+	"ap-southeast-2": true,
+	"ap-southeast-3": true,
+	"ap-southeast-4": true,
+	"ap-southeast-5": true,
+	"ap-southeast-7": true,
+	"ap-northeast-1": true,
+	"ap-northeast-2": true,
+	"ap-northeast-3": true,
+	"ca-central-1":   true,
+	"ca-west-1":      true,
+	"eu-central-1":   true,
+	"eu-central-2":   true,
+	"eu-west-1":      true,
+	"eu-west-2":      true,
+	"eu-west-3":      true,
+	"eu-south-1":     true,
+	"eu-south-2":     true,
+	"eu-north-1":     true,
+	"il-central-1":   true,
+	"me-south-1":     true,
+	"me-central-1":   true,
+	"mx-central-1":   true,
+	"sa-east-1":      true,
+}
+
+// ValidRegion reports whether clients can be provisioned in region.
+func ValidRegion(region string) bool {
+	return validRegions[region]
+}
+
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// environmentPattern keeps environment names usable as a prefix of bucket,
+// role and function names.
+var environmentPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,15}$`)
+
+// Validate checks every setting and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validRegions[c.AWSRegion], "aws_region %q is not a supported AWS region", c.AWSRegion)
+	check(c.AWSAccountID != "", "aws_account_id (AWS_ACCOUNT_ID) is required")
+	check(c.AWSAccountID == "" || accountIDPattern.MatchString(c.AWSAccountID), "aws_account_id %q must be 12 digits", c.AWSAccountID)
+	check(environmentPattern.MatchString(c.Environment),
+		"environment %q must be up to 16 lowercase letters, digits and hyphens, starting with a letter", c.Environment)
+
+	if c.AWSEndpointURL != "" {
+		err := awsclient.ValidateEndpoint(c.AWSEndpointURL)
+		check(err == nil, "aws_endpoint_url: %v", err)
+	}
+	_, err := awsclient.ParseEndpoints(c.AWSEndpoints)
+	check(err == nil, "aws_endpoints: %v", err)
+	check((c.AWSAccessKeyID == "") == (c.AWSSecretAccessKey == ""), "aws_access_key_id and aws_secret_access_key must be set together")
+	check(c.AWSSessionToken == "" || c.AWSAccessKeyID != "", "aws_session_token requires aws_access_key_id and aws_secret_access_key")
+
+	check(c.DefaultTier != "", "default_tier is required")
+
+	_, err = logger.ParseLevel(c.LogLevel)
+	check(err == nil, "log_level: %v", err)
+	check(oneOf(c.LogFormat, logger.FormatText, logger.FormatJSON), "log_format %q must be text or json", c.LogFormat)
+
+	check(oneOf(c.StateStore, "file", "memory"), "state_store %q must be file or memory", c.StateStore)
+	check(c.StateStore != "file" || c.StateDir != "", "state_dir is required for the file state store")
+	check(oneOf(c.AuditLog, "file", "memory"), "audit_log %q must be file or memory", c.AuditLog)
+	check(c.AuditLog != "file" || c.AuditLogPath != "", "audit_log_path is required for the file audit log")
+
+	_, _, err = net.SplitHostPort(c.ListenAddr)
+	check(err == nil, "listen_addr %q must be host:port or :port", c.ListenAddr)
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "tls_cert_file and tls_key_file must be set together")
+	check(c.TLSClientCAFile == "" || c.TLSCertFile != "", "tls_client_ca_file requires tls_cert_file and tls_key_file")
+
+	check(c.LambdaCreateBackoff <= c.LambdaCreateMaxBackoff,
+		"lambda_create_backoff %s must not exceed lambda_create_max_backoff %s", c.LambdaCreateBackoff, c.LambdaCreateMaxBackoff)
+
+	check(c.AuthDisabled || c.APIKeys != "" || c.AuthTokenSecret != "",
+		"api_keys (API_KEYS) or auth_token_secret (AUTH_TOKEN_SECRET) is required unless auth_disabled is true")
+
+	check(oneOf(c.TracingExporter, "none", "otlp", "stdout", "file"), "tracing_exporter %q must be none, otlp, stdout or file", c.TracingExporter)
+	check(c.TracingExporter != "file" || c.TracingFile != "", "tracing_file is required for the file trace exporter")
+
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/provisioner"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// ReadinessChecks are the checks the service must pass before it can
+// provision: valid credentials for the configured account, a cheap read-only
+// call to each service the provisioner uses, the shared IAM policy client
+// roles are attached to, the state store, and for each registered account
+// that its role can be assumed and has the shared policy.
+func ReadinessChecks(cfg *config.Config, awsClient *awsclient.AWSClient, registry *accounts.Registry, store state.Store) []Check {
+	checks := []Check{
+		{Name: "aws_credentials", Run: func(ctx context.Context) error {
+			return checkIdentity(ctx, awsClient, cfg.AWSAccountID)
+		}},
+		{Name: "s3", Run: func(ctx context.Context) error {
+			_, err := awsClient.S3Client.ListBuckets(ctx, &s3.ListBucketsInput{})
+			return err
+		}},
+		{Name: "iam_shared_policy", Run: func(ctx context.Context) error {
+			_, err := awsClient.IAMClient.GetPolicy(ctx, &iam.GetPolicyInput{
+				PolicyArn: aws.String(provisioner.SharedPolicyARN(cfg.AWSAccountID)),
+			})
+			return err
+		}},
+		{Name: "cloudwatch", Run: func(ctx context.Context) error {
+			_, err := awsClient.CloudWatchClient.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{MaxRecords: aws.Int32(1)})
+			return err
+		}},
+		{Name: "cloudwatch_logs", Run: func(ctx context.Context) error {
+			_, err := awsClient.CloudWatchLogsClient.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{Limit: aws.Int32(1)})
+			return err
+		}},
+		{Name: "eventbridge", Run: func(ctx context.Context) error {
+			_, err := awsClient.EventBridgeClient.ListRules(ctx, &eventbridge.ListRulesInput{Limit: aws.Int32(1)})
+			return err
+		}},
+		{Name: "lambda", Run: func(ctx context.Context) error {
+			_, err := awsClient.LambdaClient.ListFunctions(ctx, &lambda.ListFunctionsInput{MaxItems: aws.Int32(1)})
+			return err
+		}},
+		{Name: "sns", Run: func(ctx context.Context) error {
+			_, err := awsClient.SNSClient.ListTopics(ctx, &sns.ListTopicsInput{})
+			return err
+		}},
+		{Name: "state_store", Run: store.Ping},
+	}
+
+	for _, accountID := range registry.IDs() {
+		clients, _ := registry.Clients(accountID)
+		checks = append(checks, Check{Name: "account_" + accountID, Run: func(ctx context.Context) error {
+			client, err := clients.Client(ctx, cfg.AWSRegion)
+			if err != nil {
+				return err
+			}
+			if err := checkIdentity(ctx, client, accountID); err != nil {
+				return err
+			}
+			_, err = client.IAMClient.GetPolicy(ctx, &iam.GetPolicyInput{
+				PolicyArn: aws.String(provisioner.SharedPolicyARN(accountID)),
+			})
+			return err
+		}})
+	}
+	return checks
+}
+
+// checkIdentity checks that the client's credentials work and belong to the
+// account.
+func checkIdentity(ctx context.Context, client *awsclient.AWSClient, accountID string) error {
+	identity, err := client.STSClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return err
+	}
+	if account := aws.ToString(identity.Account); account != accountID {
+		return fmt.Errorf("credentials belong to account %s, expected %s", account, accountID)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+)
+
+func TestReadinessChecks(t *testing.T) {
+	const account, otherAccount = "123456789012", "210987654321"
+
+	tests := []struct {
+		name       string
+		accountID  string
+		failOn     string
+		otherReady bool
+		wantFailed []string
+	}{
+		{name: "ready", accountID: account, otherReady: true},
+		{name: "credentials of another account", accountID: "999999999999", otherReady: true, wantFailed: []string{"aws_credentials", "iam_shared_policy"}},
+		{name: "service unreachable", accountID: account, failOn: "lambda:ListFunctions", otherReady: true, wantFailed: []string{"lambda"}},
+		{name: "shared policy missing in an account", accountID: account, wantFailed: []string{"account_" + otherAccount}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.AWSAccountID = tt.accountID
+			cloud := fake.New(account, cfg.AWSRegion)
+			cloud.IAM.AddPolicy("go-infra-policy")
+			if tt.failOn != "" {
+				cloud.FailOn(tt.failOn, errors.New("injected failure"))
+			}
+			other := fake.New(otherAccount, cfg.AWSRegion)
+			if tt.otherReady {
+				other.IAM.AddPolicy("go-infra-policy")
+			}
+			registry := accounts.NewRegistry([]accounts.Account{{ID: otherAccount}}, func(accounts.Account) *awsclient.Factory {
+				return fake.NewFactory(other)
+			})
+
+			checks := ReadinessChecks(cfg, cloud.Client(), registry, state.NewMemoryStore())
+			report := NewChecker(0, time.Second, checks...).Check(context.Background())
+
+			var failed []string
+			for _, check := range checks {
+				if report.Checks[check.Name].Status != StatusOK {
+					failed = append(failed, check.Name)
+				}
+			}
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("failed checks = %v, want %v", failed, tt.wantFailed)
+			}
+			if report.Ready() != (len(tt.wantFailed) == 0) {
+				t.Errorf("Ready() = %v with failed checks %v", report.Ready(), failed)
+			}
+		})
+	}
+}
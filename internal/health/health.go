@@ -0,0 +1,128 @@
+// Package health reports whether the service is ready to provision: whether
+// its AWS credentials are valid for the configured account, the AWS services
+// it uses are reachable and its state store is available.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Readiness statuses
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// defaultCheckTimeout applies when NewChecker is given no timeout.
+const defaultCheckTimeout = 5 * time.Second
+
+// Check is a single readiness check. Run returns nil if the dependency it
+// checks is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of one check. Only the status is served: readiness
+// needs no authentication, and errors can name accounts and resources, so
+// the error and duration are for the logs.
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"-"`
+	DurationMS int64  `json:"-"`
+}
+
+// Report is the outcome of every check. The service is ready only if they
+// all passed.
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+func (r *Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Checker runs the checks and caches the report, so frequent probes do not
+// each make a round of AWS calls.
+type Checker struct {
+	checks  []Check
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewChecker returns a checker that reruns checks once its report is older
+// than ttl, giving each check up to timeout. A zero ttl disables caching.
+func NewChecker(ttl, timeout time.Duration, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	return &Checker{checks: checks, ttl: ttl, timeout: timeout}
+}
+
+// Check returns the cached report, running the checks first if it is stale.
+// Concurrent callers wait for a single run.
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.report != nil && time.Since(c.report.CheckedAt) < c.ttl {
+		return c.report
+	}
+	// The report is shared, so a caller giving up must not fail it
+	c.report = c.run(context.WithoutCancel(ctx))
+	return c.report
+}
+
+// run runs every check concurrently.
+func (c *Checker) run(ctx context.Context) *Report {
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status:    StatusReady,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]Result, len(c.checks)),
+	}
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
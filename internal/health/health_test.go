@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []error
+		wantStatus string
+	}{
+		{"all pass", []error{nil, nil}, StatusReady},
+		{"one fails", []error{nil, errors.New("unreachable")}, StatusNotReady},
+		{"no checks", nil, StatusReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checks []Check
+			for i, err := range tt.checks {
+				checks = append(checks, Check{Name: string(rune('a' + i)), Run: func(context.Context) error { return err }})
+			}
+
+			report := NewChecker(0, time.Second, checks...).Check(context.Background())
+			if report.Status != tt.wantStatus || report.Ready() != (tt.wantStatus == StatusReady) {
+				t.Errorf("Check() status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			for i, err := range tt.checks {
+				result := report.Checks[string(rune('a'+i))]
+				if (err == nil) != (result.Status == StatusOK) || (err != nil && result.Error != err.Error()) {
+					t.Errorf("check %c = %+v, want error %v", 'a'+i, result, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerCaches(t *testing.T) {
+	var runs atomic.Int32
+	check := Check{Name: "slow", Run: func(context.Context) error {
+		runs.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}}
+
+	cached := NewChecker(time.Hour, time.Second, check)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cached.Check(context.Background())
+		}()
+	}
+	wg.Wait()
+	if got := runs.Load(); got != 1 {
+		t.Errorf("concurrent Check() ran the checks %d times, want 1", got)
+	}
+
+	uncached := NewChecker(0, time.Second, check)
+	uncached.Check(context.Background())
+	uncached.Check(context.Background())
+	if got := runs.Load(); got != 3 {
+		t.Errorf("checks ran %d times in all, want 3 with caching disabled", got)
+	}
+}
+
+func TestCheckerTimesOut(t *testing.T) {
+	check := Check{Name: "hanging", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	// A caller giving up does not fail the shared report; the timeout does
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := NewChecker(time.Hour, 10*time.Millisecond, check).Check(ctx)
+	result := report.Checks["hanging"]
+	if result.Status != StatusFailed || result.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("hanging check = %+v, want it failed by the timeout", result)
+	}
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Step statuses
+const (
+	StepRunning   = "running"
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+)
+
+type Job struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	ClientID string      `json:"client_id"`
+	Status   string      `json:"status"`
+	Steps    []Step      `json:"steps"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	// ErrorDetail is set when the job failed with a classified error
+	ErrorDetail *models.ProvisionError `json:"error_detail,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	FinishedAt  *time.Time             `json:"finished_at,omitempty"`
+}
+
+type Step struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMS int64      `json:"duration_ms,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func (j *Job) done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Progress records the steps of a running job. It is handed to the job's
+// function, which reports each step as it starts and finishes.
+type Progress struct {
+	mu  *sync.RWMutex
+	job *Job
+}
+
+func (p *Progress) StepStarted(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.job.Steps = append(p.job.Steps, Step{
+		Name:      name,
+		Status:    StepRunning,
+		StartedAt: time.Now().UTC(),
+	})
+}
+
+func (p *Progress) StepFinished(name string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Finish the most recent step with this name that is still running
+	for i := len(p.job.Steps) - 1; i >= 0; i-- {
+		step := &p.job.Steps[i]
+		if step.Name != name || step.Status != StepRunning {
+			continue
+		}
+
+		now := time.Now().UTC()
+		step.FinishedAt = &now
+		step.DurationMS = now.Sub(step.StartedAt).Milliseconds()
+		step.Status = StepSucceeded
+		if err != nil {
+			step.Status = StepFailed
+			step.Error = models.ClientMessage(err)
+		}
+		return
+	}
+}
@@ -0,0 +1,248 @@
+// Package jobs runs long operations such as provisioning in a pool of
+// background workers, detached from the HTTP request that submitted them,
+// and keeps their progress so it can be queried later.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+var (
+	ErrQueueFull  = errors.New("jobs: queue is full")
+	ErrShutdown   = errors.New("jobs: manager is shutting down")
+	ErrClientBusy = errors.New("jobs: a job is already in progress for this client")
+)
+
+// Func is the work a job performs. Its result is stored on the job, also
+// alongside an error, such as the per-region results of a run that failed in
+// only some regions.
+type Func func(ctx context.Context, progress *Progress) (interface{}, error)
+
+type task struct {
+	job *Job
+	fn  Func
+}
+
+type Manager struct {
+	queue     chan task
+	retention time.Duration
+	metrics   *metrics.Metrics
+	logger    *logger.Logger
+
+	// ctx is the parent of every job's context; it is independent of the
+	// requests that submit jobs and is only cancelled on forced shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	jobs    map[string]*Job
+	active  map[string]string // client ID -> job ID
+	closing bool
+}
+
+// NewManager starts workers goroutines pulling from a queue of queueSize
+// jobs. Finished jobs are kept for retention before being forgotten.
+func NewManager(workers, queueSize int, retention time.Duration, metrics *metrics.Metrics, logger *logger.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		queue:     make(chan task, queueSize),
+		retention: retention,
+		metrics:   metrics,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+		jobs:      make(map[string]*Job),
+		active:    make(map[string]string),
+	}
+
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	return m
+}
+
+// Submit queues fn as a new job for the client. Only one job per client may be
+// queued or running at a time.
+func (m *Manager) Submit(jobType, clientID string, fn Func) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:        id,
+		Type:      jobType,
+		ClientID:  clientID,
+		Status:    StatusQueued,
+		Steps:     []Step{},
+		CreatedAt: time.Now().UTC(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closing {
+		return nil, ErrShutdown
+	}
+	if _, busy := m.active[clientID]; busy {
+		return nil, ErrClientBusy
+	}
+	m.prune()
+
+	select {
+	case m.queue <- task{job: job, fn: fn}:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	m.jobs[id] = job
+	m.active[clientID] = id
+	return snapshot(job), nil
+}
+
+// Get returns a copy of the job with the given ID.
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	return snapshot(job), true
+}
+
+// Active returns a copy of the client's queued or running job, if any.
+func (m *Manager) Active(clientID string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.active[clientID]
+	if !ok {
+		return nil, false
+	}
+	return snapshot(m.jobs[id]), true
+}
+
+// Shutdown stops accepting jobs and waits for queued and running jobs to
+// finish. If ctx expires first, running jobs are cancelled and Shutdown
+// returns ctx's error once they have stopped.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closing {
+		m.closing = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.cancel()
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) worker() {
+	defer m.wg.Done()
+
+	for t := range m.queue {
+		m.run(t)
+	}
+}
+
+func (m *Manager) run(t task) {
+	job := t.job
+
+	m.mu.Lock()
+	now := time.Now().UTC()
+	job.Status = StatusRunning
+	job.StartedAt = &now
+	m.mu.Unlock()
+
+	// The job's work logs with the job's ID
+	log := m.logger.With(logger.JobID, job.ID, "job_type", job.Type)
+	log.Info("Starting job", logger.ClientID, job.ClientID)
+	m.metrics.JobStarted(job.Type)
+
+	result, err := m.call(logger.WithContext(m.ctx, log), t, &Progress{mu: &m.mu, job: job})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	job.Result = result
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = models.ClientMessage(err)
+		var perr *models.ProvisionError
+		if errors.As(err, &perr) {
+			job.ErrorDetail = perr
+		}
+		log.Error("Job failed", logger.ClientID, job.ClientID, logger.Err(err))
+	} else {
+		job.Status = StatusSucceeded
+		log.Info("Job succeeded", logger.ClientID, job.ClientID)
+	}
+	delete(m.active, job.ClientID)
+	m.metrics.JobFinished(job.Type, job.Status)
+}
+
+// call runs the job's function, turning a panic into a job failure so one bad
+// job cannot take a worker down.
+func (m *Manager) call(ctx context.Context, t task, progress *Progress) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return t.fn(ctx, progress)
+}
+
+// prune forgets finished jobs older than the retention period. The caller
+// must hold m.mu.
+func (m *Manager) prune() {
+	cutoff := time.Now().Add(-m.retention)
+	for id, job := range m.jobs {
+		if job.done() && job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func snapshot(job *Job) *Job {
+	out := *job
+	out.Steps = make([]Step, len(job.Steps))
+	copy(out.Steps, job.Steps)
+	return &out
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,288 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+func newTestManager(t *testing.T, workers, queueSize int) *Manager {
+	t.Helper()
+	log, err := logger.New("error", "text", io.Discard)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	m := NewManager(workers, queueSize, time.Hour, nil, log)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = m.Shutdown(ctx)
+	})
+	return m
+}
+
+// wait returns the job once it is done.
+func wait(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := m.Get(id)
+		if !ok {
+			t.Fatalf("job %s is unknown", id)
+		}
+		if job.done() {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestManagerRunsJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		fn         Func
+		wantStatus string
+		wantResult interface{}
+		wantError  bool
+		wantCode   string
+	}{
+		{
+			name: "succeeds",
+			fn: func(ctx context.Context, p *Progress) (interface{}, error) {
+				p.StepStarted("s3_bucket")
+				p.StepFinished("s3_bucket", nil)
+				return "done", nil
+			},
+			wantStatus: StatusSucceeded,
+			wantResult: "done",
+		},
+		{
+			name: "fails with a result",
+			fn: func(ctx context.Context, p *Progress) (interface{}, error) {
+				p.StepStarted("s3_bucket")
+				p.StepFinished("s3_bucket", errors.New("boom"))
+				return "partial", models.NewProvisionError(models.ErrCodeThrottled, "slow down", nil)
+			},
+			wantStatus: StatusFailed,
+			wantResult: "partial",
+			wantError:  true,
+			wantCode:   models.ErrCodeThrottled,
+		},
+		{
+			name: "panics",
+			fn: func(ctx context.Context, p *Progress) (interface{}, error) {
+				panic("bad job")
+			},
+			wantStatus: StatusFailed,
+			wantError:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, 1, 1)
+			job, err := m.Submit("provision", "acme", tt.fn)
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			if job.Status != StatusQueued || job.ID == "" {
+				t.Errorf("submitted job = %+v, want a queued job with an ID", job)
+			}
+
+			job = wait(t, m, job.ID)
+			if job.Status != tt.wantStatus || job.Result != tt.wantResult || (job.Error != "") != tt.wantError {
+				t.Errorf("job = %s, result %v, error %q; want %s, %v, error %v", job.Status, job.Result, job.Error, tt.wantStatus, tt.wantResult, tt.wantError)
+			}
+			if tt.wantCode != "" && (job.ErrorDetail == nil || job.ErrorDetail.Code != tt.wantCode) {
+				t.Errorf("error detail = %+v, want %s", job.ErrorDetail, tt.wantCode)
+			}
+			if job.StartedAt == nil || job.FinishedAt == nil {
+				t.Errorf("job times = %v, %v, want both set", job.StartedAt, job.FinishedAt)
+			}
+			if _, active := m.Active("acme"); active {
+				t.Error("finished job is still active")
+			}
+		})
+	}
+}
+
+func TestProgressSteps(t *testing.T) {
+	m := newTestManager(t, 1, 1)
+	job, err := m.Submit("provision", "acme", func(ctx context.Context, p *Progress) (interface{}, error) {
+		p.StepStarted("iam_role")
+		p.StepStarted("lambda_function")
+		p.StepFinished("iam_role", nil)
+		p.StepFinished("lambda_function", models.NewProvisionError(models.ErrCodeThrottled, "slow down", errors.New("boom")))
+		// Retried steps start again under the same name
+		p.StepStarted("lambda_function")
+		p.StepFinished("lambda_function", nil)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	job = wait(t, m, job.ID)
+	want := []struct{ name, status string }{
+		{"iam_role", StepSucceeded},
+		{"lambda_function", StepFailed},
+		{"lambda_function", StepSucceeded},
+	}
+	if len(job.Steps) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(job.Steps), len(want))
+	}
+	for i, step := range job.Steps {
+		if step.Name != want[i].name || step.Status != want[i].status || step.FinishedAt == nil {
+			t.Errorf("step %d = %+v, want %s %s", i, step, want[i].name, want[i].status)
+		}
+	}
+	if job.Steps[1].Error != "THROTTLED: slow down" {
+		t.Errorf("failed step error = %q, want the code and message only", job.Steps[1].Error)
+	}
+}
+
+// Errors may wrap AWS errors naming accounts and resources, which are logged
+// but kept out of the job clients see.
+func TestJobHidesErrorCause(t *testing.T) {
+	const cause = "arn:aws:iam::123456789012:role/secret is not authorized (request 0f1e2d)"
+	tests := []struct {
+		name        string
+		err         error
+		wantMessage string
+	}{
+		{
+			name:        "provision error",
+			err:         models.NewProvisionError(models.ErrCodeAccessDenied, "failed to apply iam_role", errors.New(cause)),
+			wantMessage: "ACCESS_DENIED: failed to apply iam_role",
+		},
+		{
+			name:        "any other error",
+			err:         fmt.Errorf("failed to create role: %w", errors.New(cause)),
+			wantMessage: "INTERNAL_ERROR: internal error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, 1, 1)
+			job, err := m.Submit("provision", "acme", func(ctx context.Context, p *Progress) (interface{}, error) {
+				p.StepStarted("iam_role")
+				p.StepFinished("iam_role", tt.err)
+				return nil, tt.err
+			})
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+
+			job = wait(t, m, job.ID)
+			if job.Error != tt.wantMessage || job.Steps[0].Error != tt.wantMessage {
+				t.Errorf("job error = %q, step error = %q, want %q", job.Error, job.Steps[0].Error, tt.wantMessage)
+			}
+			body, err := json.Marshal(job)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if strings.Contains(string(body), "123456789012") || strings.Contains(string(body), "0f1e2d") {
+				t.Errorf("job JSON carries the cause: %s", body)
+			}
+		})
+	}
+}
+
+func TestSubmitRejects(t *testing.T) {
+	block := make(chan struct{})
+	blocking := func(ctx context.Context, p *Progress) (interface{}, error) {
+		<-block
+		return nil, nil
+	}
+
+	// Without workers, jobs stay queued
+	m := newTestManager(t, 0, 1)
+	defer close(block)
+	if _, err := m.Submit("provision", "acme", blocking); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		clientID string
+		want     error
+	}{
+		{"client busy", "acme", ErrClientBusy},
+		{"queue full", "beta", ErrQueueFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Submit("deprovision", tt.clientID, blocking); !errors.Is(err, tt.want) {
+				t.Errorf("Submit() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if job, ok := m.Active("acme"); !ok || job.Status != StatusQueued {
+		t.Errorf("Active() = %+v, %v, want the queued job", job, ok)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("waits for running jobs", func(t *testing.T) {
+		m := newTestManager(t, 1, 1)
+		started := make(chan struct{})
+		job, err := m.Submit("provision", "acme", func(ctx context.Context, p *Progress) (interface{}, error) {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			return "done", ctx.Err()
+		})
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		<-started
+
+		if err := m.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+		if job, _ := m.Get(job.ID); job.Status != StatusSucceeded {
+			t.Errorf("job = %s, want it to finish uncancelled", job.Status)
+		}
+		if _, err := m.Submit("provision", "beta", nil); !errors.Is(err, ErrShutdown) {
+			t.Errorf("Submit() after Shutdown(): error = %v, want ErrShutdown", err)
+		}
+	})
+
+	t.Run("cancels jobs when the grace period ends", func(t *testing.T) {
+		m := newTestManager(t, 1, 1)
+		started := make(chan struct{})
+		job, err := m.Submit("provision", "acme", func(ctx context.Context, p *Progress) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Shutdown() error = %v, want DeadlineExceeded", err)
+		}
+		if job, _ := m.Get(job.ID); job.Status != StatusFailed {
+			t.Errorf("job = %s, want it cancelled", job.Status)
+		}
+	})
+}
+
+func TestGetUnknownJob(t *testing.T) {
+	m := newTestManager(t, 1, 1)
+	if _, ok := m.Get("missing"); ok {
+		t.Error("Get() found a job that was never submitted")
+	}
+}
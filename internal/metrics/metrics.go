@@ -0,0 +1,208 @@
+// Package metrics collects the service's Prometheus metrics: HTTP requests,
+// provisioning steps, rollbacks, jobs and AWS API calls.
+//
+// Every method is safe to call on a nil *Metrics, which records nothing, so
+// components can be built without metrics.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "provisioner"
+
+// stepBuckets cover provisioning steps, which range from a quick API call to
+// minutes of waiting for IAM.
+var stepBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	stepDuration *prometheus.HistogramVec
+	stepFailures *prometheus.CounterVec
+
+	rollbacks           prometheus.Counter
+	rolledBackResources *prometheus.CounterVec
+
+	jobsInFlight *prometheus.GaugeVec
+	jobs         *prometheus.CounterVec
+
+	awsCalls        *prometheus.CounterVec
+	awsCallDuration *prometheus.HistogramVec
+}
+
+// New returns metrics registered on a registry of their own, along with the
+// Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+
+		stepDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "step_duration_seconds",
+			Help:      "Time taken by provisioning steps, by step and outcome.",
+			Buckets:   stepBuckets,
+		}, []string{"step", "outcome"}),
+		stepFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "step_failures_total",
+			Help:      "Provisioning steps that failed, by step.",
+		}, []string{"step"}),
+
+		rollbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rollbacks_total",
+			Help:      "Provisioning runs that failed and were rolled back.",
+		}),
+		rolledBackResources: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rolled_back_resources_total",
+			Help:      "Resources deleted by rollbacks, by resource type.",
+		}, []string{"resource_type"}),
+
+		jobsInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "jobs_in_flight",
+			Help:      "Jobs currently running, by type.",
+		}, []string{"type"}),
+		jobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_total",
+			Help:      "Jobs finished, by type and status.",
+		}, []string{"type", "status"}),
+
+		awsCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "aws_api_calls_total",
+			Help:      "AWS API operations made, by service, operation and error code; the error code is empty for successful calls.",
+		}, []string{"service", "operation", "error_code"}),
+		awsCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "aws_api_call_duration_seconds",
+			Help:      "Time taken by AWS API operations including retries, by service and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.stepDuration,
+		m.stepFailures,
+		m.rollbacks,
+		m.rolledBackResources,
+		m.jobsInFlight,
+		m.jobs,
+		m.awsCalls,
+		m.awsCallDuration,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a served request. route is the route's path
+// template, so that client IDs and job IDs do not each get their own series.
+func (m *Metrics) ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.httpRequestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// ObserveStep records a finished provisioning step.
+func (m *Metrics) ObserveStep(step string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+		m.stepFailures.WithLabelValues(step).Inc()
+	}
+	m.stepDuration.WithLabelValues(step, outcome).Observe(duration.Seconds())
+}
+
+// ObserveRollback records a rollback that deleted resources of the given
+// types.
+func (m *Metrics) ObserveRollback(resourceTypes []string) {
+	if m == nil {
+		return
+	}
+	m.rollbacks.Inc()
+	for _, t := range resourceTypes {
+		m.rolledBackResources.WithLabelValues(t).Inc()
+	}
+}
+
+// JobStarted and JobFinished track running jobs.
+func (m *Metrics) JobStarted(jobType string) {
+	if m == nil {
+		return
+	}
+	m.jobsInFlight.WithLabelValues(jobType).Inc()
+}
+
+func (m *Metrics) JobFinished(jobType, status string) {
+	if m == nil {
+		return
+	}
+	m.jobsInFlight.WithLabelValues(jobType).Dec()
+	m.jobs.WithLabelValues(jobType, status).Inc()
+}
+
+// ObserveAWSCall records an AWS API operation. It is an
+// awsclient.CallRecorder.
+func (m *Metrics) ObserveAWSCall(call awsclient.Call) {
+	if m == nil {
+		return
+	}
+	m.awsCalls.WithLabelValues(call.Service, call.Operation, errorCode(call.Err)).Inc()
+	m.awsCallDuration.WithLabelValues(call.Service, call.Operation).Observe(call.Duration.Seconds())
+}
+
+// errorCode returns the AWS error code of err, "" for no error and "Unknown"
+// for errors that did not come from AWS, such as network failures.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return "Unknown"
+}
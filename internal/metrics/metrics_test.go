@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/aws/smithy-go"
+)
+
+// scrape returns the metrics as served by m's handler.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want 200", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest("/api/v1/provision/{client_id}", "GET", http.StatusOK, 20*time.Millisecond)
+	m.ObserveStep("s3_bucket", time.Second, nil)
+	m.ObserveStep("iam_role", time.Second, errors.New("boom"))
+	m.ObserveRollback([]string{"s3_bucket", "log_group"})
+	m.JobStarted("provision")
+	m.JobStarted("provision")
+	m.JobFinished("provision", "succeeded")
+	m.ObserveAWSCall(awsclient.Call{Service: "s3", Operation: "CreateBucket", Duration: time.Second})
+	m.ObserveAWSCall(awsclient.Call{Service: "iam", Operation: "CreateRole", Err: &smithy.GenericAPIError{Code: "AccessDenied"}})
+	m.ObserveAWSCall(awsclient.Call{Service: "sts", Operation: "AssumeRole", Err: errors.New("connection reset")})
+
+	got := scrape(t, m)
+	for _, want := range []string{
+		`provisioner_http_requests_total{code="200",method="GET",route="/api/v1/provision/{client_id}"} 1`,
+		`provisioner_http_request_duration_seconds_count{method="GET",route="/api/v1/provision/{client_id}"} 1`,
+		`provisioner_step_duration_seconds_count{outcome="success",step="s3_bucket"} 1`,
+		`provisioner_step_duration_seconds_count{outcome="failure",step="iam_role"} 1`,
+		`provisioner_step_failures_total{step="iam_role"} 1`,
+		`provisioner_rollbacks_total 1`,
+		`provisioner_rolled_back_resources_total{resource_type="log_group"} 1`,
+		`provisioner_jobs_in_flight{type="provision"} 1`,
+		`provisioner_jobs_total{status="succeeded",type="provision"} 1`,
+		`provisioner_aws_api_calls_total{error_code="",operation="CreateBucket",service="s3"} 1`,
+		`provisioner_aws_api_calls_total{error_code="AccessDenied",operation="CreateRole",service="iam"} 1`,
+		`provisioner_aws_api_calls_total{error_code="Unknown",operation="AssumeRole",service="sts"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveHTTPRequest("/", "GET", http.StatusOK, time.Millisecond)
+	m.ObserveStep("s3_bucket", time.Second, nil)
+	m.ObserveRollback([]string{"s3_bucket"})
+	m.JobStarted("provision")
+	m.JobFinished("provision", "succeeded")
+	m.ObserveAWSCall(awsclient.Call{Service: "s3", Operation: "CreateBucket"})
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("nil metrics handler = %d, want 404", w.Code)
+	}
+}
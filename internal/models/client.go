@@ -8,13 +8,127 @@ type ResourceConfig struct {
 type ProvisionRequest struct {
 	ClientID   string `json:"client_id"`
 	ClientName string `json:"client_name"`
+	// AccountID is the AWS account to provision in. It defaults to the
+	// account the client is already provisioned in, else the service's own.
+	AccountID string `json:"account_id,omitempty"`
+	// Regions are the AWS regions to provision in, each one in turn. They
+	// default to the service's own region.
+	Regions []string `json:"regions,omitempty"`
+	// Tier names the blueprint of resources to provision, by default the
+	// server's default tier
+	Tier string `json:"tier,omitempty"`
+	// Options tune the client's resources. Settings left out take the tier's
+	// settings, else the server's defaults.
+	Options *ResourceOptions `json:"options,omitempty"`
+}
+
+// ResourceOptions are the tunable settings of a client's resources. A zero
+// value in a request or blueprint stands for the default; responses carry the
+// values in effect.
+type ResourceOptions struct {
+	LambdaMemoryMB          int     `json:"lambda_memory_mb" yaml:"lambda_memory_mb"`
+	LambdaTimeoutSeconds    int     `json:"lambda_timeout_seconds" yaml:"lambda_timeout_seconds"`
+	LambdaRuntime           string  `json:"lambda_runtime" yaml:"lambda_runtime"`
+	LogRetentionDays        int     `json:"log_retention_days" yaml:"log_retention_days"` // 0 keeps logs forever
+	S3InfrequentAccessDays  int     `json:"s3_infrequent_access_days" yaml:"s3_infrequent_access_days"`
+	S3GlacierDays           int     `json:"s3_glacier_days" yaml:"s3_glacier_days"`
+	ErrorRateAlarmThreshold float64 `json:"error_rate_alarm_threshold" yaml:"error_rate_alarm_threshold"`
+	LogVolumeAlarmThreshold float64 `json:"log_volume_alarm_threshold" yaml:"log_volume_alarm_threshold"`
 }
 
 type ProvisionResponse struct {
-	Status       string `json:"status"`
-	BucketName   string `json:"bucket_name"`
-	RoleARN      string `json:"role_arn"`
-	LogGroupName string `json:"log_group_name"`
-	LambdaARN    string `json:"lambda_arn"`
-	TopicARN     string `json:"topic_arn"`
+	Status       string           `json:"status"`
+	AccountID    string           `json:"account_id"`
+	Region       string           `json:"region"`
+	Tier         string           `json:"tier,omitempty"`
+	BucketName   string           `json:"bucket_name"`
+	RoleARN      string           `json:"role_arn"`
+	LogGroupName string           `json:"log_group_name"`
+	LambdaARN    string           `json:"lambda_arn"`
+	TopicARN     string           `json:"topic_arn"`
+	Resources    []ResourceResult `json:"resources,omitempty"`
+	Options      *ResourceOptions `json:"options,omitempty"`
+	// Error is why provisioning failed in the region, in a multi-region
+	// response
+	Error string `json:"error,omitempty"`
+}
+
+// MultiRegionResponse holds the result of provisioning a client in each of
+// several regions. Status is "success" if every region succeeded, "partial"
+// if only some did and "failed" if none did.
+type MultiRegionResponse struct {
+	ClientID  string              `json:"client_id"`
+	AccountID string              `json:"account_id"`
+	Tier      string              `json:"tier,omitempty"`
+	Status    string              `json:"status"`
+	Regions   []ProvisionResponse `json:"regions"`
+}
+
+type JobAcceptedResponse struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+type ResourceResult struct {
+	Region string `json:"region,omitempty"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type DeprovisionResponse struct {
+	ClientID  string           `json:"client_id"`
+	AccountID string           `json:"account_id"`
+	Regions   []string         `json:"regions"`
+	Status    string           `json:"status"`
+	Resources []ResourceResult `json:"resources"`
+}
+
+type ResourceStatus struct {
+	Region  string            `json:"region,omitempty"`
+	Type    string            `json:"type"`
+	Name    string            `json:"name"`
+	Exists  bool              `json:"exists"`
+	ARN     string            `json:"arn,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type StatusResponse struct {
+	ClientID  string           `json:"client_id"`
+	AccountID string           `json:"account_id"`
+	Regions   []string         `json:"regions"`
+	Tier      string           `json:"tier,omitempty"`
+	Status    string           `json:"status"`
+	Resources []ResourceStatus `json:"resources"`
+}
+
+// Plan actions
+const (
+	PlanActionCreate = "create"
+	PlanActionUpdate = "update"
+	PlanActionNone   = "none"
+)
+
+type PlannedResource struct {
+	Type    string                 `json:"type"`
+	Name    string                 `json:"name"`
+	ARN     string                 `json:"arn,omitempty"`
+	Action  string                 `json:"action"`
+	Changes []string               `json:"changes,omitempty"`
+	Config  map[string]interface{} `json:"config,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+type PlanResponse struct {
+	ClientID  string            `json:"client_id"`
+	AccountID string            `json:"account_id"`
+	Region    string            `json:"region"`
+	Tier      string            `json:"tier"`
+	DryRun    bool              `json:"dry_run"`
+	Options   ResourceOptions   `json:"options"`
+	Summary   map[string]int    `json:"summary"`
+	Resources []PlannedResource `json:"resources"`
 }
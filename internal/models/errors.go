@@ -1,20 +1,70 @@
 package models
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes
+const (
+	ErrCodeInvalidRequest   = "INVALID_REQUEST"
+	ErrCodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+	ErrCodeUnauthenticated  = "UNAUTHENTICATED"
+	ErrCodeForbidden        = "FORBIDDEN"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeAlreadyExists    = "ALREADY_EXISTS"
+	ErrCodeThrottled        = "THROTTLED"
+	ErrCodeAccessDenied     = "ACCESS_DENIED"
+	ErrCodeLimitExceeded    = "LIMIT_EXCEEDED"
+	ErrCodeInvalidName      = "INVALID_NAME"
+	ErrCodeDependencyFailed = "DEPENDENCY_FAILED"
+	ErrCodeUnavailable      = "UNAVAILABLE"
+	ErrCodeInternal         = "INTERNAL_ERROR"
+)
 
 type ProvisionError struct {
-	Code    string
-	Message string
-	Err     error
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Step and Resource locate a provisioning failure; AWSCode and RequestID
+	// identify the AWS error behind it, if there was one
+	Step      string `json:"step,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	AWSCode   string `json:"aws_code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Err is the underlying error. It is logged but never sent to clients,
+	// as it may name internal resources, accounts or paths
+	Err error `json:"-"`
 }
 
 func (e *ProvisionError) Error() string {
 	if e.Err != nil {
 		return fmt.Sprintf("%s: %s (%v)", e.Code, e.Message, e.Err)
 	}
+	return e.ClientMessage()
+}
+
+// ClientMessage is the error as clients may see it: its code and message,
+// without the underlying error.
+func (e *ProvisionError) ClientMessage() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// ClientMessage returns what clients may be told about err: the code and
+// message of the ProvisionError it wraps, or only that it was an internal
+// error otherwise. The full error is for the logs.
+func ClientMessage(err error) string {
+	var perr *ProvisionError
+	if errors.As(err, &perr) {
+		return perr.ClientMessage()
+	}
+	return fmt.Sprintf("%s: internal error", ErrCodeInternal)
+}
+
+func (e *ProvisionError) Unwrap() error {
+	return e.Err
+}
+
 func NewProvisionError(code, message string, err error) *ProvisionError {
 	return &ProvisionError{
 		Code:    code,
@@ -22,3 +72,10 @@ func NewProvisionError(code, message string, err error) *ProvisionError {
 		Err:     err,
 	}
 }
+
+type ErrorResponse struct {
+	Error *ProvisionError `json:"error"`
+	// RequestID is the API request the error answers, for errors with no
+	// more detail to give
+	RequestID string `json:"request_id,omitempty"`
+}
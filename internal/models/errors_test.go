@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProvisionErrorJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *ProvisionError
+		want string
+	}{
+		{
+			name: "without cause",
+			err:  NewProvisionError(ErrCodeInvalidRequest, "client_id is required", nil),
+			want: `{"code":"INVALID_REQUEST","message":"client_id is required"}`,
+		},
+		{
+			name: "cause is not serialized",
+			err: NewProvisionError(ErrCodeAccessDenied, "failed to apply iam_role dev-c1-role",
+				errors.New("arn:aws:iam::123456789012:role/dev-c1-role is not authorized")),
+			want: `{"code":"ACCESS_DENIED","message":"failed to apply iam_role dev-c1-role"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.err)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvisionErrorUnwrap(t *testing.T) {
+	cause := errors.New("boom")
+	err := NewProvisionError(ErrCodeInternal, "failed", cause)
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v, cause) = false, want true", err)
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Error() = %q, want it to include the cause for logs", err.Error())
+	}
+}
+
+func TestClientMessage(t *testing.T) {
+	cause := errors.New("arn:aws:iam::123456789012:role/dev-c1-role is not authorized")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"provision error", NewProvisionError(ErrCodeAccessDenied, "failed to apply iam_role", cause), "ACCESS_DENIED: failed to apply iam_role"},
+		{"wrapped provision error", fmt.Errorf("region us-east-1: %w", NewProvisionError(ErrCodeThrottled, "slow down", cause)), "THROTTLED: slow down"},
+		{"other error", fmt.Errorf("failed to create role: %w", cause), "INTERNAL_ERROR: internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientMessage(tt.err); got != tt.want {
+				t.Errorf("ClientMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// inAccount returns a provisioner working in accountID through the role
+// assumed there, or p for the service's own account or an empty ID.
+func (p *ResourceProvisioner) inAccount(accountID string) (*ResourceProvisioner, error) {
+	if accountID == "" || accountID == p.accountID {
+		return p, nil
+	}
+	clients, err := p.accounts.Clients(accountID)
+	if err != nil {
+		return nil, models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf("account %s is not registered", accountID), err)
+	}
+
+	inAccount := *p
+	inAccount.clients = clients
+	inAccount.accountID = accountID
+	return &inAccount, nil
+}
+
+// inRegion returns a provisioner whose clients act in region of p's account,
+// and ctx's logger tagged with the region.
+func (p *ResourceProvisioner) inRegion(ctx context.Context, region string) (*ResourceProvisioner, context.Context, error) {
+	client, err := p.clients.Client(ctx, region)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to region %s: %w", region, err)
+	}
+
+	inRegion := *p
+	inRegion.setClients(client)
+	inRegion.region = region
+	return &inRegion, logger.WithContext(ctx, p.log(ctx).With(logger.Region, region)), nil
+}
+
+// ValidateAccount reports an INVALID_REQUEST error if clients cannot be
+// provisioned in accountID.
+func (p *ResourceProvisioner) ValidateAccount(accountID string) error {
+	_, err := p.inAccount(accountID)
+	return err
+}
+
+// forRequest returns a provisioner working in the account of req, or in the
+// account the client is recorded in if req names none.
+func (p *ResourceProvisioner) forRequest(ctx context.Context, req *models.ProvisionRequest) (*ResourceProvisioner, error) {
+	if req.AccountID != "" {
+		return p.inAccount(req.AccountID)
+	}
+	p, _, err := p.forClient(ctx, req.ClientID)
+	return p, err
+}
+
+// forClient returns a provisioner working in the account the client is
+// recorded in, along with the record, or in the service's own account and a
+// nil record for clients without one.
+func (p *ResourceProvisioner) forClient(ctx context.Context, clientID string) (*ResourceProvisioner, *state.Record, error) {
+	record, err := p.getRecord(ctx, clientID)
+	if errors.Is(err, state.ErrNotFound) {
+		return p, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load state record: %w", err)
+	}
+	p, err = p.inAccount(record.AccountID)
+	return p, record, err
+}
+
+// requestRegions returns the regions req asks for, by default the service's
+// own.
+func (p *ResourceProvisioner) requestRegions(req *models.ProvisionRequest) []string {
+	if len(req.Regions) > 0 {
+		return req.Regions
+	}
+	return []string{p.config.AWSRegion}
+}
+
+// clientRegions returns region if set, otherwise the regions the client is
+// recorded in, by default the service's own.
+func (p *ResourceProvisioner) clientRegions(record *state.Record, region string) []string {
+	switch {
+	case region != "":
+		return []string{region}
+	case record != nil && len(record.Regions) > 0:
+		// Copied, as regions are removed from the record as they are torn down
+		return slices.Clone(record.Regions)
+	default:
+		return []string{p.config.AWSRegion}
+	}
+}
@@ -0,0 +1,57 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// audited runs fn, which works on node n and returns its ARN, and records an
+// audit event for each AWS operation fn made that could have changed
+// something. Events are recorded once fn returns, so they carry the ARN.
+func (p *ResourceProvisioner) audited(ctx context.Context, n *resourceNode, fn func(ctx context.Context) (string, error)) (string, error) {
+	if p.audit == nil {
+		return fn(ctx)
+	}
+
+	var (
+		mu    sync.Mutex
+		calls []awsclient.Call
+	)
+	arn, err := fn(awsclient.WithCallRecorder(ctx, func(call awsclient.Call) {
+		if !call.Mutating() {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, call := range calls {
+		event := audit.Event{
+			Kind:         audit.KindAWS,
+			ClientID:     n.clientID,
+			AccountID:    p.accountID,
+			Action:       fmt.Sprintf("%s:%s", call.Service, call.Operation),
+			ResourceType: n.resourceType,
+			Resource:     n.name,
+			ARN:          arn,
+			Outcome:      audit.OutcomeSuccess,
+			RequestID:    call.RequestID,
+		}
+		if call.Err != nil {
+			event.Outcome = audit.OutcomeFailure
+			event.Error = call.Err.Error()
+		}
+		if err := p.audit.Record(ctx, event); err != nil {
+			p.log(ctx).Error("Failed to record audit event", "action", event.Action, logger.Err(err))
+		}
+	}
+	return arn, err
+}
@@ -0,0 +1,69 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+)
+
+func TestProvisionRecordsAWSMutations(t *testing.T) {
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+
+	if _, err := p.ProvisionClientResources(context.Background(), testRequest("acme")); err != nil {
+		t.Fatalf("ProvisionClientResources() error = %v", err)
+	}
+	events := p.audit.Query(audit.Filter{Kind: audit.KindAWS})
+	if len(events) == 0 {
+		t.Fatal("provisioning recorded no AWS events")
+	}
+	names := p.namesIn("acme", cloud.Region)
+	var bucketCreated bool
+	for _, e := range events {
+		if isReadOnly(e.Action) {
+			t.Errorf("read-only operation %s was recorded", e.Action)
+		}
+		if e.ClientID != "acme" || e.AccountID != testAccount || e.Outcome != audit.OutcomeSuccess {
+			t.Errorf("recorded %+v, want a successful operation for acme in %s", e, testAccount)
+		}
+		if e.Action == "s3:CreateBucket" {
+			bucketCreated = e.Resource == names.bucket && e.ARN == "arn:aws:s3:::"+names.bucket
+		}
+	}
+	if !bucketCreated {
+		t.Errorf("no s3:CreateBucket event for %s with its ARN", names.bucket)
+	}
+
+	// A second run changes nothing, so records nothing
+	if _, err := p.ProvisionClientResources(context.Background(), testRequest("acme")); err != nil {
+		t.Fatalf("ProvisionClientResources() again error = %v", err)
+	}
+	if got := p.audit.Query(audit.Filter{Kind: audit.KindAWS}); len(got) != len(events) {
+		t.Errorf("re-provisioning recorded %d more events, want none", len(got)-len(events))
+	}
+	if err := p.audit.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestProvisionRecordsFailedMutations(t *testing.T) {
+	cloud := newTestCloud(testAccount, "us-east-1")
+	// The role depends on the bucket, so the bucket is always created, and
+	// rolled back
+	cloud.FailOn("iam:CreateRole", errors.New("injected failure"))
+	p := newTestProvisioner(t, cloud)
+
+	if _, err := p.ProvisionClientResources(context.Background(), testRequest("acme")); err == nil {
+		t.Fatal("ProvisionClientResources() error = nil, want the injected failure")
+	}
+	failed := p.audit.Query(audit.Filter{Kind: audit.KindAWS, Outcome: audit.OutcomeFailure})
+	if len(failed) != 1 || failed[0].Action != "iam:CreateRole" || failed[0].Error == "" {
+		t.Errorf("failed events = %+v, want the failed iam:CreateRole with its error", failed)
+	}
+	// The rollback is audited too
+	if got := p.audit.Query(audit.Filter{Kind: audit.KindAWS, Action: "s3:DeleteBucket"}); len(got) != 1 {
+		t.Errorf("recorded %d s3:DeleteBucket events, want 1 from the rollback", len(got))
+	}
+}
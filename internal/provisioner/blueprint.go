@@ -0,0 +1,133 @@
+package provisioner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/blueprints"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+)
+
+// builtinComponents make up the default tier when no blueprints are loaded.
+var builtinComponents = []string{
+	nodeBucket,
+	nodeLogGroup,
+	nodeRole,
+	nodeLambda,
+	nodeRule,
+	nodeTopic,
+	nodeErrorRateAlarm,
+	nodeLogVolumeAlarm,
+}
+
+// blueprint returns the blueprint of tier, by default the configured default
+// tier. Without loaded blueprints only the default tier exists, made of
+// builtinComponents.
+func (p *ResourceProvisioner) blueprint(tier string) (blueprints.Blueprint, error) {
+	if tier == "" {
+		tier = p.config.DefaultTier
+	}
+	if p.tiers == nil {
+		if tier != p.config.DefaultTier {
+			return blueprints.Blueprint{}, models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf("tier %s does not exist", tier), nil)
+		}
+		return blueprints.Blueprint{Name: tier, Resources: builtinComponents}, nil
+	}
+
+	b, err := p.tiers.Get(tier)
+	if err != nil {
+		return blueprints.Blueprint{}, models.NewProvisionError(models.ErrCodeInvalidRequest,
+			fmt.Sprintf("tier %s does not exist, the tiers are %v", tier, p.tiers.Names()), err)
+	}
+	return b, nil
+}
+
+// tierSettings returns the blueprint of req's tier and the options req's
+// resources get: those of req, else those of the blueprint, else the
+// defaults. It reports an INVALID_REQUEST error for unknown tiers and options
+// out of bounds.
+func (p *ResourceProvisioner) tierSettings(req *models.ProvisionRequest) (blueprints.Blueprint, models.ResourceOptions, error) {
+	b, err := p.blueprint(req.Tier)
+	if err != nil {
+		return blueprints.Blueprint{}, models.ResourceOptions{}, err
+	}
+	opts := resolveOptions(resolveOptions(DefaultOptions(), &b.Options), req.Options)
+	if err := validateOptions(opts); err != nil {
+		return blueprints.Blueprint{}, models.ResourceOptions{}, err
+	}
+	return b, opts, nil
+}
+
+// ValidateTier reports an INVALID_REQUEST error if tier does not exist or
+// opts, on top of the tier's settings, are out of bounds.
+func (p *ResourceProvisioner) ValidateTier(tier string, opts *models.ResourceOptions) error {
+	_, _, err := p.tierSettings(&models.ProvisionRequest{Tier: tier, Options: opts})
+	return err
+}
+
+// ValidateBlueprints checks that every blueprint lists only known
+// components, along with the components they depend on, and that its options
+// are within bounds.
+func ValidateBlueprints(list []blueprints.Blueprint) error {
+	var errs []error
+	for _, b := range list {
+		for _, component := range b.Resources {
+			if !slices.Contains(Components, component) {
+				errs = append(errs, fmt.Errorf("blueprint %s: unknown resource %s, the resources are %v", b.Name, component, Components))
+				continue
+			}
+			for _, dep := range componentDeps[component] {
+				if !b.Includes(dep) {
+					errs = append(errs, fmt.Errorf("blueprint %s: %s depends on %s, which is not listed", b.Name, component, dep))
+				}
+			}
+		}
+		if err := validateOptions(resolveOptions(DefaultOptions(), &b.Options)); err != nil {
+			errs = append(errs, fmt.Errorf("blueprint %s: %w", b.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordedComponents returns the components of the client in p's region: those of
+// the tier it was last provisioned with, and any others it still has
+// resources of from an earlier tier. Clients whose tier no longer exists are
+// taken to have every component.
+func (p *ResourceProvisioner) recordedComponents(record *state.Record, clientID string) []string {
+	tier := ""
+	if record != nil {
+		tier = record.Request.Tier
+	}
+	b, err := p.blueprint(tier)
+	if err != nil {
+		return Components
+	}
+
+	components := slices.Clone(b.Resources)
+	if record == nil {
+		return components
+	}
+	for _, n := range p.resourceGraph(clientID, DefaultOptions()) {
+		resource, ok := record.Resource(p.region, n.resourceType, n.name)
+		if !ok || resource.Status == state.ResourceDeleted || resource.Status == state.ResourceRolledBack {
+			continue
+		}
+		components = withDependencies(components, n.id)
+	}
+	return components
+}
+
+// withDependencies adds component and everything it depends on to
+// components.
+func withDependencies(components []string, component string) []string {
+	if slices.Contains(components, component) {
+		return components
+	}
+	components = append(components, component)
+	for _, dep := range componentDeps[component] {
+		components = withDependencies(components, dep)
+	}
+	return components
+}
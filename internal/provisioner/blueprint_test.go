@@ -0,0 +1,202 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/blueprints"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+)
+
+// testTiers are tiers like the shipped ones: basic is storage only, standard
+// the built-in stack and premium adds alarms on the function and a dashboard.
+var testTiers = []blueprints.Blueprint{
+	{Name: "basic", Resources: []string{nodeBucket, nodeRole}, Options: models.ResourceOptions{S3GlacierDays: 60}},
+	{Name: "standard", Resources: builtinComponents},
+	{Name: "premium", Resources: Components, Options: models.ResourceOptions{LambdaMemoryMB: 256}},
+}
+
+// premiumResources returns the resources only the premium tier has that the
+// client has in cloud.
+func premiumResources(p *ResourceProvisioner, cloud *fake.Cloud, clientID string) []string {
+	names := p.namesIn(clientID, cloud.Region)
+	var found []string
+	if _, ok := cloud.CloudWatch.Alarm(names.lambdaErrorsAlarm); ok {
+		found = append(found, nodeLambdaErrorsAlarm)
+	}
+	if _, ok := cloud.CloudWatch.Alarm(names.lambdaThrottlesAlarm); ok {
+		found = append(found, nodeLambdaThrottlesAlarm)
+	}
+	if _, ok := cloud.CloudWatch.Dashboard(names.dashboard); ok {
+		found = append(found, nodeDashboard)
+	}
+	return found
+}
+
+func TestValidateBlueprints(t *testing.T) {
+	tests := []struct {
+		name      string
+		blueprint blueprints.Blueprint
+		wantErr   string
+	}{
+		{name: "storage only", blueprint: blueprints.Blueprint{Name: "basic", Resources: []string{nodeBucket, nodeRole}}},
+		{name: "every component", blueprint: blueprints.Blueprint{Name: "premium", Resources: Components}},
+		{name: "unknown resource", blueprint: blueprints.Blueprint{Name: "gold", Resources: []string{nodeBucket, "rds_instance"}}, wantErr: "blueprint gold: unknown resource rds_instance"},
+		{
+			name:      "dependency not listed",
+			blueprint: blueprints.Blueprint{Name: "lean", Resources: []string{nodeBucket, nodeLogGroup, nodeLambda}},
+			wantErr:   "blueprint lean: lambda_function depends on iam_role, which is not listed",
+		},
+		{
+			name:      "options out of bounds",
+			blueprint: blueprints.Blueprint{Name: "big", Resources: []string{nodeBucket}, Options: models.ResourceOptions{LambdaMemoryMB: 20000}},
+			wantErr:   "lambda_memory_mb must be between 128 and 10240, got 20000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBlueprints([]blueprints.Blueprint{tt.blueprint})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateBlueprints() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateBlueprints() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateBlueprints(testTiers); err != nil {
+		t.Errorf("ValidateBlueprints(testTiers) error = %v", err)
+	}
+}
+
+func TestProvisionTiers(t *testing.T) {
+	tests := []struct {
+		tier        string
+		wantTier    string
+		wantBuiltin []string
+		wantPremium []string
+		wantOptions func(o *models.ResourceOptions)
+	}{
+		{tier: "basic", wantTier: "basic", wantBuiltin: []string{nodeBucket, nodeRole},
+			wantOptions: func(o *models.ResourceOptions) { o.S3GlacierDays = 60 }},
+		{tier: "", wantTier: "standard", wantBuiltin: builtinComponents},
+		{tier: "premium", wantTier: "premium", wantBuiltin: builtinComponents,
+			wantPremium: []string{nodeLambdaErrorsAlarm, nodeLambdaThrottlesAlarm, nodeDashboard},
+			wantOptions: func(o *models.ResourceOptions) { o.LambdaMemoryMB = 256 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantTier, func(t *testing.T) {
+			ctx := context.Background()
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			p.tiers = blueprints.NewRegistry(testTiers)
+
+			req := testRequest("acme")
+			req.Tier = tt.tier
+			resp, err := p.ProvisionClientResources(ctx, req)
+			if err != nil {
+				t.Fatalf("ProvisionClientResources() error = %v", err)
+			}
+			wantOptions := DefaultOptions()
+			if tt.wantOptions != nil {
+				tt.wantOptions(&wantOptions)
+			}
+			if resp.Tier != tt.wantTier || resp.Options == nil || *resp.Options != wantOptions {
+				t.Errorf("response tier %q with options %+v, want %q with %+v", resp.Tier, resp.Options, tt.wantTier, wantOptions)
+			}
+			if got := existingResources(p, cloud, "acme"); !slices.Equal(got, tt.wantBuiltin) {
+				t.Errorf("resources = %v, want %v", got, tt.wantBuiltin)
+			}
+			if got := premiumResources(p, cloud, "acme"); !slices.Equal(got, tt.wantPremium) {
+				t.Errorf("premium resources = %v, want %v", got, tt.wantPremium)
+			}
+
+			// The tier is recorded even when it was the default
+			record, err := p.store.Get(ctx, "acme")
+			if err != nil {
+				t.Fatalf("store.Get() error = %v", err)
+			}
+			if record.Request.Tier != tt.wantTier {
+				t.Errorf("recorded tier = %q, want %q", record.Request.Tier, tt.wantTier)
+			}
+		})
+	}
+}
+
+func TestProvisionUnknownTier(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiers *blueprints.Registry
+		tier  string
+	}{
+		{name: "not among the blueprints", tiers: blueprints.NewRegistry(testTiers), tier: "gold"},
+		{name: "without blueprints", tier: "basic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			p.tiers = tt.tiers
+
+			req := testRequest("acme")
+			req.Tier = tt.tier
+			_, err := p.ProvisionClientResources(context.Background(), req)
+			var perr *models.ProvisionError
+			if !errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest {
+				t.Errorf("ProvisionClientResources() error = %v, want %s", err, models.ErrCodeInvalidRequest)
+			}
+			if err := p.ValidateTier(tt.tier, nil); err == nil {
+				t.Errorf("ValidateTier(%q) error = nil", tt.tier)
+			}
+			if calls := cloud.Calls(); len(calls) != 0 {
+				t.Errorf("AWS was called for an unknown tier: %v", calls)
+			}
+		})
+	}
+}
+
+// A client moved to a smaller tier keeps the resources of the larger one
+// until it is torn down, and status and teardown still cover them.
+func TestDowngradeTier(t *testing.T) {
+	ctx := context.Background()
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+	p.tiers = blueprints.NewRegistry(testTiers)
+
+	req := testRequest("acme")
+	req.Tier = "premium"
+	if _, err := p.ProvisionClientResources(ctx, req); err != nil {
+		t.Fatalf("premium: error = %v", err)
+	}
+	req.Tier = "standard"
+	resp, err := p.ProvisionClientResources(ctx, req)
+	if err != nil {
+		t.Fatalf("standard: error = %v", err)
+	}
+	if len(resp.Resources) != len(builtinComponents) {
+		t.Errorf("standard run handled %d resources, want the %d of the tier", len(resp.Resources), len(builtinComponents))
+	}
+
+	status, err := p.DescribeClientResources(ctx, "acme", "")
+	if err != nil {
+		t.Fatalf("DescribeClientResources() error = %v", err)
+	}
+	if status.Tier != "standard" || len(status.Resources) != len(Components) {
+		t.Errorf("status tier %q with %d resources, want standard with all %d still there", status.Tier, len(status.Resources), len(Components))
+	}
+
+	if _, err := p.DeprovisionClientResources(ctx, "acme", ""); err != nil {
+		t.Fatalf("DeprovisionClientResources() error = %v", err)
+	}
+	if got := append(existingResources(p, cloud, "acme"), premiumResources(p, cloud, "acme")...); len(got) != 0 {
+		t.Errorf("resources left after teardown: %v", got)
+	}
+}
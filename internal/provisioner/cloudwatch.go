@@ -3,26 +3,29 @@ package provisioner
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 )
 
-func (p *ResourceProvisioner) setupCloudWatchAlarms(ctx context.Context, logGroupName, snsTopicArn, clientID string) error {
-	p.logger.Info("Setting up CloudWatch Alarms")
-
-	// Error Rate Alarm
-	_, err := p.cloudwatchClient.PutMetricAlarm(ctx, &cloudwatch.PutMetricAlarmInput{
-		AlarmName:          aws.String(fmt.Sprintf("%s-error-rate-alarm", clientID)),
+// errorRateAlarm alerts the client's SNS topic when the client's error count
+// exceeds the threshold set in opts.
+func (p *ResourceProvisioner) errorRateAlarm(clientID, snsTopicArn string, opts models.ResourceOptions) *cloudwatch.PutMetricAlarmInput {
+	return &cloudwatch.PutMetricAlarmInput{
+		AlarmName:          aws.String(p.namesFor(clientID).errorRateAlarm),
 		AlarmDescription:   aws.String("Alert when error rate exceeds threshold"),
 		MetricName:         aws.String("ErrorCount"),
 		Namespace:          aws.String("Custom/ClientLogs"),
 		Statistic:          types.StatisticSum, // Fixed this
 		Period:             aws.Int32(300),
 		EvaluationPeriods:  aws.Int32(1),
-		Threshold:          aws.Float64(10),
+		Threshold:          aws.Float64(opts.ErrorRateAlarmThreshold),
 		ComparisonOperator: types.ComparisonOperatorGreaterThanThreshold,
 		AlarmActions:       []string{snsTopicArn},
 		Dimensions: []types.Dimension{
@@ -31,21 +34,21 @@ func (p *ResourceProvisioner) setupCloudWatchAlarms(ctx context.Context, logGrou
 				Value: aws.String(clientID),
 			},
 		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create error rate alarm: %w", err)
 	}
+}
 
-	// Log Volume Alarm
-	_, err = p.cloudwatchClient.PutMetricAlarm(ctx, &cloudwatch.PutMetricAlarmInput{
-		AlarmName:          aws.String(fmt.Sprintf("%s-log-volume-alarm", clientID)),
+// logVolumeAlarm alerts the client's SNS topic when the volume in the client's
+// log group exceeds the threshold set in opts.
+func (p *ResourceProvisioner) logVolumeAlarm(clientID, logGroupName, snsTopicArn string, opts models.ResourceOptions) *cloudwatch.PutMetricAlarmInput {
+	return &cloudwatch.PutMetricAlarmInput{
+		AlarmName:          aws.String(p.namesFor(clientID).logVolumeAlarm),
 		AlarmDescription:   aws.String("Alert on unusual log volume"),
 		MetricName:         aws.String("IncomingLogEvents"),
 		Namespace:          aws.String("AWS/Logs"),
 		Statistic:          types.StatisticSum, // Fixed this
 		Period:             aws.Int32(300),
 		EvaluationPeriods:  aws.Int32(2),
-		Threshold:          aws.Float64(1000),
+		Threshold:          aws.Float64(opts.LogVolumeAlarmThreshold),
 		ComparisonOperator: types.ComparisonOperatorGreaterThanThreshold,
 		AlarmActions:       []string{snsTopicArn},
 		Dimensions: []types.Dimension{
@@ -54,15 +57,256 @@ func (p *ResourceProvisioner) setupCloudWatchAlarms(ctx context.Context, logGrou
 				Value: aws.String(logGroupName),
 			},
 		},
+	}
+}
+
+// lambdaErrorsAlarm alerts the client's SNS topic whenever an invocation of
+// the client's log processor fails.
+func (p *ResourceProvisioner) lambdaErrorsAlarm(clientID, snsTopicArn string) *cloudwatch.PutMetricAlarmInput {
+	names := p.namesFor(clientID)
+	return lambdaAlarm(names.lambdaErrorsAlarm, "Alert when the log processor fails", "Errors", names.lambda, snsTopicArn)
+}
+
+// lambdaThrottlesAlarm alerts the client's SNS topic whenever invocations of
+// the client's log processor are throttled.
+func (p *ResourceProvisioner) lambdaThrottlesAlarm(clientID, snsTopicArn string) *cloudwatch.PutMetricAlarmInput {
+	names := p.namesFor(clientID)
+	return lambdaAlarm(names.lambdaThrottlesAlarm, "Alert when the log processor is throttled", "Throttles", names.lambda, snsTopicArn)
+}
+
+// lambdaAlarm alarms on any nonzero count of the function's metric over five
+// minutes.
+func lambdaAlarm(alarmName, description, metricName, functionName, snsTopicArn string) *cloudwatch.PutMetricAlarmInput {
+	return &cloudwatch.PutMetricAlarmInput{
+		AlarmName:          aws.String(alarmName),
+		AlarmDescription:   aws.String(description),
+		MetricName:         aws.String(metricName),
+		Namespace:          aws.String("AWS/Lambda"),
+		Statistic:          types.StatisticSum,
+		Period:             aws.Int32(300),
+		EvaluationPeriods:  aws.Int32(1),
+		Threshold:          aws.Float64(0),
+		ComparisonOperator: types.ComparisonOperatorGreaterThanThreshold,
+		AlarmActions:       []string{snsTopicArn},
+		Dimensions: []types.Dimension{
+			{
+				Name:  aws.String("FunctionName"),
+				Value: aws.String(functionName),
+			},
+		},
+	}
+}
+
+// ensureAlarm creates the alarm, or replaces it if its definition drifted.
+func (p *ResourceProvisioner) ensureAlarm(ctx context.Context, alarm *cloudwatch.PutMetricAlarmInput) (string, error) {
+	alarmName := aws.ToString(alarm.AlarmName)
+
+	result, err := p.cloudwatchClient.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{
+		AlarmNames: []string{alarmName},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create log volume alarm: %w", err)
+		return "", fmt.Errorf("failed to check alarm %s: %w", alarmName, err)
 	}
 
+	outcome := outcomeCreated
+	if len(result.MetricAlarms) > 0 {
+		if len(alarmChanges(result.MetricAlarms[0], alarm)) == 0 {
+			return outcomeUnchanged, nil
+		}
+		outcome = outcomeUpdated
+	}
+
+	p.log(ctx).Info("Putting CloudWatch Alarm")
+	if _, err := p.cloudwatchClient.PutMetricAlarm(ctx, alarm); err != nil {
+		return outcome, fmt.Errorf("failed to put alarm %s: %w", alarmName, err)
+	}
+	return outcome, nil
+}
+
+// alarmChanges describes how the fields of an existing alarm that we set
+// differ from the desired definition.
+func alarmChanges(existing types.MetricAlarm, desired *cloudwatch.PutMetricAlarmInput) []string {
+	var changes []string
+	changed := func(field string, from, to interface{}) {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", field, from, to))
+		}
+	}
+
+	changed("description", aws.ToString(existing.AlarmDescription), aws.ToString(desired.AlarmDescription))
+	changed("metric", aws.ToString(existing.Namespace)+"/"+aws.ToString(existing.MetricName), aws.ToString(desired.Namespace)+"/"+aws.ToString(desired.MetricName))
+	changed("statistic", existing.Statistic, desired.Statistic)
+	changed("period", aws.ToInt32(existing.Period), aws.ToInt32(desired.Period))
+	changed("evaluation_periods", aws.ToInt32(existing.EvaluationPeriods), aws.ToInt32(desired.EvaluationPeriods))
+	changed("threshold", aws.ToFloat64(existing.Threshold), aws.ToFloat64(desired.Threshold))
+	changed("comparison_operator", existing.ComparisonOperator, desired.ComparisonOperator)
+
+	actions := append([]string(nil), existing.AlarmActions...)
+	wantActions := append([]string(nil), desired.AlarmActions...)
+	sort.Strings(actions)
+	sort.Strings(wantActions)
+	changed("alarm_actions", actions, wantActions)
+
+	dimensions := map[string]string{}
+	for _, d := range existing.Dimensions {
+		dimensions[aws.ToString(d.Name)] = aws.ToString(d.Value)
+	}
+	wantDimensions := map[string]string{}
+	for _, d := range desired.Dimensions {
+		wantDimensions[aws.ToString(d.Name)] = aws.ToString(d.Value)
+	}
+	changed("dimensions", dimensions, wantDimensions)
+
+	return changes
+}
+
+// planAlarm reports what ensureAlarm would do, without changing anything.
+func (p *ResourceProvisioner) planAlarm(ctx context.Context, alarm *cloudwatch.PutMetricAlarmInput) (*models.PlannedResource, error) {
+	alarmName := aws.ToString(alarm.AlarmName)
+	dimensions := map[string]string{}
+	for _, d := range alarm.Dimensions {
+		dimensions[aws.ToString(d.Name)] = aws.ToString(d.Value)
+	}
+	planned := &models.PlannedResource{
+		ARN:    fmt.Sprintf("arn:aws:cloudwatch:%s:%s:alarm:%s", p.region, p.accountID, alarmName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"description":         aws.ToString(alarm.AlarmDescription),
+			"metric":              fmt.Sprintf("%s/%s", aws.ToString(alarm.Namespace), aws.ToString(alarm.MetricName)),
+			"statistic":           alarm.Statistic,
+			"period":              aws.ToInt32(alarm.Period),
+			"evaluation_periods":  aws.ToInt32(alarm.EvaluationPeriods),
+			"threshold":           aws.ToFloat64(alarm.Threshold),
+			"comparison_operator": alarm.ComparisonOperator,
+			"alarm_actions":       alarm.AlarmActions,
+			"dimensions":          dimensions,
+		},
+	}
+
+	result, err := p.cloudwatchClient.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{
+		AlarmNames: []string{alarmName},
+	})
+	if err != nil {
+		return planned, fmt.Errorf("failed to check alarm %s: %w", alarmName, err)
+	}
+	if len(result.MetricAlarms) == 0 {
+		planned.Action = models.PlanActionCreate
+		return planned, nil
+	}
+
+	planned.ARN = aws.ToString(result.MetricAlarms[0].AlarmArn)
+	planned.Changes = alarmChanges(result.MetricAlarms[0], alarm)
+	if len(planned.Changes) > 0 {
+		planned.Action = models.PlanActionUpdate
+	}
+	return planned, nil
+}
+
+func (p *ResourceProvisioner) deleteAlarm(ctx context.Context, alarmName string) error {
+	p.log(ctx).Info("Deleting CloudWatch Alarm")
+
+	_, err := p.cloudwatchClient.DeleteAlarms(ctx, &cloudwatch.DeleteAlarmsInput{
+		AlarmNames: []string{alarmName},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete alarm: %w", err)
+	}
+
+	return nil
+}
+
+// ensureLogGroup creates the log group unless it already exists, and sets its
+// retention to retentionDays, or to never expire for 0, if it differs.
+func (p *ResourceProvisioner) ensureLogGroup(ctx context.Context, logGroupName string, retentionDays int) (string, error) {
+	existing, err := p.describeLogGroup(ctx, logGroupName)
+	if err != nil {
+		return "", err
+	}
+
+	outcome := outcomeUnchanged
+	if !existing.Exists {
+		outcome = outcomeCreated
+		if err := p.createLogGroup(ctx, logGroupName); err != nil {
+			return outcome, err
+		}
+	}
+
+	// A new log group never expires
+	current := "never_expire"
+	if existing.Exists {
+		current = existing.Details["retention_in_days"]
+	}
+	if current == logRetention(retentionDays) {
+		return outcome, nil
+	}
+	if err := p.setLogRetention(ctx, logGroupName, retentionDays); err != nil {
+		return outcome, err
+	}
+	if outcome == outcomeUnchanged {
+		outcome = outcomeUpdated
+	}
+	return outcome, nil
+}
+
+// logRetention formats retentionDays the way describeLogGroup reports it.
+func logRetention(retentionDays int) string {
+	if retentionDays == 0 {
+		return "never_expire"
+	}
+	return strconv.Itoa(retentionDays)
+}
+
+// planLogGroup reports what ensureLogGroup would do, without changing
+// anything.
+func (p *ResourceProvisioner) planLogGroup(ctx context.Context, logGroupName string, retentionDays int) (*models.PlannedResource, error) {
+	planned := &models.PlannedResource{
+		ARN:    fmt.Sprintf("arn:aws:logs:%s:%s:log-group:%s:*", p.region, p.accountID, logGroupName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"retention_in_days": logRetention(retentionDays),
+		},
+	}
+
+	existing, err := p.describeLogGroup(ctx, logGroupName)
+	if err != nil {
+		return planned, err
+	}
+	switch {
+	case !existing.Exists:
+		planned.Action = models.PlanActionCreate
+	case existing.Details["retention_in_days"] != logRetention(retentionDays):
+		planned.Action = models.PlanActionUpdate
+		planned.Changes = []string{fmt.Sprintf("retention_in_days: %s -> %s", existing.Details["retention_in_days"], logRetention(retentionDays))}
+	}
+	return planned, nil
+}
+
+// setLogRetention sets the log group's retention, removing it for 0.
+func (p *ResourceProvisioner) setLogRetention(ctx context.Context, logGroupName string, retentionDays int) error {
+	p.log(ctx).Info("Setting CloudWatch Log Group retention", "retention_in_days", retentionDays)
+
+	if retentionDays == 0 {
+		_, err := p.cloudwatchLogsClient.DeleteRetentionPolicy(ctx, &cloudwatchlogs.DeleteRetentionPolicyInput{
+			LogGroupName: aws.String(logGroupName),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove log group retention: %w", err)
+		}
+		return nil
+	}
+
+	_, err := p.cloudwatchLogsClient.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    aws.String(logGroupName),
+		RetentionInDays: aws.Int32(int32(retentionDays)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set log group retention: %w", err)
+	}
 	return nil
 }
+
 func (p *ResourceProvisioner) createLogGroup(ctx context.Context, logGroupName string) error {
-	p.logger.Info(fmt.Sprintf("Creating CloudWatch Log Group: %s", logGroupName))
+	p.log(ctx).Info("Creating CloudWatch Log Group")
 
 	_, err := p.cloudwatchLogsClient.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
 		LogGroupName: aws.String(logGroupName),
@@ -75,7 +319,7 @@ func (p *ResourceProvisioner) createLogGroup(ctx context.Context, logGroupName s
 }
 
 func (p *ResourceProvisioner) deleteLogGroup(ctx context.Context, logGroupName string) error {
-	p.logger.Info(fmt.Sprintf("Deleting CloudWatch Log Group: %s", logGroupName))
+	p.log(ctx).Info("Deleting CloudWatch Log Group")
 
 	_, err := p.cloudwatchLogsClient.DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{
 		LogGroupName: aws.String(logGroupName),
@@ -86,3 +330,62 @@ func (p *ResourceProvisioner) deleteLogGroup(ctx context.Context, logGroupName s
 
 	return nil
 }
+
+func (p *ResourceProvisioner) describeAlarm(ctx context.Context, alarmName string) (*models.ResourceStatus, error) {
+	result, err := p.cloudwatchClient.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{
+		AlarmNames: []string{alarmName},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe alarm: %w", err)
+	}
+	if len(result.MetricAlarms) == 0 {
+		return &models.ResourceStatus{}, nil
+	}
+
+	alarm := result.MetricAlarms[0]
+	return &models.ResourceStatus{
+		Exists: true,
+		ARN:    aws.ToString(alarm.AlarmArn),
+		Details: map[string]string{
+			"state":              string(alarm.StateValue),
+			"metric":             fmt.Sprintf("%s/%s", aws.ToString(alarm.Namespace), aws.ToString(alarm.MetricName)),
+			"threshold":          strconv.FormatFloat(aws.ToFloat64(alarm.Threshold), 'f', -1, 64),
+			"period":             strconv.Itoa(int(aws.ToInt32(alarm.Period))),
+			"evaluation_periods": strconv.Itoa(int(aws.ToInt32(alarm.EvaluationPeriods))),
+		},
+	}, nil
+}
+
+func (p *ResourceProvisioner) describeLogGroup(ctx context.Context, logGroupName string) (*models.ResourceStatus, error) {
+	// The lookup is by prefix, so only an exact name match counts, and it may
+	// be on any page if other groups share the prefix
+	pages := cloudwatchlogs.NewDescribeLogGroupsPaginator(p.cloudwatchLogsClient, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(logGroupName),
+	})
+	for pages.HasMorePages() {
+		result, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe log group: %w", err)
+		}
+
+		for _, group := range result.LogGroups {
+			if aws.ToString(group.LogGroupName) != logGroupName {
+				continue
+			}
+
+			retention := "never_expire"
+			if group.RetentionInDays != nil {
+				retention = strconv.Itoa(int(*group.RetentionInDays))
+			}
+			return &models.ResourceStatus{
+				Exists: true,
+				ARN:    aws.ToString(group.Arn),
+				Details: map[string]string{
+					"retention_in_days": retention,
+				},
+			}, nil
+		}
+	}
+
+	return &models.ResourceStatus{}, nil
+}
@@ -0,0 +1,44 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// Other clients' log groups can share the client's as a prefix, filling more
+// than one page of DescribeLogGroups.
+func TestLogGroupFoundAmongGroupsSharingItsPrefix(t *testing.T) {
+	ctx := context.Background()
+	cloud := newTestCloud(testAccount, "us-east-1")
+	for i := range 120 {
+		name := fmt.Sprintf("/aws/client/dev/acme-%03d", i)
+		if _, err := cloud.CloudWatchLogs.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(name)}); err != nil {
+			t.Fatalf("CreateLogGroup(%s) error = %v", name, err)
+		}
+	}
+	p := newTestProvisioner(t, cloud)
+	req := &models.ProvisionRequest{ClientID: "acme", ClientName: "Acme"}
+
+	for run := 1; run <= 2; run++ {
+		if _, err := p.ProvisionClientResources(ctx, req); err != nil {
+			t.Fatalf("run %d: ProvisionClientResources() error = %v", run, err)
+		}
+	}
+
+	inRegion, ctx, err := p.inRegion(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("inRegion() error = %v", err)
+	}
+	status, err := inRegion.describeLogGroup(ctx, "/aws/client/dev/acme")
+	if err != nil {
+		t.Fatalf("describeLogGroup() error = %v", err)
+	}
+	if !status.Exists || status.Details["retention_in_days"] == "" {
+		t.Errorf("describeLogGroup() = %+v, want the client's group", status)
+	}
+}
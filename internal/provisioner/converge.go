@@ -0,0 +1,35 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+)
+
+// Outcomes of converging a resource towards its desired configuration.
+const (
+	outcomeCreated   = "created"
+	outcomeUpdated   = "updated"
+	outcomeUnchanged = "unchanged"
+)
+
+// documentsEqual reports whether two JSON policy documents or event patterns
+// are equivalent, ignoring whitespace and key order. IAM returns documents
+// URL-encoded, so either side may be encoded.
+func documentsEqual(a, b string) bool {
+	var va, vb interface{}
+	if err := json.Unmarshal([]byte(decodeDocument(a)), &va); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(decodeDocument(b)), &vb); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func decodeDocument(document string) string {
+	if decoded, err := url.QueryUnescape(document); err == nil {
+		return decoded
+	}
+	return document
+}
@@ -0,0 +1,155 @@
+package provisioner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestProvisionConvergesDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		drift    func(ctx context.Context, cloud *fake.Cloud) error
+	}{
+		{
+			name:     "log retention",
+			resource: nodeLogGroup,
+			drift: func(ctx context.Context, cloud *fake.Cloud) error {
+				_, err := cloud.CloudWatchLogs.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+					LogGroupName:    aws.String("/aws/client/dev/acme"),
+					RetentionInDays: aws.Int32(1),
+				})
+				return err
+			},
+		},
+		{
+			name:     "bucket versioning",
+			resource: nodeBucket,
+			drift: func(ctx context.Context, cloud *fake.Cloud) error {
+				_, err := cloud.S3.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+					Bucket:                  aws.String("dev-acme-bucket"),
+					VersioningConfiguration: &s3types.VersioningConfiguration{Status: s3types.BucketVersioningStatusSuspended},
+				})
+				return err
+			},
+		},
+		{
+			name:     "bucket lifecycle filter",
+			resource: nodeBucket,
+			drift: func(ctx context.Context, cloud *fake.Cloud) error {
+				rules := bucketLifecycleRules(DefaultOptions())
+				rules[0].Filter = &s3types.LifecycleRuleFilterMemberPrefix{Value: "tmp/"}
+				_, err := cloud.S3.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+					Bucket:                 aws.String("dev-acme-bucket"),
+					LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{Rules: rules},
+				})
+				return err
+			},
+		},
+		{
+			name:     "role trust policy",
+			resource: nodeRole,
+			drift: func(ctx context.Context, cloud *fake.Cloud) error {
+				_, err := cloud.IAM.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+					RoleName:       aws.String("dev-acme-role"),
+					PolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[]}`),
+				})
+				return err
+			},
+		},
+		{
+			name:     "function memory",
+			resource: nodeLambda,
+			drift: func(ctx context.Context, cloud *fake.Cloud) error {
+				_, err := cloud.Lambda.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+					FunctionName: aws.String("dev-acme-processor"),
+					MemorySize:   aws.Int32(1024),
+				})
+				return err
+			},
+		},
+		{
+			name:     "disabled rule",
+			resource: nodeRule,
+			drift: func(ctx context.Context, cloud *fake.Cloud) error {
+				rule, _ := cloud.EventBridge.Rule("dev-acme-rule")
+				_, err := cloud.EventBridge.PutRule(ctx, &eventbridge.PutRuleInput{
+					Name:         aws.String("dev-acme-rule"),
+					EventPattern: aws.String(rule.EventPattern),
+					State:        ebtypes.RuleStateDisabled,
+				})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			if _, err := p.ProvisionClientResources(ctx, testRequest("acme")); err != nil {
+				t.Fatalf("first run: error = %v", err)
+			}
+			if err := tt.drift(ctx, cloud); err != nil {
+				t.Fatalf("drift: error = %v", err)
+			}
+
+			resp, err := p.ProvisionClientResources(ctx, testRequest("acme"))
+			if err != nil {
+				t.Fatalf("second run: error = %v", err)
+			}
+			for _, result := range resp.Resources {
+				want := outcomeUnchanged
+				if result.Type == tt.resource {
+					want = outcomeUpdated
+				}
+				if result.Status != want {
+					t.Errorf("second run: %s is %s, want %s", result.Type, result.Status, want)
+				}
+			}
+
+			// The drift is repaired, so another run changes nothing
+			resp, err = p.ProvisionClientResources(ctx, testRequest("acme"))
+			if err != nil {
+				t.Fatalf("third run: error = %v", err)
+			}
+			for _, result := range resp.Resources {
+				if result.Status != outcomeUnchanged {
+					t.Errorf("third run: %s is %s, want %s", result.Type, result.Status, outcomeUnchanged)
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentsEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"identical", `{"a":1}`, `{"a":1}`, true},
+		{"whitespace and key order", `{"a": 1, "b": [1, 2]}`, "{\n  \"b\": [1,2],\n  \"a\": 1\n}", true},
+		{"url-encoded", `%7B%22a%22%3A1%7D`, `{"a":1}`, true},
+		{"different value", `{"a":1}`, `{"a":2}`, false},
+		{"different list order", `{"a":[1,2]}`, `{"a":[2,1]}`, false},
+		{"invalid", `{"a":1}`, `not json`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := documentsEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("documentsEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
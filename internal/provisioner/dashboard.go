@@ -0,0 +1,147 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// dashboardBody graphs the client's log processor, log volume and error
+// count in p's region.
+func (p *ResourceProvisioner) dashboardBody(clientID string) string {
+	names := p.namesFor(clientID)
+	widget := func(x, y int, title string, metrics ...[]string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":   "metric",
+			"x":      x,
+			"y":      y,
+			"width":  12,
+			"height": 6,
+			"properties": map[string]interface{}{
+				"title":   title,
+				"region":  p.region,
+				"stat":    "Sum",
+				"period":  300,
+				"metrics": metrics,
+			},
+		}
+	}
+
+	body := map[string]interface{}{
+		"widgets": []interface{}{
+			widget(0, 0, "Log processor invocations and errors",
+				[]string{"AWS/Lambda", "Invocations", "FunctionName", names.lambda},
+				[]string{"AWS/Lambda", "Errors", "FunctionName", names.lambda},
+				[]string{"AWS/Lambda", "Throttles", "FunctionName", names.lambda}),
+			widget(12, 0, "Log processor duration",
+				[]string{"AWS/Lambda", "Duration", "FunctionName", names.lambda}),
+			widget(0, 6, "Incoming log events",
+				[]string{"AWS/Logs", "IncomingLogEvents", "LogGroupName", names.logGroup}),
+			widget(12, 6, "Client errors",
+				[]string{"Custom/ClientLogs", "ErrorCount", "ClientID", clientID}),
+		},
+	}
+	// The body is built from maps and strings only, so it always encodes
+	document, _ := json.Marshal(body)
+	return string(document)
+}
+
+// dashboardARN builds the ARN of a dashboard, which has no region.
+func (p *ResourceProvisioner) dashboardARN(dashboardName string) string {
+	return fmt.Sprintf("arn:aws:cloudwatch::%s:dashboard/%s", p.accountID, dashboardName)
+}
+
+// ensureDashboard creates the dashboard, or replaces it if its body drifted.
+func (p *ResourceProvisioner) ensureDashboard(ctx context.Context, dashboardName, body string) (string, string, error) {
+	existing, err := p.cloudwatchClient.GetDashboard(ctx, &cloudwatch.GetDashboardInput{
+		DashboardName: aws.String(dashboardName),
+	})
+	outcome := outcomeCreated
+	switch {
+	case isNotFound(err):
+	case err != nil:
+		return "", "", fmt.Errorf("failed to check dashboard %s: %w", dashboardName, err)
+	case documentsEqual(aws.ToString(existing.DashboardBody), body):
+		return aws.ToString(existing.DashboardArn), outcomeUnchanged, nil
+	default:
+		outcome = outcomeUpdated
+	}
+
+	p.log(ctx).Info("Putting CloudWatch Dashboard")
+	_, err = p.cloudwatchClient.PutDashboard(ctx, &cloudwatch.PutDashboardInput{
+		DashboardName: aws.String(dashboardName),
+		DashboardBody: aws.String(body),
+	})
+	if err != nil {
+		return "", outcome, fmt.Errorf("failed to put dashboard %s: %w", dashboardName, err)
+	}
+	return p.dashboardARN(dashboardName), outcome, nil
+}
+
+// planDashboard reports what ensureDashboard would do, without changing
+// anything.
+func (p *ResourceProvisioner) planDashboard(ctx context.Context, dashboardName, body string) (*models.PlannedResource, error) {
+	planned := &models.PlannedResource{
+		ARN:    p.dashboardARN(dashboardName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"body": json.RawMessage(body),
+		},
+	}
+
+	existing, err := p.cloudwatchClient.GetDashboard(ctx, &cloudwatch.GetDashboardInput{
+		DashboardName: aws.String(dashboardName),
+	})
+	switch {
+	case isNotFound(err):
+		planned.Action = models.PlanActionCreate
+	case err != nil:
+		return planned, fmt.Errorf("failed to check dashboard %s: %w", dashboardName, err)
+	case !documentsEqual(aws.ToString(existing.DashboardBody), body):
+		planned.Action = models.PlanActionUpdate
+		planned.Changes = []string{"body"}
+	}
+	return planned, nil
+}
+
+func (p *ResourceProvisioner) describeDashboard(ctx context.Context, dashboardName string) (*models.ResourceStatus, error) {
+	result, err := p.cloudwatchClient.GetDashboard(ctx, &cloudwatch.GetDashboardInput{
+		DashboardName: aws.String(dashboardName),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return &models.ResourceStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to describe dashboard: %w", err)
+	}
+
+	var body struct {
+		Widgets []json.RawMessage `json:"widgets"`
+	}
+	_ = json.Unmarshal([]byte(aws.ToString(result.DashboardBody)), &body)
+	return &models.ResourceStatus{
+		Exists: true,
+		ARN:    aws.ToString(result.DashboardArn),
+		Details: map[string]string{
+			"widgets": strconv.Itoa(len(body.Widgets)),
+		},
+	}, nil
+}
+
+func (p *ResourceProvisioner) deleteDashboard(ctx context.Context, dashboardName string) error {
+	p.log(ctx).Info("Deleting CloudWatch Dashboard")
+
+	_, err := p.cloudwatchClient.DeleteDashboards(ctx, &cloudwatch.DeleteDashboardsInput{
+		DashboardNames: []string{dashboardName},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete dashboard: %w", err)
+	}
+
+	return nil
+}
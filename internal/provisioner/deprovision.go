@@ -0,0 +1,150 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// DeprovisionClientResources removes every resource ProvisionClientResources
+// created for a client, of its current tier and any earlier one, in the
+// account it is recorded in and in region, or else every region it is
+// recorded in, dependents before the resources they depend on. Every resource
+// is attempted even if an earlier one fails, and resources that are already
+// gone are reported as not_found rather than as failures. A region is dropped
+// from the record once all of its resources are gone.
+//
+// If a region cannot be reached, or ctx is cancelled, teardown stops there
+// and the response reports the resources handled so far. A client with no
+// record is not torn down, as its resources, if any, are unknown.
+func (p *ResourceProvisioner) DeprovisionClientResources(ctx context.Context, clientID, region string) (_ *models.DeprovisionResponse, err error) {
+	ctx, span := startSpan(ctx, "DeprovisionClientResources", clientID)
+	defer func() { endSpan(span, err) }()
+	p, existing, err := p.forClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, models.NewProvisionError(models.ErrCodeNotFound, fmt.Sprintf("client %s is not provisioned", clientID), nil)
+	}
+	ctx = p.withClient(ctx, clientID)
+	p.log(ctx).Info("Starting resource teardown")
+
+	record, err := p.beginRecord(ctx, clientID, state.StatusDeprovisioning, nil)
+	if err != nil {
+		return nil, err
+	}
+	regions := p.clientRegions(record, region)
+
+	response := &models.DeprovisionResponse{
+		ClientID:  clientID,
+		AccountID: p.accountID,
+		Regions:   regions,
+		Status:    "success",
+	}
+
+	failed := 0
+	var firstErr error
+	var firstFailed *resourceNode
+	for _, region := range regions {
+		rp, regionCtx, err := p.inRegion(ctx, region)
+		if err != nil {
+			p.finishRecord(ctx, record, state.StatusFailed, err)
+			response.Status = "failed"
+			return response, err
+		}
+		// Deleting does not depend on the options resources were created with
+		nodes, err := rp.clientResources(clientID, DefaultOptions(), rp.recordedComponents(record, clientID))
+		if err != nil {
+			p.finishRecord(ctx, record, state.StatusFailed, err)
+			response.Status = "failed"
+			return response, err
+		}
+
+		// Walk the graph backwards so dependents go before what they depend on
+		regionFailed := 0
+		for i := len(nodes) - 1; i >= 0; i-- {
+			// Once cancelled, as jobs are at shutdown, stop and leave the rest
+			// to the next teardown
+			if err := ctx.Err(); err != nil {
+				ctx = context.WithoutCancel(ctx)
+				err = models.NewProvisionError(models.ErrCodeUnavailable, "teardown interrupted, deprovision again to resume", err)
+				p.log(ctx).Warn("Teardown interrupted, leaving remaining resources for the next run")
+				p.finishRecord(ctx, record, state.StatusInterrupted, err)
+				response.Status = state.StatusInterrupted
+				return response, err
+			}
+			n := nodes[i]
+			result := models.ResourceResult{
+				Region: region,
+				Type:   n.resourceType,
+				Name:   n.name,
+				Status: "deleted",
+			}
+
+			arn := ""
+			if resource, ok := record.Resource(region, n.resourceType, n.name); ok {
+				arn = resource.ARN
+			}
+			nodeCtx := rp.withNode(regionCtx, n)
+			_, err := rp.audited(nodeCtx, n, func(ctx context.Context) (string, error) {
+				return arn, n.delete(ctx)
+			})
+			if err != nil {
+				if isNotFound(err) {
+					result.Status = "not_found"
+				} else {
+					rp.log(nodeCtx).Error("Failed to delete resource", logger.Err(err))
+					result.Status = "failed"
+					result.Error = classifyError(n.id, n.name, fmt.Sprintf("failed to delete %s %s", n.resourceType, n.name), err).ClientMessage()
+					if failed == 0 {
+						firstErr, firstFailed = err, n
+					}
+					failed++
+					regionFailed++
+				}
+			}
+
+			response.Resources = append(response.Resources, result)
+
+			resourceStatus := state.ResourceDeleted
+			if result.Status == "failed" {
+				resourceStatus = state.ResourceFailed
+			}
+			record.SetResource(state.Resource{
+				Region: region,
+				Type:   n.resourceType,
+				Name:   n.name,
+				Status: resourceStatus,
+				Error:  result.Error,
+			})
+			p.saveRecord(ctx, record)
+		}
+
+		if regionFailed == 0 {
+			record.RemoveRegion(region)
+		}
+	}
+
+	if failed > 0 {
+		response.Status = "failed"
+		// The error is classified by the first failure
+		message := fmt.Sprintf("failed to delete %d of %d resources for client %s", failed, len(response.Resources), clientID)
+		err := classifyError(firstFailed.id, firstFailed.name, message, firstErr)
+		p.finishRecord(ctx, record, state.StatusFailed, err)
+		return response, err
+	}
+
+	// Resources left in other regions keep the client provisioned
+	status := state.StatusDeprovisioned
+	if len(record.Regions) > 0 {
+		status = state.StatusProvisioned
+	}
+	p.finishRecord(ctx, record, status, nil)
+
+	p.log(ctx).Info("Successfully removed all resources", "regions", regions)
+	return response, nil
+}
@@ -0,0 +1,126 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+)
+
+// provisionRegions provisions the client in the region of each cloud.
+func provisionRegions(t *testing.T, p *ResourceProvisioner, clientID string, clouds ...*fake.Cloud) {
+	t.Helper()
+	req := testRequest(clientID)
+	for _, cloud := range clouds {
+		req.Regions = append(req.Regions, cloud.Region)
+	}
+	if _, err := p.ProvisionClientRegions(context.Background(), req); err != nil {
+		t.Fatalf("ProvisionClientRegions() error = %v", err)
+	}
+}
+
+func TestDeprovisionRegions(t *testing.T) {
+	tests := []struct {
+		name        string
+		region      string
+		wantRegions []string
+		wantRecord  string
+		wantLeft    []string
+	}{
+		{name: "every region", wantRegions: []string{"us-east-1", "eu-west-1"}, wantRecord: state.StatusDeprovisioned},
+		{name: "one region", region: "eu-west-1", wantRegions: []string{"eu-west-1"}, wantRecord: state.StatusProvisioned, wantLeft: []string{"us-east-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			home := newTestCloud(testAccount, "us-east-1")
+			abroad := newTestCloud(testAccount, "eu-west-1")
+			p := newTestProvisioner(t, home, abroad)
+			provisionRegions(t, p, "acme", home, abroad)
+
+			resp, err := p.DeprovisionClientResources(ctx, "acme", tt.region)
+			if err != nil {
+				t.Fatalf("DeprovisionClientResources() error = %v", err)
+			}
+			if !slices.Equal(resp.Regions, tt.wantRegions) || len(resp.Resources) != len(tt.wantRegions)*len(builtinComponents) {
+				t.Errorf("response covers %v with %d resources, want %v", resp.Regions, len(resp.Resources), tt.wantRegions)
+			}
+
+			record, err := p.store.Get(ctx, "acme")
+			if err != nil {
+				t.Fatalf("store.Get() error = %v", err)
+			}
+			if record.Status != tt.wantRecord || !slices.Equal(record.Regions, tt.wantLeft) {
+				t.Errorf("record is %s in %v, want %s in %v", record.Status, record.Regions, tt.wantRecord, tt.wantLeft)
+			}
+			for _, cloud := range []*fake.Cloud{home, abroad} {
+				want := builtinComponents
+				if slices.Contains(tt.wantRegions, cloud.Region) {
+					want = nil
+				}
+				if got := existingResources(p, cloud, "acme"); !slices.Equal(got, want) {
+					t.Errorf("%s: resources = %v, want %v", cloud.Region, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprovisionStopsEarly(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancel     bool
+		wantStatus string
+		wantCode   string
+	}{
+		// The client's second region can no longer be reached
+		{name: "unreachable region", wantStatus: state.StatusFailed},
+		{name: "cancelled", cancel: true, wantStatus: state.StatusInterrupted, wantCode: models.ErrCodeUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := newTestCloud(testAccount, "us-east-1")
+			abroad := newTestCloud(testAccount, "eu-west-1")
+			p := newTestProvisioner(t, home, abroad)
+			provisionRegions(t, p, "acme", home, abroad)
+			p.clients = fake.NewFactory(home)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+			resp, err := p.DeprovisionClientResources(ctx, "acme", "")
+			if err == nil {
+				t.Fatal("DeprovisionClientResources() succeeded")
+			}
+			var perr *models.ProvisionError
+			if tt.wantCode != "" && (!errors.As(err, &perr) || perr.Code != tt.wantCode) {
+				t.Errorf("error = %v, want %s", err, tt.wantCode)
+			}
+			if resp == nil || resp.Status != tt.wantStatus {
+				t.Fatalf("response = %+v, want status %s", resp, tt.wantStatus)
+			}
+
+			// What was handled before stopping is reported
+			wantHandled := len(builtinComponents)
+			if tt.cancel {
+				wantHandled = 0
+			}
+			if len(resp.Resources) != wantHandled {
+				t.Errorf("got %d resource results, want %d", len(resp.Resources), wantHandled)
+			}
+			record, err := p.store.Get(context.Background(), "acme")
+			if err != nil {
+				t.Fatalf("store.Get() error = %v", err)
+			}
+			if record.Status != tt.wantStatus {
+				t.Errorf("record status = %s, want %s", record.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/aws/smithy-go"
+)
+
+// notFoundCodes are the error codes the services we use return for a missing resource.
+var notFoundCodes = map[string]bool{
+	"NoSuchBucket":              true,
+	"NotFound":                  true,
+	"NoSuchEntity":              true,
+	"ResourceNotFoundException": true,
+	"ResourceNotFound":          true,
+}
+
+// errorCode returns the AWS error code carried by err, or "" if there is none.
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func isNotFound(err error) bool {
+	return notFoundCodes[errorCode(err)]
+}
+
+// isRoleNotAssumable reports whether Lambda rejected an execution role it
+// cannot assume, which is what it returns while a new role is still
+// propagating through IAM.
+func isRoleNotAssumable(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode() == "InvalidParameterValueException" &&
+		strings.Contains(apiErr.ErrorMessage(), "cannot be assumed")
+}
+
+// errorCodes maps the AWS error codes of the services we use to the error
+// codes reported to API clients. Codes not listed fall back on the fault.
+var errorCodes = map[string]string{
+	"BucketAlreadyExists":            models.ErrCodeAlreadyExists,
+	"BucketAlreadyOwnedByYou":        models.ErrCodeAlreadyExists,
+	"EntityAlreadyExists":            models.ErrCodeAlreadyExists,
+	"ResourceAlreadyExistsException": models.ErrCodeAlreadyExists,
+	"ResourceConflictException":      models.ErrCodeAlreadyExists,
+
+	"Throttling":                      models.ErrCodeThrottled,
+	"ThrottlingException":             models.ErrCodeThrottled,
+	"ThrottledException":              models.ErrCodeThrottled,
+	"TooManyRequestsException":        models.ErrCodeThrottled,
+	"RequestLimitExceeded":            models.ErrCodeThrottled,
+	"SlowDown":                        models.ErrCodeThrottled,
+	"PriorRequestNotComplete":         models.ErrCodeThrottled,
+	"ConcurrentModification":          models.ErrCodeThrottled,
+	"ConcurrentModificationException": models.ErrCodeThrottled,
+
+	"AccessDenied":                models.ErrCodeAccessDenied,
+	"AccessDeniedException":       models.ErrCodeAccessDenied,
+	"AuthorizationError":          models.ErrCodeAccessDenied,
+	"UnauthorizedOperation":       models.ErrCodeAccessDenied,
+	"InvalidClientTokenId":        models.ErrCodeAccessDenied,
+	"UnrecognizedClientException": models.ErrCodeAccessDenied,
+	"ExpiredToken":                models.ErrCodeAccessDenied,
+	"ExpiredTokenException":       models.ErrCodeAccessDenied,
+
+	"LimitExceeded":                 models.ErrCodeLimitExceeded,
+	"LimitExceededException":        models.ErrCodeLimitExceeded,
+	"TooManyBuckets":                models.ErrCodeLimitExceeded,
+	"CodeStorageExceededException":  models.ErrCodeLimitExceeded,
+	"ServiceQuotaExceededException": models.ErrCodeLimitExceeded,
+	"SubscriptionLimitExceeded":     models.ErrCodeLimitExceeded,
+	"TopicLimitExceeded":            models.ErrCodeLimitExceeded,
+
+	"InvalidBucketName":              models.ErrCodeInvalidName,
+	"ValidationError":                models.ErrCodeInvalidName,
+	"ValidationException":            models.ErrCodeInvalidName,
+	"InvalidParameter":               models.ErrCodeInvalidName,
+	"InvalidParameterException":      models.ErrCodeInvalidName,
+	"InvalidParameterValueException": models.ErrCodeInvalidName,
+	"InvalidParameterValue":          models.ErrCodeInvalidName,
+}
+
+// classifyError turns err into a ProvisionError recording the step and
+// resource it happened in, what kind of failure it was and, for AWS errors,
+// the AWS error code and request ID. Errors that already are ProvisionErrors
+// are returned as they are.
+func classifyError(step, resource, message string, err error) *models.ProvisionError {
+	var perr *models.ProvisionError
+	if errors.As(err, &perr) {
+		return perr
+	}
+
+	perr = models.NewProvisionError(models.ErrCodeInternal, message, err)
+	perr.Step = step
+	perr.Resource = resource
+
+	var requestErr interface{ ServiceRequestID() string }
+	if errors.As(err, &requestErr) {
+		perr.RequestID = requestErr.ServiceRequestID()
+	}
+
+	var apiErr smithy.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		perr.AWSCode = apiErr.ErrorCode()
+		code, ok := errorCodes[apiErr.ErrorCode()]
+		if !ok || isRoleNotAssumable(err) {
+			// Unknown AWS failures, and Lambda not accepting a role that
+			// has not yet propagated, are failures of AWS rather than of
+			// the request
+			code = models.ErrCodeDependencyFailed
+		}
+		perr.Code = code
+	case errors.Is(err, context.DeadlineExceeded):
+		perr.Code = models.ErrCodeDependencyFailed
+	}
+	return perr
+}
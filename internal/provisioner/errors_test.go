@@ -0,0 +1,85 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/aws/smithy-go"
+)
+
+// requestError is an AWS error carrying a request ID, the way the SDK's
+// response errors do.
+type requestError struct {
+	smithy.GenericAPIError
+	requestID string
+}
+
+func (e *requestError) ServiceRequestID() string { return e.requestID }
+
+func TestClassifyError(t *testing.T) {
+	existing := models.NewProvisionError(models.ErrCodeConflict, "busy", nil)
+
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      string
+		wantAWSCode   string
+		wantRequestID string
+	}{
+		{"already exists", &smithy.GenericAPIError{Code: "BucketAlreadyExists"}, models.ErrCodeAlreadyExists, "BucketAlreadyExists", ""},
+		{"throttled", &smithy.GenericAPIError{Code: "ThrottlingException"}, models.ErrCodeThrottled, "ThrottlingException", ""},
+		{"access denied", &smithy.GenericAPIError{Code: "AccessDeniedException"}, models.ErrCodeAccessDenied, "AccessDeniedException", ""},
+		{"limit exceeded", &smithy.GenericAPIError{Code: "TooManyBuckets"}, models.ErrCodeLimitExceeded, "TooManyBuckets", ""},
+		{"invalid name", &smithy.GenericAPIError{Code: "InvalidBucketName"}, models.ErrCodeInvalidName, "InvalidBucketName", ""},
+		{"unknown AWS code", &smithy.GenericAPIError{Code: "InternalFailure"}, models.ErrCodeDependencyFailed, "InternalFailure", ""},
+		{
+			"role not assumable yet",
+			&smithy.GenericAPIError{Code: "InvalidParameterValueException", Message: "The role defined for the function cannot be assumed by Lambda."},
+			models.ErrCodeDependencyFailed, "InvalidParameterValueException", "",
+		},
+		{
+			"wrapped with request ID",
+			fmt.Errorf("failed to create bucket: %w", &requestError{GenericAPIError: smithy.GenericAPIError{Code: "AccessDenied"}, requestID: "req-1"}),
+			models.ErrCodeAccessDenied, "AccessDenied", "req-1",
+		},
+		{"deadline exceeded", fmt.Errorf("waiting: %w", context.DeadlineExceeded), models.ErrCodeDependencyFailed, "", ""},
+		{"plain error", errors.New("boom"), models.ErrCodeInternal, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perr := classifyError(nodeBucket, "dev-acme-bucket", "failed to apply", tt.err)
+			if perr.Code != tt.wantCode || perr.AWSCode != tt.wantAWSCode || perr.RequestID != tt.wantRequestID {
+				t.Errorf("classifyError() = code %q, AWS code %q, request ID %q, want %q, %q, %q",
+					perr.Code, perr.AWSCode, perr.RequestID, tt.wantCode, tt.wantAWSCode, tt.wantRequestID)
+			}
+			if perr.Step != nodeBucket || perr.Resource != "dev-acme-bucket" || perr.Message != "failed to apply" {
+				t.Errorf("classifyError() = %+v, want the step, resource and message it was given", perr)
+			}
+			if !errors.Is(perr, tt.err) {
+				t.Errorf("classifyError() does not wrap %v", tt.err)
+			}
+		})
+	}
+
+	if got := classifyError(nodeBucket, "dev-acme-bucket", "failed", fmt.Errorf("wrapped: %w", existing)); got != existing {
+		t.Errorf("classifyError() = %+v, want the ProvisionError it wraps", got)
+	}
+}
+
+func TestProvisionReportsClassifiedError(t *testing.T) {
+	cloud := newTestCloud(testAccount, "us-east-1")
+	cloud.FailOn("iam:CreateRole", &smithy.GenericAPIError{Code: "AccessDenied", Message: "not allowed"})
+	p := newTestProvisioner(t, cloud)
+
+	_, err := p.ProvisionClientResources(context.Background(), testRequest("acme"))
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) {
+		t.Fatalf("ProvisionClientResources() error = %v, want a *models.ProvisionError", err)
+	}
+	if perr.Code != models.ErrCodeAccessDenied || perr.AWSCode != "AccessDenied" || perr.Step != nodeRole || perr.Resource != p.namesIn("acme", cloud.Region).role {
+		t.Errorf("ProvisionClientResources() error = %+v, want ACCESS_DENIED on the role", perr)
+	}
+}
@@ -2,18 +2,21 @@ package provisioner
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/smithy-go"
 )
 
-func (p *ResourceProvisioner) createEventRule(ctx context.Context, ruleName, logGroupName, lambdaARN string) error {
-	p.logger.Info(fmt.Sprintf("Creating EventBridge rule: %s", ruleName))
-
-	// Create rule pattern to match log events
-	pattern := fmt.Sprintf(`{
+// eventPattern matches CloudTrail PutLogEvents calls for the client's log group.
+func eventPattern(logGroupName string) string {
+	return fmt.Sprintf(`{
         "source": ["aws.logs"],
         "detail-type": ["AWS API Call via CloudTrail"],
         "detail": {
@@ -24,20 +27,113 @@ func (p *ResourceProvisioner) createEventRule(ctx context.Context, ruleName, log
             }
         }
     }`, logGroupName)
+}
+
+// ensureEventRule creates the rule if it is missing, otherwise it restores the
+// pattern, state and Lambda target of the existing rule where they drifted.
+func (p *ResourceProvisioner) ensureEventRule(ctx context.Context, ruleName, logGroupName, lambdaARN string) (string, string, error) {
+	rule, err := p.eventBridgeClient.DescribeRule(ctx, &eventbridge.DescribeRuleInput{
+		Name: aws.String(ruleName),
+	})
+	if isNotFound(err) {
+		ruleARN, err := p.createEventRule(ctx, ruleName, logGroupName, lambdaARN)
+		return ruleARN, outcomeCreated, err
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check event rule: %w", err)
+	}
+
+	ruleARN := aws.ToString(rule.Arn)
+	outcome := outcomeUnchanged
+
+	if len(eventRuleChanges(rule, logGroupName)) > 0 {
+		p.log(ctx).Info("Updating EventBridge rule")
+		if _, err := p.putEventRule(ctx, ruleName, logGroupName); err != nil {
+			return "", "", err
+		}
+		outcome = outcomeUpdated
+	}
+
+	hasTarget, err := p.eventRuleHasTarget(ctx, ruleName, lambdaARN)
+	if err != nil {
+		return "", "", err
+	}
+	if !hasTarget {
+		p.log(ctx).Info("Updating EventBridge rule target")
+		if err := p.putEventTarget(ctx, ruleName, lambdaARN); err != nil {
+			return "", "", err
+		}
+		outcome = outcomeUpdated
+	}
+
+	return ruleARN, outcome, nil
+}
+
+// eventRuleChanges describes how an existing rule differs from what we create.
+func eventRuleChanges(rule *eventbridge.DescribeRuleOutput, logGroupName string) []string {
+	var changes []string
+	if rule.State != types.RuleStateEnabled {
+		changes = append(changes, fmt.Sprintf("state: %s -> %s", rule.State, types.RuleStateEnabled))
+	}
+	if !documentsEqual(aws.ToString(rule.EventPattern), eventPattern(logGroupName)) {
+		changes = append(changes, "event pattern")
+	}
+	return changes
+}
+
+// eventRuleHasTarget reports whether the rule targets the processor function.
+func (p *ResourceProvisioner) eventRuleHasTarget(ctx context.Context, ruleName, lambdaARN string) (bool, error) {
+	targets, err := p.eventBridgeClient.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+		Rule: aws.String(ruleName),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list event rule targets: %w", err)
+	}
+	for _, target := range targets.Targets {
+		if aws.ToString(target.Id) == "ProcessLogsFunction" && aws.ToString(target.Arn) == lambdaARN {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *ResourceProvisioner) createEventRule(ctx context.Context, ruleName, logGroupName, lambdaARN string) (string, error) {
+	p.log(ctx).Info("Creating EventBridge rule")
 
 	// Create the rule
-	_, err := p.eventBridgeClient.PutRule(ctx, &eventbridge.PutRuleInput{
+	ruleARN, err := p.putEventRule(ctx, ruleName, logGroupName)
+	if err != nil {
+		return "", err
+	}
+
+	// Add target (Lambda function)
+	if err := p.putEventTarget(ctx, ruleName, lambdaARN); err != nil {
+		return "", err
+	}
+
+	return ruleARN, nil
+}
+
+// putEventRule creates the rule or overwrites the pattern and state of an
+// existing one.
+func (p *ResourceProvisioner) putEventRule(ctx context.Context, ruleName, logGroupName string) (string, error) {
+	rule, err := p.eventBridgeClient.PutRule(ctx, &eventbridge.PutRuleInput{
 		Name:         aws.String(ruleName),
 		Description:  aws.String(fmt.Sprintf("Process logs from %s", logGroupName)),
-		EventPattern: aws.String(pattern),
+		EventPattern: aws.String(eventPattern(logGroupName)),
 		State:        types.RuleStateEnabled, // Fixed: Using the correct type
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create event rule: %w", err)
+		return "", fmt.Errorf("failed to put event rule: %w", err)
 	}
+	return *rule.RuleArn, nil
+}
 
-	// Add target (Lambda function)
-	_, err = p.eventBridgeClient.PutTargets(ctx, &eventbridge.PutTargetsInput{
+// putEventTarget points the rule at the function. EventBridge reports targets
+// it rejects in the output rather than as an error, so those are turned into
+// errors carrying the entry's error code.
+func (p *ResourceProvisioner) putEventTarget(ctx context.Context, ruleName, lambdaARN string) error {
+	out, err := p.eventBridgeClient.PutTargets(ctx, &eventbridge.PutTargetsInput{
 		Rule: aws.String(ruleName),
 		Targets: []types.Target{
 			{
@@ -49,13 +145,47 @@ func (p *ResourceProvisioner) createEventRule(ctx context.Context, ruleName, log
 	if err != nil {
 		return fmt.Errorf("failed to add target to event rule: %w", err)
 	}
-
+	if out.FailedEntryCount > 0 {
+		errs := make([]error, 0, len(out.FailedEntries))
+		for _, entry := range out.FailedEntries {
+			errs = append(errs, &smithy.GenericAPIError{
+				Code:    aws.ToString(entry.ErrorCode),
+				Message: fmt.Sprintf("target %s: %s", aws.ToString(entry.TargetId), aws.ToString(entry.ErrorMessage)),
+			})
+		}
+		return fmt.Errorf("event rule rejected %d target(s): %w", out.FailedEntryCount, errors.Join(errs...))
+	}
 	return nil
 }
+
+// deleteEventRule removes the rule's targets and then the rule itself;
+// EventBridge refuses to delete a rule that still has targets.
 func (p *ResourceProvisioner) deleteEventRule(ctx context.Context, ruleName string) error {
-	p.logger.Info(fmt.Sprintf("Deleting EventBridge rule: %s", ruleName))
+	p.log(ctx).Info("Deleting EventBridge rule")
+
+	targets, err := p.eventBridgeClient.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+		Rule: aws.String(ruleName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list event rule targets: %w", err)
+	}
 
-	_, err := p.eventBridgeClient.DeleteRule(ctx, &eventbridge.DeleteRuleInput{
+	if len(targets.Targets) > 0 {
+		ids := make([]string, 0, len(targets.Targets))
+		for _, target := range targets.Targets {
+			ids = append(ids, aws.ToString(target.Id))
+		}
+
+		_, err = p.eventBridgeClient.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+			Rule: aws.String(ruleName),
+			Ids:  ids,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove event rule targets: %w", err)
+		}
+	}
+
+	_, err = p.eventBridgeClient.DeleteRule(ctx, &eventbridge.DeleteRuleInput{
 		Name: aws.String(ruleName),
 	})
 	if err != nil {
@@ -64,3 +194,73 @@ func (p *ResourceProvisioner) deleteEventRule(ctx context.Context, ruleName stri
 
 	return nil
 }
+
+func (p *ResourceProvisioner) describeEventRule(ctx context.Context, ruleName string) (*models.ResourceStatus, error) {
+	rule, err := p.eventBridgeClient.DescribeRule(ctx, &eventbridge.DescribeRuleInput{
+		Name: aws.String(ruleName),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return &models.ResourceStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to describe event rule: %w", err)
+	}
+
+	targets, err := p.eventBridgeClient.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+		Rule: aws.String(ruleName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event rule targets: %w", err)
+	}
+	var targetARNs []string
+	for _, target := range targets.Targets {
+		targetARNs = append(targetARNs, aws.ToString(target.Arn))
+	}
+
+	return &models.ResourceStatus{
+		Exists: true,
+		ARN:    aws.ToString(rule.Arn),
+		Details: map[string]string{
+			"state":   string(rule.State),
+			"targets": strings.Join(targetARNs, ","),
+		},
+	}, nil
+}
+
+// planEventRule reports what ensureEventRule would do, without changing anything.
+func (p *ResourceProvisioner) planEventRule(ctx context.Context, ruleName, logGroupName, lambdaARN string) (*models.PlannedResource, error) {
+	planned := &models.PlannedResource{
+		ARN:    fmt.Sprintf("arn:aws:events:%s:%s:rule/%s", p.region, p.accountID, ruleName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"event_pattern": json.RawMessage(eventPattern(logGroupName)),
+			"state":         types.RuleStateEnabled,
+			"target":        map[string]string{"id": "ProcessLogsFunction", "arn": lambdaARN},
+		},
+	}
+
+	rule, err := p.eventBridgeClient.DescribeRule(ctx, &eventbridge.DescribeRuleInput{
+		Name: aws.String(ruleName),
+	})
+	switch {
+	case isNotFound(err):
+		planned.Action = models.PlanActionCreate
+		return planned, nil
+	case err != nil:
+		return planned, fmt.Errorf("failed to check event rule: %w", err)
+	}
+	planned.ARN = aws.ToString(rule.Arn)
+
+	planned.Changes = eventRuleChanges(rule, logGroupName)
+	hasTarget, err := p.eventRuleHasTarget(ctx, ruleName, lambdaARN)
+	if err != nil {
+		return planned, err
+	}
+	if !hasTarget {
+		planned.Changes = append(planned.Changes, "target ProcessLogsFunction")
+	}
+	if len(planned.Changes) > 0 {
+		planned.Action = models.PlanActionUpdate
+	}
+	return planned, nil
+}
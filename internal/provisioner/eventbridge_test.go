@@ -0,0 +1,39 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+func TestProvisionFailsOnRejectedEventTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		wantCode string
+	}{
+		{"access denied", "AccessDeniedException", models.ErrCodeAccessDenied},
+		{"unclassified", "InternalFailure", models.ErrCodeDependencyFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(testAccount, "us-east-1")
+			cloud.EventBridge.RejectTargets(tt.code)
+			p := newTestProvisioner(t, cloud)
+
+			_, err := p.ProvisionClientResources(context.Background(), &models.ProvisionRequest{ClientID: "acme", ClientName: "Acme"})
+			var perr *models.ProvisionError
+			if !errors.As(err, &perr) {
+				t.Fatalf("ProvisionClientResources() error = %v, want a ProvisionError", err)
+			}
+			if perr.Code != tt.wantCode || perr.AWSCode != tt.code {
+				t.Errorf("error code = %s (AWS %s), want %s (AWS %s)", perr.Code, perr.AWSCode, tt.wantCode, tt.code)
+			}
+			if _, ok := cloud.EventBridge.Rule("dev-acme-rule"); ok {
+				t.Error("rule with a rejected target was not rolled back")
+			}
+		})
+	}
+}
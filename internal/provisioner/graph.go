@@ -0,0 +1,195 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// resourceNode is one resource in a client's resource graph. It declares the
+// nodes it depends on and how to read, plan, apply and delete the resource;
+// apply creates the resource if it is missing and otherwise updates it in
+// place, plan reports what apply would do.
+type resourceNode struct {
+	id           string // unique within the graph, also the progress step name
+	clientID     string // the client the resource belongs to
+	resourceType string
+	name         string
+	dependsOn    []string
+
+	read func(ctx context.Context) (*models.ResourceStatus, error)
+	// apply receives the ARNs of the nodes it depends on, keyed by node ID,
+	// and returns the resource's ARN and outcome.
+	apply func(ctx context.Context, deps map[string]string) (string, string, error)
+	// plan receives dependency ARNs like apply. The planned resource it
+	// returns carries the ARN the resource has or will have, even on error.
+	plan   func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error)
+	delete func(ctx context.Context) error
+}
+
+// withNode returns a context whose log entries are tagged with the node's
+// resource.
+func (p *ResourceProvisioner) withNode(ctx context.Context, n *resourceNode) context.Context {
+	return logger.WithContext(ctx, p.log(ctx).With(logger.ResourceType, n.resourceType, logger.Resource, n.name))
+}
+
+// nodeResult is the outcome of applying one node.
+type nodeResult struct {
+	node    *resourceNode
+	arn     string
+	outcome string
+	err     error
+}
+
+var errDependencyFailed = errors.New("skipped because a dependency failed")
+
+// sortNodes orders the nodes so that every node comes after the nodes it
+// depends on, keeping the declared order wherever dependencies allow. It fails
+// on unknown dependencies and cycles.
+func sortNodes(nodes []*resourceNode) ([]*resourceNode, error) {
+	byID := make(map[string]*resourceNode, len(nodes))
+	for _, n := range nodes {
+		if _, ok := byID[n.id]; ok {
+			return nil, fmt.Errorf("duplicate resource node %s", n.id)
+		}
+		byID[n.id] = n
+	}
+	for _, n := range nodes {
+		for _, dep := range n.dependsOn {
+			if _, ok := byID[dep]; !ok {
+				return nil, fmt.Errorf("resource node %s depends on unknown node %s", n.id, dep)
+			}
+		}
+	}
+
+	sorted := make([]*resourceNode, 0, len(nodes))
+	placed := make(map[string]bool, len(nodes))
+	for len(sorted) < len(nodes) {
+		progressed := false
+		for _, n := range nodes {
+			if placed[n.id] {
+				continue
+			}
+			ready := true
+			for _, dep := range n.dependsOn {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				sorted = append(sorted, n)
+				placed[n.id] = true
+				progressed = true
+			}
+		}
+		if !progressed {
+			return nil, fmt.Errorf("resource graph has a dependency cycle")
+		}
+	}
+	return sorted, nil
+}
+
+// applyGraph applies the nodes in dependency order, running nodes whose
+// dependencies are all done concurrently. Once a node fails no further nodes
+// are started; nodes already running are allowed to finish. onApplied is
+// called for each node that succeeds, one call at a time.
+//
+// The results follow the order of nodes, which must already be sorted. The
+// returned error is the first failure, as a *models.ProvisionError.
+func (p *ResourceProvisioner) applyGraph(ctx context.Context, nodes []*resourceNode, onApplied func(nodeResult)) ([]nodeResult, error) {
+	results := make([]nodeResult, len(nodes))
+	index := make(map[string]int, len(nodes))
+	done := make([]chan struct{}, len(nodes))
+	for i, n := range nodes {
+		index[n.id] = i
+		done[i] = make(chan struct{})
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n *resourceNode) {
+			defer wg.Done()
+			defer close(done[i])
+			results[i].node = n
+
+			deps := make(map[string]string, len(n.dependsOn))
+			for _, dep := range n.dependsOn {
+				<-done[index[dep]]
+				if results[index[dep]].err != nil {
+					results[i].err = errDependencyFailed
+					return
+				}
+				deps[dep] = results[index[dep]].arn
+			}
+
+			mu.Lock()
+			stopped := firstErr != nil
+			mu.Unlock()
+			if stopped {
+				results[i].err = errDependencyFailed
+				return
+			}
+
+			ctx := p.withNode(ctx, n)
+			var arn, outcome string
+			err := p.runStep(ctx, n.id, func(ctx context.Context) (err error) {
+				arn, err = p.audited(ctx, n, func(ctx context.Context) (arn string, err error) {
+					arn, outcome, err = n.apply(ctx, deps)
+					return arn, err
+				})
+				return err
+			})
+			results[i].arn, results[i].outcome, results[i].err = arn, outcome, err
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = classifyError(n.id, n.name, fmt.Sprintf("failed to apply %s %s", n.resourceType, n.name), err)
+				}
+				return
+			}
+			onApplied(results[i])
+		}(i, n)
+	}
+
+	wg.Wait()
+	return results, firstErr
+}
+
+// rollback deletes, in reverse dependency order, every node the results show
+// as created by this run, including nodes that were created but then failed
+// to configure. Resources that are already gone are ignored. It returns the
+// nodes it rolled back.
+func (p *ResourceProvisioner) rollback(ctx context.Context, results []nodeResult) []*resourceNode {
+	var rolledBack []*resourceNode
+	for i := len(results) - 1; i >= 0; i-- {
+		result := results[i]
+		if result.outcome != outcomeCreated {
+			continue
+		}
+
+		n := result.node
+		nodeCtx := p.withNode(ctx, n)
+		_, err := p.audited(nodeCtx, n, func(ctx context.Context) (string, error) {
+			return result.arn, n.delete(ctx)
+		})
+		if err != nil && !isNotFound(err) {
+			p.log(nodeCtx).Error("Failed to roll back resource", logger.Err(err))
+			continue
+		}
+		rolledBack = append(rolledBack, n)
+	}
+	return rolledBack
+}
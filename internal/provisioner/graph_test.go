@@ -0,0 +1,218 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// testNode returns a node that applies by calling apply, if set, and
+// otherwise succeeds with an ARN derived from its ID.
+func testNode(id string, dependsOn []string, apply func(ctx context.Context, deps map[string]string) (string, string, error)) *resourceNode {
+	if apply == nil {
+		apply = func(ctx context.Context, deps map[string]string) (string, string, error) {
+			return "arn:" + id, outcomeCreated, nil
+		}
+	}
+	return &resourceNode{id: id, name: id, resourceType: "test", dependsOn: dependsOn, apply: apply}
+}
+
+func nodeIDs(nodes []*resourceNode) []string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.id
+	}
+	return ids
+}
+
+func TestSortNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []*resourceNode
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "declared order kept without dependencies",
+			nodes: []*resourceNode{testNode("a", nil, nil), testNode("b", nil, nil), testNode("c", nil, nil)},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name: "dependencies placed first",
+			nodes: []*resourceNode{
+				testNode("a", []string{"c"}, nil),
+				testNode("b", nil, nil),
+				testNode("c", []string{"b"}, nil),
+			},
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name:    "unknown dependency",
+			nodes:   []*resourceNode{testNode("a", []string{"missing"}, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "cycle",
+			nodes:   []*resourceNode{testNode("a", []string{"b"}, nil), testNode("b", []string{"a"}, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate",
+			nodes:   []*resourceNode{testNode("a", nil, nil), testNode("a", nil, nil)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := sortNodes(tt.nodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sortNodes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := nodeIDs(sorted); !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("sortNodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientResourcesOrder(t *testing.T) {
+	p := newTestProvisioner(t)
+	nodes, err := p.clientResources("acme", DefaultOptions(), Components)
+	if err != nil {
+		t.Fatalf("clientResources() error = %v", err)
+	}
+	if len(nodes) != len(Components) {
+		t.Fatalf("clientResources() returned %d nodes, want %d", len(nodes), len(Components))
+	}
+
+	position := map[string]int{}
+	for i, n := range nodes {
+		position[n.id] = i
+	}
+	for _, n := range nodes {
+		for _, dep := range n.dependsOn {
+			if position[dep] > position[n.id] {
+				t.Errorf("node %s comes before its dependency %s", n.id, dep)
+			}
+		}
+	}
+}
+
+func TestApplyGraph(t *testing.T) {
+	p := newTestProvisioner(t)
+
+	// a and b only proceed once both have started, so they must run
+	// concurrently; c receives both of their ARNs.
+	var started sync.WaitGroup
+	started.Add(2)
+	waitForBoth := func(id string) func(ctx context.Context, deps map[string]string) (string, string, error) {
+		return func(ctx context.Context, deps map[string]string) (string, string, error) {
+			started.Done()
+			started.Wait()
+			return "arn:" + id, outcomeCreated, nil
+		}
+	}
+	var gotDeps map[string]string
+	nodes := []*resourceNode{
+		testNode("a", nil, waitForBoth("a")),
+		testNode("b", nil, waitForBoth("b")),
+		testNode("c", []string{"a", "b"}, func(ctx context.Context, deps map[string]string) (string, string, error) {
+			gotDeps = deps
+			return "arn:c", outcomeUpdated, nil
+		}),
+	}
+
+	var applied []string
+	done := make(chan struct{})
+	var results []nodeResult
+	var err error
+	go func() {
+		defer close(done)
+		results, err = p.applyGraph(context.Background(), nodes, func(r nodeResult) {
+			applied = append(applied, r.node.id)
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("applyGraph() did not run independent nodes concurrently")
+	}
+
+	if err != nil {
+		t.Fatalf("applyGraph() error = %v", err)
+	}
+	if want := map[string]string{"a": "arn:a", "b": "arn:b"}; len(gotDeps) != 2 || gotDeps["a"] != want["a"] || gotDeps["b"] != want["b"] {
+		t.Errorf("dependency ARNs = %v, want %v", gotDeps, want)
+	}
+	if got := nodeIDs([]*resourceNode{results[0].node, results[1].node, results[2].node}); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("results follow %v, want the order of nodes", got)
+	}
+	if results[2].outcome != outcomeUpdated {
+		t.Errorf("results[2].outcome = %q, want %q", results[2].outcome, outcomeUpdated)
+	}
+	if len(applied) != 3 || applied[2] != "c" {
+		t.Errorf("onApplied called for %v, want a and b then c", applied)
+	}
+}
+
+func TestApplyGraphStopsOnFailure(t *testing.T) {
+	p := newTestProvisioner(t)
+	failure := errors.New("boom")
+	nodes := []*resourceNode{
+		testNode("a", nil, nil),
+		testNode("b", []string{"a"}, func(ctx context.Context, deps map[string]string) (string, string, error) {
+			return "", outcomeCreated, failure
+		}),
+		testNode("c", []string{"b"}, nil),
+	}
+
+	results, err := p.applyGraph(context.Background(), nodes, func(nodeResult) {})
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) {
+		t.Fatalf("applyGraph() error = %v, want a *models.ProvisionError", err)
+	}
+	if !errors.Is(err, failure) {
+		t.Errorf("applyGraph() error = %v, want it to wrap %v", err, failure)
+	}
+	if results[0].err != nil {
+		t.Errorf("results[0].err = %v, want nil", results[0].err)
+	}
+	if !errors.Is(results[2].err, errDependencyFailed) {
+		t.Errorf("results[2].err = %v, want %v", results[2].err, errDependencyFailed)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	p := newTestProvisioner(t)
+
+	var deleted []string
+	node := func(id string, deleteErr error) *resourceNode {
+		n := testNode(id, nil, nil)
+		n.delete = func(ctx context.Context) error {
+			deleted = append(deleted, id)
+			return deleteErr
+		}
+		return n
+	}
+	stuck := node("stuck", errors.New("delete failed"))
+	results := []nodeResult{
+		{node: node("first", nil), outcome: outcomeCreated},
+		{node: node("existing", nil), outcome: outcomeUnchanged},
+		{node: stuck, outcome: outcomeCreated},
+		{node: node("failed", nil), outcome: outcomeCreated, err: errors.New("configure failed")},
+	}
+
+	rolledBack := p.rollback(context.Background(), results)
+	if want := []string{"failed", "stuck", "first"}; !slices.Equal(deleted, want) {
+		t.Errorf("rollback() deleted %v, want %v", deleted, want)
+	}
+	if want := []string{"failed", "first"}; !slices.Equal(nodeIDs(rolledBack), want) {
+		t.Errorf("rollback() = %v, want %v", nodeIDs(rolledBack), want)
+	}
+}
@@ -2,19 +2,19 @@ package provisioner
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/iam/types"
 )
 
-func (p *ResourceProvisioner) createIAMRole(ctx context.Context, roleName, bucketName, logGroupName, lambdaName string) (string, error) {
-	p.logger.Info(fmt.Sprintf("Creating IAM role: %s", roleName))
-
-	// Updated trust relationship to properly allow Lambda
-	assumeRolePolicy := `{
+// lambdaTrustPolicy lets Lambda assume the client role.
+const lambdaTrustPolicy = `{
         "Version": "2012-10-17",
         "Statement": [
             {
@@ -27,10 +27,66 @@ func (p *ResourceProvisioner) createIAMRole(ctx context.Context, roleName, bucke
         ]
     }`
 
+// ensureIAMRole creates the role if it is missing, then makes sure its trust
+// policy, managed policies and inline policy match what we provision. Managed
+// policies attached by someone else are left in place.
+func (p *ResourceProvisioner) ensureIAMRole(ctx context.Context, roleName, bucketName, logGroupName string) (string, string, error) {
+	var roleARN string
+	outcome := outcomeUnchanged
+
+	role, err := p.iamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	})
+	switch {
+	case isNotFound(err):
+		outcome = outcomeCreated
+		roleARN, err = p.createIAMRole(ctx, roleName, bucketName)
+		if err != nil {
+			return "", outcome, err
+		}
+	case err != nil:
+		return "", "", fmt.Errorf("failed to check role: %w", err)
+	default:
+		roleARN = aws.ToString(role.Role.Arn)
+		if !documentsEqual(aws.ToString(role.Role.AssumeRolePolicyDocument), lambdaTrustPolicy) {
+			p.log(ctx).Info("Updating trust policy of IAM role")
+			_, err = p.iamClient.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+				RoleName:       aws.String(roleName),
+				PolicyDocument: aws.String(lambdaTrustPolicy),
+			})
+			if err != nil {
+				return "", "", fmt.Errorf("failed to update trust policy: %w", err)
+			}
+			outcome = outcomeUpdated
+		}
+	}
+
+	changed, err := p.attachRolePolicies(ctx, roleName)
+	if err != nil {
+		return roleARN, outcome, err
+	}
+	if changed && outcome == outcomeUnchanged {
+		outcome = outcomeUpdated
+	}
+
+	changed, err = p.putRoleInlinePolicy(ctx, roleName, bucketName, logGroupName)
+	if err != nil {
+		return roleARN, outcome, err
+	}
+	if changed && outcome == outcomeUnchanged {
+		outcome = outcomeUpdated
+	}
+
+	return roleARN, outcome, nil
+}
+
+func (p *ResourceProvisioner) createIAMRole(ctx context.Context, roleName, bucketName string) (string, error) {
+	p.log(ctx).Info("Creating IAM role")
+
 	// Create the role
 	roleResult, err := p.iamClient.CreateRole(ctx, &iam.CreateRoleInput{
 		RoleName:                 aws.String(roleName),
-		AssumeRolePolicyDocument: aws.String(assumeRolePolicy),
+		AssumeRolePolicyDocument: aws.String(lambdaTrustPolicy),
 		Description:              aws.String(fmt.Sprintf("Role for client: %s", bucketName)),
 		Tags: []types.Tag{
 			{Key: aws.String("Environment"), Value: aws.String(p.config.Environment)},
@@ -41,30 +97,81 @@ func (p *ResourceProvisioner) createIAMRole(ctx context.Context, roleName, bucke
 		return "", fmt.Errorf("failed to create role: %w", err)
 	}
 
-	// Add small delay to allow role to propagate
-	time.Sleep(10 * time.Second)
-
-	// Attach the existing policy
-	policyArn := fmt.Sprintf("arn:aws:iam::%s:policy/go-infra-policy", p.config.AWSAccountID)
-	_, err = p.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
-		RoleName:  aws.String(roleName),
-		PolicyArn: aws.String(policyArn),
-	})
+	// IAM is eventually consistent: wait until the role can be read back.
+	// Lambda may still reject it for a while, which createLambdaFunction
+	// retries.
+	err = iam.NewRoleExistsWaiter(p.iamClient).Wait(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	}, p.iamRoleWaitTimeout())
 	if err != nil {
-		return "", fmt.Errorf("failed to attach main policy: %w", err)
+		return "", fmt.Errorf("failed waiting for role to exist: %w", err)
 	}
 
-	// Also attach AWS Lambda basic execution role
-	_, err = p.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
-		RoleName:  aws.String(roleName),
-		PolicyArn: aws.String("arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"),
+	return *roleResult.Role.Arn, nil
+}
+
+// SharedPolicyARN is the customer managed policy, created outside the
+// service, that every client role is attached to.
+func SharedPolicyARN(accountID string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:policy/go-infra-policy", accountID)
+}
+
+// rolePolicyARNs are the managed policies every client role has: the shared
+// policy and the AWS Lambda basic execution role.
+func (p *ResourceProvisioner) rolePolicyARNs() []string {
+	return []string{
+		SharedPolicyARN(p.accountID),
+		"arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+	}
+}
+
+// missingRolePolicies returns whichever of rolePolicyARNs are not attached to the role.
+func (p *ResourceProvisioner) missingRolePolicies(ctx context.Context, roleName string) ([]string, error) {
+	attached := map[string]bool{}
+	pages := iam.NewListAttachedRolePoliciesPaginator(p.iamClient, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
 	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list attached policies: %w", err)
+		}
+		for _, policy := range page.AttachedPolicies {
+			attached[aws.ToString(policy.PolicyArn)] = true
+		}
+	}
+
+	var missing []string
+	for _, policyARN := range p.rolePolicyARNs() {
+		if !attached[policyARN] {
+			missing = append(missing, policyARN)
+		}
+	}
+	return missing, nil
+}
+
+// attachRolePolicies attaches whichever of rolePolicyARNs the role is missing.
+func (p *ResourceProvisioner) attachRolePolicies(ctx context.Context, roleName string) (bool, error) {
+	missing, err := p.missingRolePolicies(ctx, roleName)
 	if err != nil {
-		return "", fmt.Errorf("failed to attach Lambda execution policy: %w", err)
+		return false, err
 	}
 
-	// Add client-specific inline policy
-	inlinePolicy := fmt.Sprintf(`{
+	for _, policyARN := range missing {
+		_, err := p.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+			RoleName:  aws.String(roleName),
+			PolicyArn: aws.String(policyARN),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to attach policy %s: %w", policyARN, err)
+		}
+	}
+	return len(missing) > 0, nil
+}
+
+// roleInlinePolicy grants the client role access to its own bucket and log group.
+func (p *ResourceProvisioner) roleInlinePolicy(bucketName, logGroupName string) string {
+	return fmt.Sprintf(`{
         "Version": "2012-10-17",
         "Statement": [
             {
@@ -93,60 +200,194 @@ func (p *ResourceProvisioner) createIAMRole(ctx context.Context, roleName, bucke
                 ]
             }
         ]
-    }`, bucketName, bucketName, p.config.AWSRegion, p.config.AWSAccountID, logGroupName)
+    }`, bucketName, bucketName, p.region, p.accountID, logGroupName)
+}
+
+// inlinePolicyMatches reports whether the role has the named inline policy
+// with the given document.
+func (p *ResourceProvisioner) inlinePolicyMatches(ctx context.Context, roleName, policyName, document string) (bool, error) {
+	current, err := p.iamClient.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+		RoleName:   aws.String(roleName),
+		PolicyName: aws.String(policyName),
+	})
+	switch {
+	case isNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to get inline policy: %w", err)
+	}
+	return documentsEqual(aws.ToString(current.PolicyDocument), document), nil
+}
+
+// putRoleInlinePolicy writes the client-specific inline policy unless the role
+// already has an identical one.
+func (p *ResourceProvisioner) putRoleInlinePolicy(ctx context.Context, roleName, bucketName, logGroupName string) (bool, error) {
+	policyName := fmt.Sprintf("%s-policy", roleName)
+	document := p.roleInlinePolicy(bucketName, logGroupName)
+
+	matches, err := p.inlinePolicyMatches(ctx, roleName, policyName, document)
+	if err != nil || matches {
+		return false, err
+	}
 
 	_, err = p.iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
 		RoleName:       aws.String(roleName),
-		PolicyName:     aws.String(fmt.Sprintf("%s-policy", roleName)),
-		PolicyDocument: aws.String(inlinePolicy),
+		PolicyName:     aws.String(policyName),
+		PolicyDocument: aws.String(document),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to attach inline policy: %w", err)
+		return false, fmt.Errorf("failed to attach inline policy: %w", err)
 	}
-
-	return *roleResult.Role.Arn, nil
+	return true, nil
 }
 
-// Update cleanup to detach both policies
+// cleanupIAMRole detaches every managed policy and deletes every inline policy
+// on the role before deleting it, since IAM refuses to delete a role that
+// still has policies.
 func (p *ResourceProvisioner) cleanupIAMRole(ctx context.Context, roleName string) error {
-	p.logger.Info(fmt.Sprintf("Cleaning up IAM role: %s", roleName))
+	p.log(ctx).Info("Cleaning up IAM role")
+
+	// Detach managed policies
+	attached := iam.NewListAttachedRolePoliciesPaginator(p.iamClient, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	})
+	for attached.HasMorePages() {
+		page, err := attached.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list attached policies: %w", err)
+		}
+		for _, policy := range page.AttachedPolicies {
+			_, err := p.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+				RoleName:  aws.String(roleName),
+				PolicyArn: policy.PolicyArn,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to detach policy %s: %w", aws.ToString(policy.PolicyArn), err)
+			}
+		}
+	}
 
-	// Detach the main policy
-	policyArn := fmt.Sprintf("arn:aws:iam::%s:policy/go-infra-policy", p.config.AWSAccountID)
-	_, err := p.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
-		RoleName:  aws.String(roleName),
-		PolicyArn: aws.String(policyArn),
+	// Delete inline policies
+	inline := iam.NewListRolePoliciesPaginator(p.iamClient, &iam.ListRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	})
+	for inline.HasMorePages() {
+		page, err := inline.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list inline policies: %w", err)
+		}
+		for _, policyName := range page.PolicyNames {
+			_, err := p.iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+				RoleName:   aws.String(roleName),
+				PolicyName: aws.String(policyName),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to delete role policy %s: %w", policyName, err)
+			}
+		}
+	}
+
+	// Delete the role
+	_, err := p.iamClient.DeleteRole(ctx, &iam.DeleteRoleInput{
+		RoleName: aws.String(roleName),
 	})
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to detach main policy: %v", err))
+		return fmt.Errorf("failed to delete role: %w", err)
 	}
 
-	// Detach Lambda execution policy
-	_, err = p.iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
-		RoleName:  aws.String(roleName),
-		PolicyArn: aws.String("arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"),
+	p.log(ctx).Info("Successfully cleaned up IAM role")
+	return nil
+}
+
+func (p *ResourceProvisioner) describeIAMRole(ctx context.Context, roleName string) (*models.ResourceStatus, error) {
+	role, err := p.iamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
 	})
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to detach Lambda execution policy: %v", err))
+		if isNotFound(err) {
+			return &models.ResourceStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to describe role: %w", err)
 	}
 
-	// Delete the inline policy
-	_, err = p.iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
-		RoleName:   aws.String(roleName),
-		PolicyName: aws.String(fmt.Sprintf("%s-policy", roleName)),
+	attached, err := p.iamClient.ListAttachedRolePolicies(ctx, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to delete role policy: %w", err)
+		return nil, fmt.Errorf("failed to list attached policies: %w", err)
+	}
+	var policies []string
+	for _, policy := range attached.AttachedPolicies {
+		policies = append(policies, aws.ToString(policy.PolicyName))
 	}
 
-	// Delete the role
-	_, err = p.iamClient.DeleteRole(ctx, &iam.DeleteRoleInput{
+	inline, err := p.iamClient.ListRolePolicies(ctx, &iam.ListRolePoliciesInput{
 		RoleName: aws.String(roleName),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to delete role: %w", err)
+		return nil, fmt.Errorf("failed to list inline policies: %w", err)
 	}
 
-	p.logger.Info(fmt.Sprintf("Successfully cleaned up IAM role: %s", roleName))
-	return nil
+	return &models.ResourceStatus{
+		Exists: true,
+		ARN:    aws.ToString(role.Role.Arn),
+		Details: map[string]string{
+			"created":           aws.ToTime(role.Role.CreateDate).Format(time.RFC3339),
+			"attached_policies": strings.Join(policies, ","),
+			"inline_policies":   strings.Join(inline.PolicyNames, ","),
+		},
+	}, nil
+}
+
+// planIAMRole reports what ensureIAMRole would do, without changing anything.
+func (p *ResourceProvisioner) planIAMRole(ctx context.Context, roleName, bucketName, logGroupName string) (*models.PlannedResource, error) {
+	policyName := fmt.Sprintf("%s-policy", roleName)
+	document := p.roleInlinePolicy(bucketName, logGroupName)
+	planned := &models.PlannedResource{
+		ARN:    fmt.Sprintf("arn:aws:iam::%s:role/%s", p.accountID, roleName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"assume_role_policy": json.RawMessage(lambdaTrustPolicy),
+			"managed_policies":   p.rolePolicyARNs(),
+			"inline_policy_name": policyName,
+			"inline_policy":      json.RawMessage(document),
+		},
+	}
+
+	role, err := p.iamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	})
+	switch {
+	case isNotFound(err):
+		planned.Action = models.PlanActionCreate
+		return planned, nil
+	case err != nil:
+		return planned, fmt.Errorf("failed to check role: %w", err)
+	}
+	planned.ARN = aws.ToString(role.Role.Arn)
+
+	if !documentsEqual(aws.ToString(role.Role.AssumeRolePolicyDocument), lambdaTrustPolicy) {
+		planned.Changes = append(planned.Changes, "trust policy")
+	}
+
+	missing, err := p.missingRolePolicies(ctx, roleName)
+	if err != nil {
+		return planned, err
+	}
+	for _, policyARN := range missing {
+		planned.Changes = append(planned.Changes, fmt.Sprintf("attach %s", policyARN))
+	}
+
+	matches, err := p.inlinePolicyMatches(ctx, roleName, policyName, document)
+	if err != nil {
+		return planned, err
+	}
+	if !matches {
+		planned.Changes = append(planned.Changes, fmt.Sprintf("inline policy %s", policyName))
+	}
+
+	if len(planned.Changes) > 0 {
+		planned.Action = models.PlanActionUpdate
+	}
+	return planned, nil
 }
@@ -4,14 +4,23 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"strconv"
+	"time"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 )
 
+// lambdaHandler is the entry point of the log processor function. Its
+// runtime, timeout and memory size come from the client's options.
+const lambdaHandler = "index.handler"
+
 // Helper function to create ZIP file bytes
 func createZipBytes(functionCode string) []byte {
 	// Create a buffer to write our zip to
@@ -41,11 +50,9 @@ func createZipBytes(functionCode string) []byte {
 	return buf.Bytes()
 }
 
-func (p *ResourceProvisioner) createLambdaFunction(ctx context.Context, functionName, roleARN, targetBucket string) (string, error) {
-	p.logger.Info(fmt.Sprintf("Creating Lambda function: %s", functionName))
-
-	// Lambda function code (basic log processor)
-	functionCode := fmt.Sprintf(`
+// lambdaFunctionCode is the source of the log processor function.
+func lambdaFunctionCode(targetBucket string) string {
+	return fmt.Sprintf(`
 exports.handler = async (event) => {
     const AWS = require('aws-sdk');
     const s3 = new AWS.S3();
@@ -71,29 +78,142 @@ exports.handler = async (event) => {
         throw error;
     }
 };`, targetBucket)
+}
+
+// ensureLambdaFunction creates the function if it is missing, otherwise it
+// updates the configuration and code of the existing function where they
+// differ from what we deploy.
+func (p *ResourceProvisioner) ensureLambdaFunction(ctx context.Context, functionName, roleARN, targetBucket string, opts models.ResourceOptions) (string, string, error) {
+	existing, err := p.lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	if isNotFound(err) {
+		functionARN, err := p.createLambdaFunction(ctx, functionName, roleARN, targetBucket, opts)
+		return functionARN, outcomeCreated, err
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check lambda function: %w", err)
+	}
+
+	cfg := existing.Configuration
+	functionARN := aws.ToString(cfg.FunctionArn)
+	outcome := outcomeUnchanged
+
+	if len(lambdaConfigChanges(cfg, roleARN, targetBucket, opts)) > 0 {
+		p.log(ctx).Info("Updating Lambda function configuration")
+		_, err = p.lambdaClient.UpdateFunctionConfiguration(ctx, &lambda.UpdateFunctionConfigurationInput{
+			FunctionName: aws.String(functionName),
+			Role:         aws.String(roleARN),
+			Handler:      aws.String(lambdaHandler),
+			Runtime:      types.Runtime(opts.LambdaRuntime),
+			Environment: &types.Environment{
+				Variables: map[string]string{
+					"TARGET_BUCKET": targetBucket,
+				},
+			},
+			Timeout:    aws.Int32(int32(opts.LambdaTimeoutSeconds)),
+			MemorySize: aws.Int32(int32(opts.LambdaMemoryMB)),
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to update lambda configuration: %w", err)
+		}
+		outcome = outcomeUpdated
+
+		// Lambda rejects further changes until the configuration update finishes
+		err = lambda.NewFunctionUpdatedV2Waiter(p.lambdaClient).Wait(ctx, &lambda.GetFunctionInput{
+			FunctionName: aws.String(functionName),
+		}, 2*time.Minute)
+		if err != nil {
+			return "", "", fmt.Errorf("failed waiting for lambda configuration update: %w", err)
+		}
+	}
+
+	zipBytes := createZipBytes(lambdaFunctionCode(targetBucket))
+	if zipBytes == nil {
+		return "", "", fmt.Errorf("failed to create zip file for lambda function")
+	}
+	if aws.ToString(cfg.CodeSha256) != codeSha256(zipBytes) {
+		p.log(ctx).Info("Updating Lambda function code")
+		_, err = p.lambdaClient.UpdateFunctionCode(ctx, &lambda.UpdateFunctionCodeInput{
+			FunctionName: aws.String(functionName),
+			ZipFile:      zipBytes,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to update lambda code: %w", err)
+		}
+		outcome = outcomeUpdated
+	}
+
+	return functionARN, outcome, nil
+}
+
+// lambdaConfigChanges describes how an existing function's configuration
+// differs from what we deploy.
+func lambdaConfigChanges(cfg *types.FunctionConfiguration, roleARN, targetBucket string, opts models.ResourceOptions) []string {
+	var changes []string
+	if aws.ToString(cfg.Role) != roleARN {
+		changes = append(changes, fmt.Sprintf("role: %s -> %s", aws.ToString(cfg.Role), roleARN))
+	}
+	if aws.ToString(cfg.Handler) != lambdaHandler {
+		changes = append(changes, fmt.Sprintf("handler: %s -> %s", aws.ToString(cfg.Handler), lambdaHandler))
+	}
+	if string(cfg.Runtime) != opts.LambdaRuntime {
+		changes = append(changes, fmt.Sprintf("runtime: %s -> %s", cfg.Runtime, opts.LambdaRuntime))
+	}
+	if int(aws.ToInt32(cfg.Timeout)) != opts.LambdaTimeoutSeconds {
+		changes = append(changes, fmt.Sprintf("timeout: %d -> %d", aws.ToInt32(cfg.Timeout), opts.LambdaTimeoutSeconds))
+	}
+	if int(aws.ToInt32(cfg.MemorySize)) != opts.LambdaMemoryMB {
+		changes = append(changes, fmt.Sprintf("memory_size: %d -> %d", aws.ToInt32(cfg.MemorySize), opts.LambdaMemoryMB))
+	}
+
+	var env map[string]string
+	if cfg.Environment != nil {
+		env = cfg.Environment.Variables
+	}
+	if len(env) != 1 || env["TARGET_BUCKET"] != targetBucket {
+		changes = append(changes, "environment")
+	}
+	return changes
+}
+
+// codeSha256 is how Lambda reports the hash of a deployment package.
+func codeSha256(zipBytes []byte) string {
+	sum := sha256.Sum256(zipBytes)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func (p *ResourceProvisioner) createLambdaFunction(ctx context.Context, functionName, roleARN, targetBucket string, opts models.ResourceOptions) (string, error) {
+	p.log(ctx).Info("Creating Lambda function")
 
 	// Create ZIP file containing the function code
-	zipBytes := createZipBytes(functionCode)
+	zipBytes := createZipBytes(lambdaFunctionCode(targetBucket))
 	if zipBytes == nil {
 		return "", fmt.Errorf("failed to create zip file for lambda function")
 	}
 
-	// Create Lambda function
-	createResult, err := p.lambdaClient.CreateFunction(ctx, &lambda.CreateFunctionInput{
+	// Create Lambda function. A role created moments ago may not be
+	// assumable by Lambda yet, so that specific rejection is retried.
+	input := &lambda.CreateFunctionInput{
 		FunctionName: aws.String(functionName),
 		Role:         aws.String(roleARN),
-		Handler:      aws.String("index.handler"),
+		Handler:      aws.String(lambdaHandler),
 		Code: &types.FunctionCode{
 			ZipFile: zipBytes,
 		},
-		Runtime: types.RuntimeNodejs18x,
+		Runtime: types.Runtime(opts.LambdaRuntime),
 		Environment: &types.Environment{
 			Variables: map[string]string{
 				"TARGET_BUCKET": targetBucket,
 			},
 		},
-		Timeout:    aws.Int32(30),
-		MemorySize: aws.Int32(128),
+		Timeout:    aws.Int32(int32(opts.LambdaTimeoutSeconds)),
+		MemorySize: aws.Int32(int32(opts.LambdaMemoryMB)),
+	}
+	var createResult *lambda.CreateFunctionOutput
+	err := p.retry(ctx, "create lambda function", p.lambdaCreatePolicy(), isRoleNotAssumable, func() (err error) {
+		createResult, err = p.lambdaClient.CreateFunction(ctx, input)
+		return err
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to create lambda function: %w", err)
@@ -102,7 +222,7 @@ exports.handler = async (event) => {
 	return *createResult.FunctionArn, nil
 }
 func (p *ResourceProvisioner) deleteLambdaFunction(ctx context.Context, functionName string) error {
-	p.logger.Info(fmt.Sprintf("Deleting Lambda function: %s", functionName))
+	p.log(ctx).Info("Deleting Lambda function")
 
 	_, err := p.lambdaClient.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
 		FunctionName: aws.String(functionName),
@@ -113,3 +233,73 @@ func (p *ResourceProvisioner) deleteLambdaFunction(ctx context.Context, function
 
 	return nil
 }
+
+func (p *ResourceProvisioner) describeLambdaFunction(ctx context.Context, functionName string) (*models.ResourceStatus, error) {
+	result, err := p.lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return &models.ResourceStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to describe lambda function: %w", err)
+	}
+
+	cfg := result.Configuration
+	return &models.ResourceStatus{
+		Exists: true,
+		ARN:    aws.ToString(cfg.FunctionArn),
+		Details: map[string]string{
+			"runtime":     string(cfg.Runtime),
+			"handler":     aws.ToString(cfg.Handler),
+			"memory_size": strconv.Itoa(int(aws.ToInt32(cfg.MemorySize))),
+			"timeout":     strconv.Itoa(int(aws.ToInt32(cfg.Timeout))),
+			"role":        aws.ToString(cfg.Role),
+			"state":       string(cfg.State),
+		},
+	}, nil
+}
+
+// planLambdaFunction reports what ensureLambdaFunction would do, without
+// changing anything.
+func (p *ResourceProvisioner) planLambdaFunction(ctx context.Context, functionName, roleARN, targetBucket string, opts models.ResourceOptions) (*models.PlannedResource, error) {
+	zipBytes := createZipBytes(lambdaFunctionCode(targetBucket))
+	if zipBytes == nil {
+		return nil, fmt.Errorf("failed to create zip file for lambda function")
+	}
+	planned := &models.PlannedResource{
+		ARN:    fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", p.region, p.accountID, functionName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"runtime":     opts.LambdaRuntime,
+			"handler":     lambdaHandler,
+			"memory_size": opts.LambdaMemoryMB,
+			"timeout":     opts.LambdaTimeoutSeconds,
+			"role":        roleARN,
+			"environment": map[string]string{"TARGET_BUCKET": targetBucket},
+			"code_sha256": codeSha256(zipBytes),
+		},
+	}
+
+	existing, err := p.lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	switch {
+	case isNotFound(err):
+		planned.Action = models.PlanActionCreate
+		return planned, nil
+	case err != nil:
+		return planned, fmt.Errorf("failed to check lambda function: %w", err)
+	}
+
+	cfg := existing.Configuration
+	planned.ARN = aws.ToString(cfg.FunctionArn)
+	planned.Changes = lambdaConfigChanges(cfg, roleARN, targetBucket, opts)
+	if aws.ToString(cfg.CodeSha256) != codeSha256(zipBytes) {
+		planned.Changes = append(planned.Changes, "code")
+	}
+	if len(planned.Changes) > 0 {
+		planned.Action = models.PlanActionUpdate
+	}
+	return planned, nil
+}
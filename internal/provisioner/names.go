@@ -0,0 +1,126 @@
+package provisioner
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// clientIDPattern matches client IDs that are valid in every resource name:
+// groups of lowercase letters and digits joined by single hyphens.
+var clientIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// resourceNames holds the names of every resource provisioned for a client.
+// Both provisioning and teardown derive names from here so they always agree.
+type resourceNames struct {
+	bucket         string
+	role           string
+	logGroup       string
+	rule           string
+	lambda         string
+	topic          string
+	errorRateAlarm string
+	logVolumeAlarm string
+
+	lambdaErrorsAlarm    string
+	lambdaThrottlesAlarm string
+	dashboard            string
+}
+
+// namesFor returns the names of the client's resources in p's region.
+func (p *ResourceProvisioner) namesFor(clientID string) resourceNames {
+	return p.namesIn(clientID, p.region)
+}
+
+// namesIn returns the names of the client's resources in region. Bucket, role
+// and dashboard names are global, so outside the service's own region they
+// carry the region too.
+func (p *ResourceProvisioner) namesIn(clientID, region string) resourceNames {
+	env := p.config.Environment
+	global := clientID
+	if region != p.config.AWSRegion {
+		global = clientID + "-" + region
+	}
+
+	return resourceNames{
+		bucket:         fmt.Sprintf("%s-%s-bucket", env, global),
+		role:           fmt.Sprintf("%s-%s-role", env, global),
+		logGroup:       fmt.Sprintf("/aws/client/%s/%s", env, clientID),
+		rule:           fmt.Sprintf("%s-%s-rule", env, clientID),
+		lambda:         fmt.Sprintf("%s-%s-processor", env, clientID),
+		topic:          fmt.Sprintf("%s-%s-alerts", env, clientID),
+		errorRateAlarm: fmt.Sprintf("%s-error-rate-alarm", clientID),
+		logVolumeAlarm: fmt.Sprintf("%s-log-volume-alarm", clientID),
+
+		lambdaErrorsAlarm:    fmt.Sprintf("%s-lambda-errors-alarm", clientID),
+		lambdaThrottlesAlarm: fmt.Sprintf("%s-lambda-throttles-alarm", clientID),
+		dashboard:            fmt.Sprintf("%s-%s-dashboard", env, global),
+	}
+}
+
+// limitedName is a resource name along with the longest name AWS accepts for
+// its kind of resource.
+type limitedName struct {
+	kind  string
+	name  string
+	limit int
+}
+
+// limited returns the names whose length AWS limits.
+func (n resourceNames) limited() []limitedName {
+	return []limitedName{
+		{"bucket", n.bucket, 63},
+		{"role", n.role, 64},
+		{"log group", n.logGroup, 512},
+		{"rule", n.rule, 64},
+		{"function", n.lambda, 64},
+		{"topic", n.topic, 256},
+		{"alarm", n.errorRateAlarm, 255},
+		{"alarm", n.logVolumeAlarm, 255},
+		{"alarm", n.lambdaErrorsAlarm, 255},
+		{"alarm", n.lambdaThrottlesAlarm, 255},
+		{"dashboard", n.dashboard, 255},
+	}
+}
+
+// maxClientIDLength returns the length of the longest client ID whose
+// resource names in region are all within AWS limits.
+func (p *ResourceProvisioner) maxClientIDLength(region string) int {
+	longest := -1
+	for _, n := range p.namesIn("", region).limited() {
+		if longest < 0 || n.limit-len(n.name) < longest {
+			longest = n.limit - len(n.name)
+		}
+	}
+	return longest
+}
+
+// ValidateClientID reports an INVALID_REQUEST error if clientID cannot be
+// used in resource names: if it is not made of lowercase letters, digits and
+// single hyphens, or is too long for the longest name derived from it in any
+// of regions, by default the service's own.
+func (p *ResourceProvisioner) ValidateClientID(clientID string, regions []string) error {
+	if clientID == "" {
+		return models.NewProvisionError(models.ErrCodeInvalidRequest, "client_id is required", nil)
+	}
+	if !clientIDPattern.MatchString(clientID) {
+		return models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf(
+			"client_id %q must be lowercase letters and digits, separated by single hyphens", clientID), nil)
+	}
+	if len(regions) == 0 {
+		regions = []string{p.config.AWSRegion}
+	}
+	for _, region := range regions {
+		if max := p.maxClientIDLength(region); len(clientID) > max {
+			return models.NewProvisionError(models.ErrCodeInvalidRequest, fmt.Sprintf(
+				"client_id %q is longer than %d characters, the most resource names in %s allow", clientID, max, region), nil)
+		}
+	}
+	return nil
+}
+
+// topicARN builds the ARN SNS assigns to a topic in the provisioner's account and region.
+func (p *ResourceProvisioner) topicARN(topicName string) string {
+	return fmt.Sprintf("arn:aws:sns:%s:%s:%s", p.region, p.accountID, topicName)
+}
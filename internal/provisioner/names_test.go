@@ -0,0 +1,113 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+func TestValidateClientID(t *testing.T) {
+	p := newTestProvisioner(t)
+	longest := strings.Repeat("a", 50)
+	longestAbroad := strings.Repeat("a", 37)
+
+	tests := []struct {
+		name     string
+		clientID string
+		regions  []string
+		wantErr  bool
+	}{
+		{"simple", "acme", nil, false},
+		{"hyphenated", "acme-corp-01", nil, false},
+		{"digits", "42", nil, false},
+		{"longest", longest, nil, false},
+		{"longest in own region", longest, []string{"us-east-1"}, false},
+		{"longest in every region", longestAbroad, []string{"us-east-1", "ap-southeast-2"}, false},
+		{"empty", "", nil, true},
+		{"trailing hyphen", "c-", nil, true},
+		{"leading hyphen", "-c", nil, true},
+		{"double hyphen", "c--1", nil, true},
+		{"uppercase", "Acme", nil, true},
+		{"underscore", "acme_corp", nil, true},
+		{"dot", "acme.corp", nil, true},
+		{"too long", longest + "a", nil, true},
+		{"too long with region suffix", longest, []string{"us-east-1", "ap-southeast-2"}, true},
+		{"too long in one region", longestAbroad + "a", []string{"ap-southeast-2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.ValidateClientID(tt.clientID, tt.regions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateClientID(%q, %v) error = %v, wantErr %v", tt.clientID, tt.regions, err, tt.wantErr)
+			}
+			var perr *models.ProvisionError
+			if err != nil && (!errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest) {
+				t.Errorf("ValidateClientID(%q, %v) error = %v, want %s", tt.clientID, tt.regions, err, models.ErrCodeInvalidRequest)
+			}
+		})
+	}
+}
+
+func TestMaxClientIDLength(t *testing.T) {
+	p := newTestProvisioner(t)
+	tests := []struct {
+		region string
+		want   int
+	}{
+		// Limited by the function name, which has no region
+		{"us-east-1", 50},
+		// Limited by the bucket name, which carries the region
+		{"eu-west-1", 42},
+		{"ap-southeast-2", 37},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			got := p.maxClientIDLength(tt.region)
+			if got != tt.want {
+				t.Errorf("maxClientIDLength(%s) = %d, want %d", tt.region, got, tt.want)
+			}
+			for _, n := range p.namesIn(strings.Repeat("a", got), tt.region).limited() {
+				if len(n.name) > n.limit {
+					t.Errorf("%s name %s has %d characters, more than %d", n.kind, n.name, len(n.name), n.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestProvisionRejectsInvalidClientID(t *testing.T) {
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+
+	_, err := p.ProvisionClientResources(context.Background(), &models.ProvisionRequest{ClientID: "c-", ClientName: "C"})
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest {
+		t.Fatalf("ProvisionClientResources() error = %v, want %s", err, models.ErrCodeInvalidRequest)
+	}
+	if calls := cloud.Calls(); len(calls) != 0 {
+		t.Errorf("AWS was called for an invalid client ID: %v", calls)
+	}
+}
+
+func TestProvisionRejectsClientIDTooLongForARegion(t *testing.T) {
+	home := newTestCloud(testAccount, "us-east-1")
+	abroad := newTestCloud(testAccount, "ap-southeast-2")
+	p := newTestProvisioner(t, home, abroad)
+
+	req := &models.ProvisionRequest{
+		ClientID:   strings.Repeat("a", 40),
+		ClientName: "A",
+		Regions:    []string{"us-east-1", "ap-southeast-2"},
+	}
+	_, err := p.ProvisionClientRegions(context.Background(), req)
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest {
+		t.Fatalf("ProvisionClientRegions() error = %v, want %s", err, models.ErrCodeInvalidRequest)
+	}
+	if calls := append(home.Calls(), abroad.Calls()...); len(calls) != 0 {
+		t.Errorf("AWS was called before the names were checked: %v", calls)
+	}
+}
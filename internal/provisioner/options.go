@@ -0,0 +1,102 @@
+package provisioner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// DefaultOptions returns the settings used for anything neither a request nor
+// its tier's blueprint sets.
+func DefaultOptions() models.ResourceOptions {
+	return models.ResourceOptions{
+		LambdaMemoryMB:          128,
+		LambdaTimeoutSeconds:    30,
+		LambdaRuntime:           string(lambdatypes.RuntimeNodejs18x),
+		S3InfrequentAccessDays:  30,
+		S3GlacierDays:           90,
+		ErrorRateAlarmThreshold: 10,
+		LogVolumeAlarmThreshold: 1000,
+	}
+}
+
+// lambdaRuntimes are the runtimes the log processor's code runs on.
+var lambdaRuntimes = []string{
+	string(lambdatypes.RuntimeNodejs18x),
+	string(lambdatypes.RuntimeNodejs20x),
+	string(lambdatypes.RuntimeNodejs22x),
+}
+
+// logRetentionDays are the retention periods CloudWatch Logs accepts.
+var logRetentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// resolveOptions returns opts with every setting left out taken from base.
+func resolveOptions(base models.ResourceOptions, opts *models.ResourceOptions) models.ResourceOptions {
+	resolved := base
+	if opts == nil {
+		return resolved
+	}
+	if opts.LambdaMemoryMB != 0 {
+		resolved.LambdaMemoryMB = opts.LambdaMemoryMB
+	}
+	if opts.LambdaTimeoutSeconds != 0 {
+		resolved.LambdaTimeoutSeconds = opts.LambdaTimeoutSeconds
+	}
+	if opts.LambdaRuntime != "" {
+		resolved.LambdaRuntime = opts.LambdaRuntime
+	}
+	if opts.LogRetentionDays != 0 {
+		resolved.LogRetentionDays = opts.LogRetentionDays
+	}
+	if opts.S3InfrequentAccessDays != 0 {
+		resolved.S3InfrequentAccessDays = opts.S3InfrequentAccessDays
+	}
+	if opts.S3GlacierDays != 0 {
+		resolved.S3GlacierDays = opts.S3GlacierDays
+	}
+	if opts.ErrorRateAlarmThreshold != 0 {
+		resolved.ErrorRateAlarmThreshold = opts.ErrorRateAlarmThreshold
+	}
+	if opts.LogVolumeAlarmThreshold != 0 {
+		resolved.LogVolumeAlarmThreshold = opts.LogVolumeAlarmThreshold
+	}
+	return resolved
+}
+
+// validateOptions reports an INVALID_REQUEST error listing every setting of
+// the resolved options o that is out of bounds.
+func validateOptions(o models.ResourceOptions) error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(o.LambdaMemoryMB >= 128 && o.LambdaMemoryMB <= 10240,
+		"lambda_memory_mb must be between 128 and 10240, got %d", o.LambdaMemoryMB)
+	check(o.LambdaTimeoutSeconds >= 1 && o.LambdaTimeoutSeconds <= 900,
+		"lambda_timeout_seconds must be between 1 and 900, got %d", o.LambdaTimeoutSeconds)
+	check(slices.Contains(lambdaRuntimes, o.LambdaRuntime),
+		"lambda_runtime must be one of %s, got %q", strings.Join(lambdaRuntimes, ", "), o.LambdaRuntime)
+	check(o.LogRetentionDays == 0 || slices.Contains(logRetentionDays, o.LogRetentionDays),
+		"log_retention_days must be one of %v, got %d", logRetentionDays, o.LogRetentionDays)
+	// S3 only moves objects to Standard-IA after 30 days, and keeps them there
+	// for 30 days before Glacier
+	check(o.S3InfrequentAccessDays >= 30 && o.S3InfrequentAccessDays <= 3650,
+		"s3_infrequent_access_days must be between 30 and 3650, got %d", o.S3InfrequentAccessDays)
+	check(o.S3GlacierDays >= o.S3InfrequentAccessDays+30 && o.S3GlacierDays <= 3650,
+		"s3_glacier_days must be between s3_infrequent_access_days + 30 (%d) and 3650, got %d", o.S3InfrequentAccessDays+30, o.S3GlacierDays)
+	check(o.ErrorRateAlarmThreshold >= 1 && o.ErrorRateAlarmThreshold <= 100000,
+		"error_rate_alarm_threshold must be between 1 and 100000, got %v", o.ErrorRateAlarmThreshold)
+	check(o.LogVolumeAlarmThreshold >= 1 && o.LogVolumeAlarmThreshold <= 10000000,
+		"log_volume_alarm_threshold must be between 1 and 10000000, got %v", o.LogVolumeAlarmThreshold)
+
+	if len(problems) > 0 {
+		return models.NewProvisionError(models.ErrCodeInvalidRequest, "invalid options: "+strings.Join(problems, "; "), nil)
+	}
+	return nil
+}
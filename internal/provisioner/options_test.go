@@ -0,0 +1,164 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+func TestResolveOptions(t *testing.T) {
+	base := DefaultOptions()
+	tests := []struct {
+		name string
+		opts *models.ResourceOptions
+		want func(o *models.ResourceOptions)
+	}{
+		{name: "no options", opts: nil, want: func(o *models.ResourceOptions) {}},
+		{name: "empty options", opts: &models.ResourceOptions{}, want: func(o *models.ResourceOptions) {}},
+		{
+			name: "some settings",
+			opts: &models.ResourceOptions{LambdaMemoryMB: 512, LogRetentionDays: 14, LogVolumeAlarmThreshold: 5000},
+			want: func(o *models.ResourceOptions) {
+				o.LambdaMemoryMB, o.LogRetentionDays, o.LogVolumeAlarmThreshold = 512, 14, 5000
+			},
+		},
+		{
+			name: "every setting",
+			opts: &models.ResourceOptions{
+				LambdaMemoryMB: 256, LambdaTimeoutSeconds: 60, LambdaRuntime: "nodejs20.x", LogRetentionDays: 7,
+				S3InfrequentAccessDays: 60, S3GlacierDays: 180, ErrorRateAlarmThreshold: 5, LogVolumeAlarmThreshold: 200,
+			},
+			want: func(o *models.ResourceOptions) {
+				*o = models.ResourceOptions{
+					LambdaMemoryMB: 256, LambdaTimeoutSeconds: 60, LambdaRuntime: "nodejs20.x", LogRetentionDays: 7,
+					S3InfrequentAccessDays: 60, S3GlacierDays: 180, ErrorRateAlarmThreshold: 5, LogVolumeAlarmThreshold: 200,
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := base
+			tt.want(&want)
+			if got := resolveOptions(base, tt.opts); got != want {
+				t.Errorf("resolveOptions() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *models.ResourceOptions)
+		wantErr string
+	}{
+		{"defaults", func(o *models.ResourceOptions) {}, ""},
+		{"largest", func(o *models.ResourceOptions) {
+			o.LambdaMemoryMB, o.LambdaTimeoutSeconds, o.LogRetentionDays = 10240, 900, 3653
+			o.S3InfrequentAccessDays, o.S3GlacierDays = 3620, 3650
+		}, ""},
+		{"memory too small", func(o *models.ResourceOptions) { o.LambdaMemoryMB = 64 }, "lambda_memory_mb"},
+		{"timeout too long", func(o *models.ResourceOptions) { o.LambdaTimeoutSeconds = 901 }, "lambda_timeout_seconds"},
+		{"unsupported runtime", func(o *models.ResourceOptions) { o.LambdaRuntime = "python3.12" }, "lambda_runtime"},
+		{"retention CloudWatch does not offer", func(o *models.ResourceOptions) { o.LogRetentionDays = 10 }, "log_retention_days"},
+		{"infrequent access too soon", func(o *models.ResourceOptions) { o.S3InfrequentAccessDays = 7 }, "s3_infrequent_access_days"},
+		{"glacier too soon after infrequent access", func(o *models.ResourceOptions) { o.S3GlacierDays = 45 }, "s3_glacier_days must be between s3_infrequent_access_days + 30 (60)"},
+		{"error threshold too low", func(o *models.ResourceOptions) { o.ErrorRateAlarmThreshold = 0.5 }, "error_rate_alarm_threshold"},
+		{"log volume threshold too high", func(o *models.ResourceOptions) { o.LogVolumeAlarmThreshold = 1e8 }, "log_volume_alarm_threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			err := validateOptions(opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateOptions() error = %v", err)
+				}
+				return
+			}
+			var perr *models.ProvisionError
+			if !errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateOptions() error = %v, want %s mentioning %q", err, models.ErrCodeInvalidRequest, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOptionsReportsEveryProblem(t *testing.T) {
+	opts := DefaultOptions()
+	opts.LambdaMemoryMB, opts.LambdaRuntime = 1, "go1.x"
+	err := validateOptions(opts)
+	for _, want := range []string{"lambda_memory_mb", "lambda_runtime"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validateOptions() error = %v, want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestProvisionWithOptions(t *testing.T) {
+	ctx := context.Background()
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+	names := p.namesIn("acme", cloud.Region)
+
+	req := testRequest("acme")
+	req.Options = &models.ResourceOptions{LambdaMemoryMB: 512, LogRetentionDays: 14, S3GlacierDays: 120, ErrorRateAlarmThreshold: 3}
+	resp, err := p.ProvisionClientResources(ctx, req)
+	if err != nil {
+		t.Fatalf("ProvisionClientResources() error = %v", err)
+	}
+	want := resolveOptions(DefaultOptions(), req.Options)
+	if resp.Options == nil || *resp.Options != want {
+		t.Errorf("response options = %+v, want the effective %+v", resp.Options, want)
+	}
+
+	check := func(want models.ResourceOptions) {
+		t.Helper()
+		fn, _ := cloud.Lambda.Function(names.lambda)
+		if int(fn.MemorySize) != want.LambdaMemoryMB || int(fn.Timeout) != want.LambdaTimeoutSeconds || string(fn.Runtime) != want.LambdaRuntime {
+			t.Errorf("function has %d MB, %d s, %s, want %d MB, %d s, %s",
+				fn.MemorySize, fn.Timeout, fn.Runtime, want.LambdaMemoryMB, want.LambdaTimeoutSeconds, want.LambdaRuntime)
+		}
+		if group, _ := cloud.CloudWatchLogs.LogGroup(names.logGroup); int(group.RetentionInDays) != want.LogRetentionDays {
+			t.Errorf("log group retention = %d days, want %d", group.RetentionInDays, want.LogRetentionDays)
+		}
+		if alarm, _ := cloud.CloudWatch.Alarm(names.errorRateAlarm); alarm.Threshold != want.ErrorRateAlarmThreshold {
+			t.Errorf("error rate alarm threshold = %v, want %v", alarm.Threshold, want.ErrorRateAlarmThreshold)
+		}
+		bucket, _ := cloud.S3.Bucket(names.bucket)
+		var days []int32
+		for _, rule := range bucket.Lifecycle {
+			for _, transition := range rule.Transitions {
+				days = append(days, *transition.Days)
+			}
+		}
+		if len(days) != 2 || int(days[0]) != want.S3InfrequentAccessDays || int(days[1]) != want.S3GlacierDays {
+			t.Errorf("bucket transitions after %v days, want %d and %d", days, want.S3InfrequentAccessDays, want.S3GlacierDays)
+		}
+	}
+	check(want)
+
+	// Provisioning again with other options updates the resources
+	req.Options = &models.ResourceOptions{LambdaTimeoutSeconds: 120, LogRetentionDays: 30}
+	if _, err := p.ProvisionClientResources(ctx, req); err != nil {
+		t.Fatalf("ProvisionClientResources() error = %v with new options", err)
+	}
+	check(resolveOptions(DefaultOptions(), req.Options))
+
+	// Options out of bounds are rejected before AWS is called
+	before := len(cloud.Calls())
+	req.Options = &models.ResourceOptions{LambdaMemoryMB: 64}
+	_, err = p.ProvisionClientResources(ctx, req)
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest {
+		t.Errorf("out of bounds options: error = %v, want %s", err, models.ErrCodeInvalidRequest)
+	}
+	if calls := cloud.Calls()[before:]; len(calls) != 0 {
+		t.Errorf("AWS was called for invalid options: %v", calls)
+	}
+}
@@ -0,0 +1,105 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// planActionUnknown is reported for resources whose current state could not
+// be read.
+const planActionUnknown = "unknown"
+
+// PlanClientResources reports what ProvisionClientResources would do for the
+// request, in its one region, without changing anything: for each resource of
+// the request's tier whether it would be created, updated or left alone, with
+// the configuration it would get.
+//
+// A resource that cannot be read is reported with an error and the plan is
+// still returned, together with an error.
+func (p *ResourceProvisioner) PlanClientResources(ctx context.Context, req *models.ProvisionRequest) (_ *models.PlanResponse, err error) {
+	ctx, span := startSpan(ctx, "PlanClientResources", req.ClientID)
+	defer func() { endSpan(span, err) }()
+	if err := p.ValidateClientID(req.ClientID, p.requestRegions(req)); err != nil {
+		return nil, err
+	}
+	blueprint, opts, err := p.tierSettings(req)
+	if err != nil {
+		return nil, err
+	}
+	regions := p.requestRegions(req)
+	if len(regions) > 1 {
+		return nil, models.NewProvisionError(models.ErrCodeInvalidRequest, "a plan covers only one region", nil)
+	}
+	p, err = p.forRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = p.withClient(ctx, req.ClientID)
+	p, ctx, err = p.inRegion(ctx, regions[0])
+	if err != nil {
+		return nil, err
+	}
+	p.log(ctx).Info("Planning resources")
+
+	nodes, err := p.clientResources(req.ClientID, opts, blueprint.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.PlanResponse{
+		ClientID:  req.ClientID,
+		AccountID: p.accountID,
+		Region:    p.region,
+		Tier:      blueprint.Name,
+		DryRun:    true,
+		Options:   opts,
+		Summary: map[string]int{
+			models.PlanActionCreate: 0,
+			models.PlanActionUpdate: 0,
+			models.PlanActionNone:   0,
+		},
+	}
+
+	// Nodes are sorted, so the ARNs a node depends on are always known
+	arns := make(map[string]string, len(nodes))
+	var failed []string
+	var firstErr error
+	var firstFailed *resourceNode
+	for _, n := range nodes {
+		deps := make(map[string]string, len(n.dependsOn))
+		for _, dep := range n.dependsOn {
+			deps[dep] = arns[dep]
+		}
+
+		nodeCtx := p.withNode(ctx, n)
+		planned, err := n.plan(nodeCtx, deps)
+		if planned == nil {
+			planned = &models.PlannedResource{}
+		}
+		planned.Type = n.resourceType
+		planned.Name = n.name
+		if err != nil {
+			p.log(nodeCtx).Error("Failed to plan resource", logger.Err(err))
+			planned.Action = planActionUnknown
+			planned.Error = classifyError(n.id, n.name, fmt.Sprintf("failed to plan %s %s", n.resourceType, n.name), err).ClientMessage()
+			if firstErr == nil {
+				firstErr, firstFailed = err, n
+			}
+			failed = append(failed, n.name)
+		}
+
+		arns[n.id] = planned.ARN
+		response.Summary[planned.Action]++
+		response.Resources = append(response.Resources, *planned)
+	}
+
+	if len(failed) > 0 {
+		// The error is classified by the first failure
+		message := fmt.Sprintf("failed to plan %d resources: %v", len(failed), failed)
+		return response, classifyError(firstFailed.id, firstFailed.name, message, firstErr)
+	}
+	return response, nil
+}
@@ -0,0 +1,69 @@
+package provisioner
+
+import (
+	"context"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StepReporter is told as each provisioning step starts and finishes, e.g. by
+// a background job tracking progress.
+type StepReporter interface {
+	StepStarted(step string)
+	StepFinished(step string, err error)
+}
+
+type stepReporterKey struct{}
+
+// WithStepReporter returns a context whose provisioning steps are reported to r.
+func WithStepReporter(ctx context.Context, r StepReporter) context.Context {
+	return context.WithValue(ctx, stepReporterKey{}, r)
+}
+
+type stepPrefixKey struct{}
+
+// withStepPrefix returns a context whose steps are reported to the
+// StepReporter as "<prefix>/<step>", telling the regions of a run apart.
+func withStepPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, stepPrefixKey{}, prefix)
+}
+
+// runStep runs fn as the named step in a span of its own, reporting it to the
+// context's StepReporter and logging and measuring its outcome and duration.
+func (p *ResourceProvisioner) runStep(ctx context.Context, step string, fn func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "step "+step, trace.WithAttributes(attribute.String("provisioner.step", step)))
+	log := p.log(ctx).With(logger.Step, step)
+	r, _ := ctx.Value(stepReporterKey{}).(StepReporter)
+	reported := step
+	if prefix, ok := ctx.Value(stepPrefixKey{}).(string); ok {
+		reported = prefix + "/" + step
+	}
+	if r != nil {
+		r.StepStarted(reported)
+	}
+	log.Debug("Step started")
+	start := time.Now()
+
+	err := fn(ctx)
+	endSpan(span, err)
+
+	duration := time.Since(start)
+	p.metrics.ObserveStep(step, duration, err)
+	if err != nil {
+		log.Error("Step failed", "duration_ms", duration.Milliseconds(), logger.Err(err))
+	} else {
+		log.Info("Step finished", "duration_ms", duration.Milliseconds())
+	}
+	if r != nil {
+		// Reporters show the error to clients, so it is classified first
+		var reportErr error
+		if err != nil {
+			reportErr = classifyError(step, "", "step "+step+" failed", err)
+		}
+		r.StepFinished(reported, reportErr)
+	}
+	return err
+}
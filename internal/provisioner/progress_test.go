@@ -0,0 +1,37 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
+)
+
+func TestProvisionRecordsMetrics(t *testing.T) {
+	cloud := newTestCloud(testAccount, "us-east-1")
+	// The role depends on the bucket, so the bucket is always created, and
+	// rolled back
+	cloud.FailOn("iam:CreateRole", errors.New("injected failure"))
+	p := newTestProvisioner(t, cloud)
+	p.metrics = metrics.New()
+
+	if _, err := p.ProvisionClientResources(context.Background(), testRequest("acme")); err == nil {
+		t.Fatal("ProvisionClientResources() error = nil, want the injected failure")
+	}
+
+	w := httptest.NewRecorder()
+	p.metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		`provisioner_step_duration_seconds_count{outcome="success",step="s3_bucket"} 1`,
+		`provisioner_step_failures_total{step="iam_role"} 1`,
+		`provisioner_rollbacks_total 1`,
+		`provisioner_rolled_back_resources_total{resource_type="s3_bucket"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
@@ -2,196 +2,283 @@ package provisioner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/blueprints"
 	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/metrics"
 	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
 	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
 	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
-	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
-	"github.com/aws/aws-sdk-go-v2/service/iam"
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
 )
 
 type ResourceProvisioner struct {
-	s3Client             *s3.Client
-	iamClient            *iam.Client
-	cloudwatchClient     *cloudwatch.Client
-	cloudwatchLogsClient *cloudwatchlogs.Client
-	eventBridgeClient    *eventbridge.Client
-	lambdaClient         *lambda.Client
-	snsClient            *sns.Client
-	config               *config.Config
-	logger               *logger.Logger
+	s3Client             awsclient.S3API
+	iamClient            awsclient.IAMAPI
+	cloudwatchClient     awsclient.CloudWatchAPI
+	cloudwatchLogsClient awsclient.CloudWatchLogsAPI
+	eventBridgeClient    awsclient.EventBridgeAPI
+	lambdaClient         awsclient.LambdaAPI
+	snsClient            awsclient.SNSAPI
+	// clients builds the clients above for each region of the account
+	clients  *awsclient.Factory
+	accounts *accounts.Registry
+	// tiers holds the blueprints clients are provisioned from, nil if only
+	// the built-in default tier exists
+	tiers *blueprints.Registry
+	// accountID and region are those the clients above act in. They are
+	// set on copies of the provisioner made by inAccount and inRegion.
+	accountID string
+	region    string
+	store     state.Store
+	audit     *audit.Log
+	metrics   *metrics.Metrics
+	config    *config.Config
+	logger    *logger.Logger
 }
 
-func NewResourceProvisioner(cfg *config.Config, awsClient *awsclient.AWSClient, logger *logger.Logger) *ResourceProvisioner {
+// NewResourceProvisioner returns a provisioner working in the service's own
+// account with the clients of awsClients, and in the accounts of registry on
+// request. Clients get the resources of the tiers in tiers, or with nil tiers
+// the full stack of the default tier.
+func NewResourceProvisioner(cfg *config.Config, awsClients *awsclient.Factory, registry *accounts.Registry, tiers *blueprints.Registry, store state.Store, auditLog *audit.Log, metrics *metrics.Metrics, logger *logger.Logger) *ResourceProvisioner {
 	return &ResourceProvisioner{
-		s3Client:             awsClient.S3Client,
-		iamClient:            awsClient.IAMClient,
-		cloudwatchClient:     awsClient.CloudWatchClient,
-		cloudwatchLogsClient: awsClient.CloudWatchLogsClient,
-		eventBridgeClient:    awsClient.EventBridgeClient,
-		lambdaClient:         awsClient.LambdaClient,
-		snsClient:            awsClient.SNSClient,
-		config:               cfg,
-		logger:               logger,
+		clients:   awsClients,
+		accounts:  registry,
+		tiers:     tiers,
+		accountID: cfg.AWSAccountID,
+		store:     store,
+		audit:     auditLog,
+		metrics:   metrics,
+		config:    cfg,
+		logger:    logger,
 	}
 }
 
-func (p *ResourceProvisioner) ProvisionClientResources(ctx context.Context, req *models.ProvisionRequest) (*models.ProvisionResponse, error) {
-	p.logger.Info(fmt.Sprintf("Starting resource provisioning for client: %s", req.ClientID))
+func (p *ResourceProvisioner) setClients(awsClient *awsclient.AWSClient) {
+	p.s3Client = awsClient.S3Client
+	p.iamClient = awsClient.IAMClient
+	p.cloudwatchClient = awsClient.CloudWatchClient
+	p.cloudwatchLogsClient = awsClient.CloudWatchLogsClient
+	p.eventBridgeClient = awsClient.EventBridgeClient
+	p.lambdaClient = awsClient.LambdaClient
+	p.snsClient = awsClient.SNSClient
+}
 
-	// Generate resource names
-	bucketName := fmt.Sprintf("%s-%s-bucket", p.config.Environment, req.ClientID)
-	roleName := fmt.Sprintf("%s-%s-role", p.config.Environment, req.ClientID)
-	logGroupName := fmt.Sprintf("/aws/client/%s/%s", p.config.Environment, req.ClientID)
-	ruleName := fmt.Sprintf("%s-%s-rule", p.config.Environment, req.ClientID)
-	lambdaName := fmt.Sprintf("%s-%s-processor", p.config.Environment, req.ClientID)
-	topicName := fmt.Sprintf("%s-%s-alerts", p.config.Environment, req.ClientID)
+// log returns the context's logger, which carries the client, job or request
+// being worked on, falling back to the provisioner's own.
+func (p *ResourceProvisioner) log(ctx context.Context) *logger.Logger {
+	return logger.FromContext(ctx, p.logger)
+}
 
-	// Create S3 bucket
-	err := p.createS3Bucket(ctx, bucketName)
-	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to create S3 bucket: %v", err))
-		return nil, fmt.Errorf("failed to create S3 bucket: %w", err)
-	}
+// withClient returns a context whose log entries are tagged with the client
+// and the account it is provisioned in.
+func (p *ResourceProvisioner) withClient(ctx context.Context, clientID string) context.Context {
+	return logger.WithContext(ctx, p.log(ctx).With(logger.ClientID, clientID, logger.AccountID, p.accountID))
+}
 
-	// Create IAM role
-	roleARN, err := p.createIAMRole(ctx, roleName, bucketName, logGroupName, lambdaName)
-	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to create IAM role: %v", err))
-		p.deleteS3Bucket(ctx, bucketName)
-		return nil, fmt.Errorf("failed to create IAM role: %w", err)
-	}
+// ProvisionClientResources converges the client's resources in the region
+// of req, which must name at most one. See ProvisionClientRegions.
+func (p *ResourceProvisioner) ProvisionClientResources(ctx context.Context, req *models.ProvisionRequest) (_ *models.ProvisionResponse, err error) {
+	ctx, span := startSpan(ctx, "ProvisionClientResources", req.ClientID)
+	defer func() { endSpan(span, err) }()
 
-	// Create CloudWatch Log Group
-	err = p.createLogGroup(ctx, logGroupName)
+	regions := p.requestRegions(req)
+	if len(regions) > 1 {
+		return nil, models.NewProvisionError(models.ErrCodeInvalidRequest, "more than one region requested", nil)
+	}
+	results, err := p.provision(ctx, req, regions)
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to create log group: %v", err))
-		p.cleanup(ctx, &cleanupConfig{
-			bucketName: bucketName,
-			roleName:   roleName,
-		})
-		return nil, fmt.Errorf("failed to create log group: %w", err)
+		return nil, err
 	}
+	return &results[0], nil
+}
 
-	// Create Lambda Function
-	lambdaARN, err := p.createLambdaFunction(ctx, lambdaName, roleARN, bucketName)
-	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to create lambda function: %v", err))
-		p.cleanup(ctx, &cleanupConfig{
-			bucketName:   bucketName,
-			roleName:     roleName,
-			logGroupName: logGroupName,
-		})
-		return nil, fmt.Errorf("failed to create lambda function: %w", err)
+// ProvisionClientRegions converges the resources that the blueprint of req's
+// tier lists for the client, in each region of req in turn: missing ones are
+// created, drifted ones updated and matching ones left alone. They go in the
+// requested account, else the one the client was provisioned in before, else
+// the service's own.
+//
+// Within a region, resources are applied in dependency order with independent
+// ones in parallel. If any fails, the resources created in that region by
+// this run are rolled back in reverse order, and the other regions carry on.
+// If ctx is cancelled by shutdown, the resources are kept instead and the
+// remaining regions skipped, and the record is marked interrupted, so that
+// provisioning again resumes where this run stopped.
+//
+// The response has the result of each region. If any failed, it is returned
+// together with an error classified by the first failure.
+func (p *ResourceProvisioner) ProvisionClientRegions(ctx context.Context, req *models.ProvisionRequest) (_ *models.MultiRegionResponse, err error) {
+	ctx, span := startSpan(ctx, "ProvisionClientRegions", req.ClientID)
+	defer func() { endSpan(span, err) }()
+
+	results, err := p.provision(ctx, req, p.requestRegions(req))
+	if results == nil {
+		return nil, err
 	}
 
-	// Create EventBridge Rule
-	err = p.createEventRule(ctx, ruleName, logGroupName, lambdaARN)
-	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to create event rule: %v", err))
-		p.cleanup(ctx, &cleanupConfig{
-			bucketName:   bucketName,
-			roleName:     roleName,
-			logGroupName: logGroupName,
-			lambdaName:   lambdaName,
-		})
-		return nil, fmt.Errorf("failed to create event rule: %w", err)
+	response := &models.MultiRegionResponse{
+		ClientID: req.ClientID,
+		Status:   "success",
+		Regions:  results,
+	}
+	failed := 0
+	for _, result := range results {
+		response.AccountID = result.AccountID
+		response.Tier = result.Tier
+		if result.Status != "success" {
+			failed++
+		}
 	}
+	switch {
+	case failed == len(results):
+		response.Status = "failed"
+	case failed > 0:
+		response.Status = "partial"
+	}
+	return response, err
+}
 
-	// Create SNS Topic
-	topicARN, err := p.createSNSTopic(ctx, topicName)
+// provision converges the client's resources in each of regions and returns
+// the result of each, failed ones with their error. The returned error is
+// that of the only region, or a summary classified by the first failure. No
+// results are returned if the run could not start.
+func (p *ResourceProvisioner) provision(ctx context.Context, req *models.ProvisionRequest, regions []string) ([]models.ProvisionResponse, error) {
+	if err := p.ValidateClientID(req.ClientID, regions); err != nil {
+		return nil, err
+	}
+	blueprint, opts, err := p.tierSettings(req)
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to create SNS topic: %v", err))
-		p.cleanup(ctx, &cleanupConfig{
-			bucketName:   bucketName,
-			roleName:     roleName,
-			logGroupName: logGroupName,
-			lambdaName:   lambdaName,
-			ruleName:     ruleName,
-		})
-		return nil, fmt.Errorf("failed to create SNS topic: %w", err)
+		return nil, err
 	}
-
-	// Set up CloudWatch Alarms
-	err = p.setupCloudWatchAlarms(ctx, logGroupName, topicARN, req.ClientID)
+	p, err = p.forRequest(ctx, req)
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to set up alarms: %v", err))
-		p.cleanup(ctx, &cleanupConfig{
-			bucketName:   bucketName,
-			roleName:     roleName,
-			logGroupName: logGroupName,
-			lambdaName:   lambdaName,
-			ruleName:     ruleName,
-			topicARN:     topicARN,
-		})
-		return nil, fmt.Errorf("failed to set up alarms: %w", err)
+		return nil, err
 	}
+	ctx = p.withClient(ctx, req.ClientID)
+	p.log(ctx).Info("Starting resource provisioning", "regions", regions, "tier", blueprint.Name)
 
-	response := &models.ProvisionResponse{
-		Status:       "success",
-		BucketName:   bucketName,
-		RoleARN:      roleARN,
-		LogGroupName: logGroupName,
-		LambdaARN:    lambdaARN,
-		TopicARN:     topicARN,
+	// The tier is recorded even if it was left to the default, so that status
+	// and teardown still know it if the default changes
+	recorded := *req
+	recorded.Tier = blueprint.Name
+	record, err := p.beginRecord(ctx, req.ClientID, state.StatusProvisioning, &recorded)
+	if err != nil {
+		return nil, err
 	}
+	record.AddRegions(regions...)
+	p.saveRecord(ctx, record)
 
-	p.logger.Info("Successfully provisioned all resources")
-	return response, nil
-}
-
-// Add this struct and method in your provisioner.go file, after the ProvisionClientResources function
-
-type cleanupConfig struct {
-	bucketName   string
-	roleName     string
-	logGroupName string
-	lambdaName   string
-	ruleName     string
-	topicARN     string
-}
+	results := make([]models.ProvisionResponse, 0, len(regions))
+	var failed []string
+	var firstErr error
+	for _, region := range regions {
+		regionCtx := ctx
+		if len(regions) > 1 {
+			regionCtx = withStepPrefix(ctx, region)
+		}
+		result, err := p.provisionRegion(regionCtx, record, req.ClientID, region, blueprint, opts)
+		if err != nil {
+			result = &models.ProvisionResponse{Status: "failed", AccountID: p.accountID, Region: region, Tier: blueprint.Name,
+				Error: classifyError("", "", "failed to provision region "+region, err).ClientMessage()}
+			failed = append(failed, region)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		results = append(results, *result)
 
-func (p *ResourceProvisioner) cleanup(ctx context.Context, config *cleanupConfig) {
-	if config.bucketName != "" {
-		if err := p.deleteS3Bucket(ctx, config.bucketName); err != nil {
-			p.logger.Error(fmt.Sprintf("Failed to cleanup S3 bucket: %v", err))
+		// At shutdown, leave the remaining regions to the next run
+		if ctx.Err() != nil {
+			break
 		}
 	}
 
-	if config.roleName != "" {
-		if err := p.cleanupIAMRole(ctx, config.roleName); err != nil {
-			p.logger.Error(fmt.Sprintf("Failed to cleanup IAM role: %v", err))
+	status := state.StatusProvisioned
+	switch {
+	case ctx.Err() != nil:
+		// Already cancelled, so the record is written with a context that is not
+		ctx = context.WithoutCancel(ctx)
+		status = state.StatusInterrupted
+	case len(failed) > 0:
+		status = state.StatusFailed
+	}
+	err = firstErr
+	if len(failed) > 0 && len(regions) > 1 {
+		code := models.ErrCodeInternal
+		var perr *models.ProvisionError
+		if errors.As(firstErr, &perr) {
+			code = perr.Code
 		}
+		err = models.NewProvisionError(code, fmt.Sprintf("provisioning failed in %d of %d regions: %v", len(failed), len(regions), failed), firstErr)
 	}
+	p.finishRecord(ctx, record, status, err)
+	return results, err
+}
 
-	if config.logGroupName != "" {
-		if err := p.deleteLogGroup(ctx, config.logGroupName); err != nil {
-			p.logger.Error(fmt.Sprintf("Failed to cleanup log group: %v", err))
-		}
+// provisionRegion converges the client's resources of blueprint in region
+// with the settings in opts, rolling back the ones it created if any fails,
+// unless ctx was cancelled.
+func (p *ResourceProvisioner) provisionRegion(ctx context.Context, record *state.Record, clientID, region string, blueprint blueprints.Blueprint, opts models.ResourceOptions) (*models.ProvisionResponse, error) {
+	p, ctx, err := p.inRegion(ctx, region)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.lambdaName != "" {
-		if err := p.deleteLambdaFunction(ctx, config.lambdaName); err != nil {
-			p.logger.Error(fmt.Sprintf("Failed to cleanup lambda function: %v", err))
-		}
+	nodes, err := p.clientResources(clientID, opts, blueprint.Resources)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.ruleName != "" {
-		if err := p.deleteEventRule(ctx, config.ruleName); err != nil {
-			p.logger.Error(fmt.Sprintf("Failed to cleanup event rule: %v", err))
+	results, err := p.applyGraph(ctx, nodes, func(result nodeResult) {
+		p.recordResource(ctx, record, result.node.resourceType, result.node.name, result.arn)
+	})
+	if err != nil && ctx.Err() != nil {
+		err = models.NewProvisionError(models.ErrCodeUnavailable, "provisioning interrupted by shutdown, provision again to resume", err)
+		p.log(ctx).Warn("Provisioning interrupted, keeping created resources for the next run", logger.Err(err))
+		return nil, err
+	}
+	if err != nil {
+		var rolledBack []*resourceNode
+		p.runStep(ctx, "rollback", func(ctx context.Context) error {
+			rolledBack = p.rollback(ctx, results)
+			return nil
+		})
+		rolledBackTypes := make([]string, 0, len(rolledBack))
+		for _, n := range rolledBack {
+			rolledBackTypes = append(rolledBackTypes, n.resourceType)
+			record.SetResource(state.Resource{Region: region, Type: n.resourceType, Name: n.name, Status: state.ResourceRolledBack})
 		}
+		p.metrics.ObserveRollback(rolledBackTypes)
+		return nil, err
 	}
 
-	if config.topicARN != "" {
-		if err := p.deleteSNSTopic(ctx, config.topicARN); err != nil {
-			p.logger.Error(fmt.Sprintf("Failed to cleanup SNS topic: %v", err))
+	response := &models.ProvisionResponse{Status: "success", AccountID: p.accountID, Region: region, Tier: blueprint.Name, Options: &opts}
+	for _, result := range results {
+		switch result.node.id {
+		case nodeBucket:
+			response.BucketName = result.node.name
+		case nodeRole:
+			response.RoleARN = result.arn
+		case nodeLogGroup:
+			response.LogGroupName = result.node.name
+		case nodeLambda:
+			response.LambdaARN = result.arn
+		case nodeTopic:
+			response.TopicARN = result.arn
 		}
+		response.Resources = append(response.Resources, models.ResourceResult{
+			Type:   result.node.resourceType,
+			Name:   result.node.name,
+			Status: result.outcome,
+		})
 	}
+
+	p.log(ctx).Info("Successfully provisioned all resources")
+	return response, nil
 }
@@ -0,0 +1,593 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/accounts"
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+const (
+	testAccount  = "123456789012"
+	otherAccount = "210987654321"
+)
+
+// newTestCloud returns a fake cloud with the service's shared policy, which
+// roles are attached to.
+func newTestCloud(accountID, region string) *fake.Cloud {
+	cloud := fake.New(accountID, region)
+	cloud.IAM.AddPolicy("go-infra-policy")
+	return cloud
+}
+
+// newTestProvisioner returns a provisioner working in the default config's
+// region of testAccount, against clouds.
+func newTestProvisioner(t *testing.T, clouds ...*fake.Cloud) *ResourceProvisioner {
+	t.Helper()
+	cfg := config.Default()
+	cfg.AWSAccountID = testAccount
+	log, err := logger.New("error", "text", io.Discard)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return NewResourceProvisioner(cfg, fake.NewFactory(clouds...), nil, nil, state.NewMemoryStore(), audit.NewMemoryLog(), nil, log)
+}
+
+// withAccount registers accountID with p, served by clouds.
+func withAccount(p *ResourceProvisioner, accountID string, clouds ...*fake.Cloud) {
+	p.accounts = accounts.NewRegistry(
+		[]accounts.Account{{ID: accountID, RoleARN: "arn:aws:iam::" + accountID + ":role/go-infra-provisioner"}},
+		func(accounts.Account) *awsclient.Factory { return fake.NewFactory(clouds...) },
+	)
+}
+
+// existingResources returns the components of the default tier the client
+// has in cloud.
+func existingResources(p *ResourceProvisioner, cloud *fake.Cloud, clientID string) []string {
+	names := p.namesIn(clientID, cloud.Region)
+	exists := map[string]bool{}
+	_, exists[nodeBucket] = cloud.S3.Bucket(names.bucket)
+	_, exists[nodeLogGroup] = cloud.CloudWatchLogs.LogGroup(names.logGroup)
+	_, exists[nodeRole] = cloud.IAM.Role(names.role)
+	_, exists[nodeLambda] = cloud.Lambda.Function(names.lambda)
+	_, exists[nodeRule] = cloud.EventBridge.Rule(names.rule)
+	_, exists[nodeTopic] = cloud.SNS.Topic(cloud.SNS.TopicARN(names.topic))
+	_, exists[nodeErrorRateAlarm] = cloud.CloudWatch.Alarm(names.errorRateAlarm)
+	_, exists[nodeLogVolumeAlarm] = cloud.CloudWatch.Alarm(names.logVolumeAlarm)
+
+	var found []string
+	for _, component := range builtinComponents {
+		if exists[component] {
+			found = append(found, component)
+		}
+	}
+	return found
+}
+
+func testRequest(clientID string) *models.ProvisionRequest {
+	return &models.ProvisionRequest{ClientID: clientID, ClientName: "Test " + clientID}
+}
+
+func TestProvisionClientResources(t *testing.T) {
+	tests := []struct {
+		name   string
+		failOn string
+	}{
+		{name: "creates every resource"},
+		{name: "rolls back when the bucket fails", failOn: "s3:CreateBucket"},
+		{name: "rolls back when the role fails", failOn: "iam:CreateRole"},
+		{name: "rolls back when the function fails", failOn: "lambda:CreateFunction"},
+		{name: "rolls back when the rule fails", failOn: "eventbridge:PutRule"},
+		{name: "rolls back when the topic fails", failOn: "sns:CreateTopic"},
+		{name: "rolls back when an alarm fails", failOn: "cloudwatch:PutMetricAlarm"},
+		{name: "rolls back when the log group fails", failOn: "logs:CreateLogGroup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			if tt.failOn != "" {
+				cloud.FailOn(tt.failOn, errors.New("injected failure"))
+			}
+
+			resp, err := p.ProvisionClientResources(ctx, testRequest("acme"))
+			record, getErr := p.store.Get(ctx, "acme")
+			if getErr != nil {
+				t.Fatalf("store.Get() error = %v", getErr)
+			}
+
+			if tt.failOn == "" {
+				if err != nil {
+					t.Fatalf("ProvisionClientResources() error = %v", err)
+				}
+				if resp.Status != "success" || resp.BucketName != "dev-acme-bucket" || resp.AccountID != testAccount || resp.Region != "us-east-1" {
+					t.Errorf("response = %+v", resp)
+				}
+				if got := existingResources(p, cloud, "acme"); !slices.Equal(got, builtinComponents) {
+					t.Errorf("resources = %v, want %v", got, builtinComponents)
+				}
+				for _, result := range resp.Resources {
+					if result.Status != outcomeCreated {
+						t.Errorf("%s %s is %s, want %s", result.Type, result.Name, result.Status, outcomeCreated)
+					}
+				}
+				if record.Status != state.StatusProvisioned {
+					t.Errorf("record status = %s, want %s", record.Status, state.StatusProvisioned)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("ProvisionClientResources() succeeded despite the failure")
+			}
+			if got := existingResources(p, cloud, "acme"); len(got) != 0 {
+				t.Errorf("resources left after rollback: %v", got)
+			}
+			if record.Status != state.StatusFailed {
+				t.Errorf("record status = %s, want %s", record.Status, state.StatusFailed)
+			}
+		})
+	}
+}
+
+func TestProvisionAgainAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+
+	cloud.FailOn("sns:CreateTopic", errors.New("injected failure"))
+	if _, err := p.ProvisionClientResources(ctx, testRequest("acme")); err == nil {
+		t.Fatal("ProvisionClientResources() succeeded despite the failure")
+	}
+	cloud.ClearFailures()
+	if _, err := p.ProvisionClientResources(ctx, testRequest("acme")); err != nil {
+		t.Fatalf("ProvisionClientResources() error = %v after the failure cleared", err)
+	}
+	if got := existingResources(p, cloud, "acme"); !slices.Equal(got, builtinComponents) {
+		t.Errorf("resources = %v, want %v", got, builtinComponents)
+	}
+}
+
+// A run interrupted by shutdown keeps what it created, and the next run
+// carries on from there.
+func TestProvisionInterrupted(t *testing.T) {
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Shutting down while the role is created, which always follows the bucket
+	cloud.AddCallRecorder(func(call awsclient.Call) {
+		if call.Service+":"+call.Operation == "iam:CreateRole" {
+			cancel()
+		}
+	})
+	cloud.FailOn("iam:CreateRole", context.Canceled)
+
+	_, err := p.ProvisionClientResources(ctx, testRequest("acme"))
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) || perr.Code != models.ErrCodeUnavailable {
+		t.Fatalf("ProvisionClientResources() error = %v, want %s", err, models.ErrCodeUnavailable)
+	}
+	record, err := p.store.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("store.Get() error = %v", err)
+	}
+	if record.Status != state.StatusInterrupted {
+		t.Errorf("record status = %s, want %s", record.Status, state.StatusInterrupted)
+	}
+	kept := existingResources(p, cloud, "acme")
+	if !slices.Contains(kept, nodeBucket) || slices.Contains(kept, nodeRole) {
+		t.Errorf("resources kept = %v, want those created before the role", kept)
+	}
+
+	cloud.ClearFailures()
+	resp, err := p.ProvisionClientResources(context.Background(), testRequest("acme"))
+	if err != nil {
+		t.Fatalf("ProvisionClientResources() error = %v after the interruption", err)
+	}
+	var created int
+	for _, result := range resp.Resources {
+		if result.Status == outcomeCreated {
+			created++
+		}
+	}
+	if want := len(builtinComponents) - len(kept); created != want {
+		t.Errorf("resuming created %d resources, want the %d missing", created, want)
+	}
+}
+
+func TestProvisionIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+
+	first, err := p.ProvisionClientResources(ctx, testRequest("acme"))
+	if err != nil {
+		t.Fatalf("first run: error = %v", err)
+	}
+	before := len(cloud.Calls())
+	second, err := p.ProvisionClientResources(ctx, testRequest("acme"))
+	if err != nil {
+		t.Fatalf("second run: error = %v", err)
+	}
+
+	for _, result := range second.Resources {
+		if result.Status != outcomeUnchanged {
+			t.Errorf("second run: %s %s is %s, want %s", result.Type, result.Name, result.Status, outcomeUnchanged)
+		}
+	}
+	if first.LambdaARN != second.LambdaARN || first.TopicARN != second.TopicARN || first.RoleARN != second.RoleARN {
+		t.Errorf("ARNs changed between runs: %+v and %+v", first, second)
+	}
+	for _, call := range cloud.Calls()[before:] {
+		if !isReadOnly(call) {
+			t.Errorf("second run called %s", call)
+		}
+	}
+}
+
+func TestDeprovisionClientResources(t *testing.T) {
+	tests := []struct {
+		name       string
+		provision  bool
+		tornDown   bool
+		failOn     string
+		wantStatus string
+		wantResult string
+	}{
+		{name: "deletes every resource", provision: true, wantStatus: "success", wantResult: "deleted"},
+		{name: "reports missing resources", provision: true, tornDown: true, wantStatus: "success", wantResult: "not_found"},
+		{name: "reports a failed deletion", provision: true, failOn: "lambda:DeleteFunction", wantStatus: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			if tt.provision {
+				if _, err := p.ProvisionClientResources(ctx, testRequest("acme")); err != nil {
+					t.Fatalf("ProvisionClientResources() error = %v", err)
+				}
+			}
+			if tt.tornDown {
+				if _, err := p.DeprovisionClientResources(ctx, "acme", ""); err != nil {
+					t.Fatalf("first teardown: error = %v", err)
+				}
+			}
+			if tt.failOn != "" {
+				cloud.FailOn(tt.failOn, errors.New("injected failure"))
+			}
+
+			resp, err := p.DeprovisionClientResources(ctx, "acme", "")
+			if (err != nil) != (tt.failOn != "") {
+				t.Fatalf("DeprovisionClientResources() error = %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", resp.Status, tt.wantStatus)
+			}
+			if len(resp.Resources) != len(builtinComponents) {
+				t.Errorf("got %d resource results, want %d", len(resp.Resources), len(builtinComponents))
+			}
+
+			if tt.failOn == "" {
+				for _, result := range resp.Resources {
+					want := tt.wantResult
+					// SNS deletes missing topics without complaint
+					if result.Type == nodeTopic {
+						want = "deleted"
+					}
+					if result.Status != want {
+						t.Errorf("%s %s is %s, want %s", result.Type, result.Name, result.Status, want)
+					}
+				}
+				if got := existingResources(p, cloud, "acme"); len(got) != 0 {
+					t.Errorf("resources left after teardown: %v", got)
+				}
+				return
+			}
+
+			// Every other resource is still attempted
+			if got := existingResources(p, cloud, "acme"); !slices.Equal(got, []string{nodeLambda}) {
+				t.Errorf("resources left = %v, want only %s", got, nodeLambda)
+			}
+			cloud.ClearFailures()
+			if _, err := p.DeprovisionClientResources(ctx, "acme", ""); err != nil {
+				t.Fatalf("second teardown: error = %v", err)
+			}
+			if got := existingResources(p, cloud, "acme"); len(got) != 0 {
+				t.Errorf("resources left after the second teardown: %v", got)
+			}
+		})
+	}
+}
+
+// A client with no record, such as a mistyped one, is not torn down, and no
+// record is left behind for it.
+func TestDeprovisionUnknownClient(t *testing.T) {
+	ctx := context.Background()
+	cloud := newTestCloud(testAccount, "us-east-1")
+	p := newTestProvisioner(t, cloud)
+
+	_, err := p.DeprovisionClientResources(ctx, "acme", "")
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) || perr.Code != models.ErrCodeNotFound {
+		t.Errorf("DeprovisionClientResources() error = %v, want %s", err, models.ErrCodeNotFound)
+	}
+	if _, err := p.store.Get(ctx, "acme"); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("store.Get() error = %v, want no record", err)
+	}
+	if calls := cloud.Calls(); len(calls) != 0 {
+		t.Errorf("AWS was called for an unknown client: %v", calls)
+	}
+}
+
+func TestPlanClientResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud)
+		want    map[string]int
+	}{
+		{
+			name: "new client",
+			want: map[string]int{models.PlanActionCreate: len(builtinComponents), models.PlanActionUpdate: 0, models.PlanActionNone: 0},
+		},
+		{
+			name: "provisioned client",
+			prepare: func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud) {
+				if _, err := p.ProvisionClientResources(context.Background(), testRequest("acme")); err != nil {
+					t.Fatalf("ProvisionClientResources() error = %v", err)
+				}
+			},
+			want: map[string]int{models.PlanActionCreate: 0, models.PlanActionUpdate: 0, models.PlanActionNone: len(builtinComponents)},
+		},
+		{
+			name: "deleted topic",
+			prepare: func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud) {
+				ctx := context.Background()
+				if _, err := p.ProvisionClientResources(ctx, testRequest("acme")); err != nil {
+					t.Fatalf("ProvisionClientResources() error = %v", err)
+				}
+				inRegion, ctx, err := p.inRegion(ctx, "us-east-1")
+				if err != nil {
+					t.Fatalf("inRegion() error = %v", err)
+				}
+				if err := inRegion.deleteSNSTopic(ctx, inRegion.topicARN("dev-acme-alerts")); err != nil {
+					t.Fatalf("deleteSNSTopic() error = %v", err)
+				}
+			},
+			want: map[string]int{models.PlanActionCreate: 1, models.PlanActionUpdate: 0, models.PlanActionNone: len(builtinComponents) - 1},
+		},
+		{
+			name: "drifted log retention",
+			prepare: func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud) {
+				ctx := context.Background()
+				if _, err := p.ProvisionClientResources(ctx, testRequest("acme")); err != nil {
+					t.Fatalf("ProvisionClientResources() error = %v", err)
+				}
+				_, err := cloud.CloudWatchLogs.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+					LogGroupName:    aws.String("/aws/client/dev/acme"),
+					RetentionInDays: aws.Int32(1),
+				})
+				if err != nil {
+					t.Fatalf("PutRetentionPolicy() error = %v", err)
+				}
+			},
+			want: map[string]int{models.PlanActionCreate: 0, models.PlanActionUpdate: 1, models.PlanActionNone: len(builtinComponents) - 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			if tt.prepare != nil {
+				tt.prepare(t, p, cloud)
+			}
+			before := len(cloud.Calls())
+
+			plan, err := p.PlanClientResources(context.Background(), testRequest("acme"))
+			if err != nil {
+				t.Fatalf("PlanClientResources() error = %v", err)
+			}
+			if !plan.DryRun || len(plan.Resources) != len(builtinComponents) {
+				t.Errorf("plan = %+v, want a dry run of %d resources", plan, len(builtinComponents))
+			}
+			for action, n := range tt.want {
+				if plan.Summary[action] != n {
+					t.Errorf("summary[%s] = %d, want %d", action, plan.Summary[action], n)
+				}
+			}
+			for _, call := range cloud.Calls()[before:] {
+				if !isReadOnly(call) {
+					t.Errorf("plan called %s", call)
+				}
+			}
+		})
+	}
+}
+
+// isReadOnly reports whether the fake operation only reads.
+func isReadOnly(operation string) bool {
+	_, op, _ := strings.Cut(operation, ":")
+	for _, prefix := range []string{"Get", "Describe", "List", "Head"} {
+		if strings.HasPrefix(op, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProvisionClientRegions(t *testing.T) {
+	tests := []struct {
+		name       string
+		failIn     string
+		wantStatus string
+	}{
+		{name: "every region", wantStatus: "success"},
+		{name: "one region fails", failIn: "eu-west-1", wantStatus: "partial"},
+		{name: "every region fails", failIn: "*", wantStatus: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := newTestCloud(testAccount, "us-east-1")
+			abroad := newTestCloud(testAccount, "eu-west-1")
+			p := newTestProvisioner(t, home, abroad)
+			for _, cloud := range []*fake.Cloud{home, abroad} {
+				if tt.failIn == "*" || tt.failIn == cloud.Region {
+					cloud.FailOn("sns:CreateTopic", errors.New("injected failure"))
+				}
+			}
+
+			req := testRequest("acme")
+			req.Regions = []string{"us-east-1", "eu-west-1"}
+			resp, err := p.ProvisionClientRegions(context.Background(), req)
+			if (err != nil) != (tt.failIn != "") {
+				t.Fatalf("ProvisionClientRegions() error = %v", err)
+			}
+			if resp.Status != tt.wantStatus || len(resp.Regions) != 2 {
+				t.Fatalf("response = %+v, want %s with 2 regions", resp, tt.wantStatus)
+			}
+
+			for i, cloud := range []*fake.Cloud{home, abroad} {
+				result := resp.Regions[i]
+				failed := tt.failIn == "*" || tt.failIn == cloud.Region
+				if result.Region != cloud.Region || (result.Status == "success") == failed {
+					t.Errorf("result %d = %s in %s, want failed = %v in %s", i, result.Status, result.Region, failed, cloud.Region)
+				}
+				want := builtinComponents
+				if failed {
+					want = nil
+				}
+				if got := existingResources(p, cloud, "acme"); !slices.Equal(got, want) {
+					t.Errorf("%s: resources = %v, want %v", cloud.Region, got, want)
+				}
+			}
+			if tt.failIn == "" {
+				if _, ok := abroad.S3.Bucket("dev-acme-eu-west-1-bucket"); !ok {
+					t.Error("the bucket outside the service's region does not carry the region")
+				}
+			}
+		})
+	}
+}
+
+// Each region's resources refer to that region, and the record tells which
+// region each resource is in.
+func TestProvisionClientRegionsRecordsRegions(t *testing.T) {
+	ctx := context.Background()
+	home := newTestCloud(testAccount, "us-east-1")
+	abroad := newTestCloud(testAccount, "eu-west-1")
+	p := newTestProvisioner(t, home, abroad)
+	provisionRegions(t, p, "acme", home, abroad)
+
+	for _, cloud := range []*fake.Cloud{home, abroad} {
+		names := p.namesIn("acme", cloud.Region)
+		if bucket, _ := cloud.S3.Bucket(names.bucket); bucket.Region != cloud.Region {
+			t.Errorf("bucket %s is in %q, want %s", names.bucket, bucket.Region, cloud.Region)
+		}
+		role, _ := cloud.IAM.Role(names.role)
+		if policy := role.InlinePolicies[names.role+"-policy"]; !strings.Contains(policy, "arn:aws:logs:"+cloud.Region+":"+testAccount+":log-group:"+names.logGroup) {
+			t.Errorf("%s role policy %s does not grant the region's log group", cloud.Region, policy)
+		}
+	}
+
+	record, err := p.store.Get(ctx, "acme")
+	if err != nil {
+		t.Fatalf("store.Get() error = %v", err)
+	}
+	if want := []string{"us-east-1", "eu-west-1"}; !slices.Equal(record.Regions, want) {
+		t.Errorf("record regions = %v, want %v", record.Regions, want)
+	}
+	perRegion := map[string]int{}
+	for _, res := range record.Resources {
+		perRegion[res.Region]++
+	}
+	if len(perRegion) != 2 || perRegion["us-east-1"] != perRegion["eu-west-1"] {
+		t.Errorf("recorded resources per region = %v, want the same resources in both", perRegion)
+	}
+}
+
+// Records written before clients could be in several regions are read as
+// being in the service's region.
+func TestGetRecordWithoutRegions(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		wantRegions []string
+	}{
+		{name: "provisioned", status: state.StatusProvisioned, wantRegions: []string{"us-east-1"}},
+		{name: "deprovisioned", status: state.StatusDeprovisioned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p := newTestProvisioner(t)
+			old := &state.Record{ClientID: "acme", Status: tt.status, Resources: []state.Resource{{Type: "s3_bucket", Name: "dev-acme-bucket"}}}
+			if err := p.store.Put(ctx, old); err != nil {
+				t.Fatalf("store.Put() error = %v", err)
+			}
+
+			record, err := p.getRecord(ctx, "acme")
+			if err != nil {
+				t.Fatalf("getRecord() error = %v", err)
+			}
+			if !slices.Equal(record.Regions, tt.wantRegions) || record.Resources[0].Region != "us-east-1" {
+				t.Errorf("record regions = %v with resources in %q, want %v with resources in us-east-1",
+					record.Regions, record.Resources[0].Region, tt.wantRegions)
+			}
+		})
+	}
+}
+
+func TestProvisionInAnotherAccount(t *testing.T) {
+	ctx := context.Background()
+	home := newTestCloud(testAccount, "us-east-1")
+	other := newTestCloud(otherAccount, "us-east-1")
+	p := newTestProvisioner(t, home)
+	withAccount(p, otherAccount, other)
+
+	req := testRequest("acme")
+	req.AccountID = otherAccount
+	resp, err := p.ProvisionClientResources(ctx, req)
+	if err != nil {
+		t.Fatalf("ProvisionClientResources() error = %v", err)
+	}
+	if resp.AccountID != otherAccount || !strings.Contains(resp.RoleARN, otherAccount) {
+		t.Errorf("response = %+v, want resources in %s", resp, otherAccount)
+	}
+	if got := existingResources(p, other, "acme"); !slices.Equal(got, builtinComponents) {
+		t.Errorf("resources in the other account = %v, want %v", got, builtinComponents)
+	}
+	if got := existingResources(p, home, "acme"); len(got) != 0 {
+		t.Errorf("resources in the service's account = %v, want none", got)
+	}
+
+	// Later calls find the account in the client's record
+	if _, err := p.DeprovisionClientResources(ctx, "acme", ""); err != nil {
+		t.Fatalf("DeprovisionClientResources() error = %v", err)
+	}
+	if got := existingResources(p, other, "acme"); len(got) != 0 {
+		t.Errorf("resources left in the other account: %v", got)
+	}
+
+	req.AccountID = "999999999999"
+	_, err = p.ProvisionClientResources(ctx, req)
+	var perr *models.ProvisionError
+	if !errors.As(err, &perr) || perr.Code != models.ErrCodeInvalidRequest {
+		t.Errorf("unregistered account: error = %v, want %s", err, models.ErrCodeInvalidRequest)
+	}
+}
@@ -0,0 +1,97 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// beginRecord loads the client's state record, or starts a new one, and marks
+// it with the given in-progress status. It fails if the record cannot be
+// written, so no AWS resources are touched without a record of it, and if the
+// client still has resources in another account than p's.
+func (p *ResourceProvisioner) beginRecord(ctx context.Context, clientID, status string, req *models.ProvisionRequest) (*state.Record, error) {
+	record, err := p.getRecord(ctx, clientID)
+	if err != nil {
+		if !errors.Is(err, state.ErrNotFound) {
+			return nil, fmt.Errorf("failed to load state record: %w", err)
+		}
+		record = &state.Record{
+			ClientID:    clientID,
+			Environment: p.config.Environment,
+			CreatedAt:   time.Now().UTC(),
+		}
+	}
+
+	if record.AccountID != "" && record.AccountID != p.accountID && record.Status != state.StatusDeprovisioned {
+		return nil, models.NewProvisionError(models.ErrCodeConflict,
+			fmt.Sprintf("client %s is provisioned in account %s, deprovision it there first", clientID, record.AccountID), nil)
+	}
+	record.AccountID = p.accountID
+
+	if req != nil {
+		record.ClientName = req.ClientName
+		record.Request = *req
+	}
+	record.Status = status
+	record.Error = ""
+	record.UpdatedAt = time.Now().UTC()
+
+	if err := p.store.Put(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to save state record: %w", err)
+	}
+	return record, nil
+}
+
+// getRecord loads the client's state record. Records written before clients
+// could be provisioned in several regions are read as being in the service's
+// own region.
+func (p *ResourceProvisioner) getRecord(ctx context.Context, clientID string) (*state.Record, error) {
+	record, err := p.store.Get(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(record.Regions) == 0 && record.Status != state.StatusDeprovisioned {
+		record.Regions = []string{p.config.AWSRegion}
+	}
+	for i := range record.Resources {
+		if record.Resources[i].Region == "" {
+			record.Resources[i].Region = p.config.AWSRegion
+		}
+	}
+	return record, nil
+}
+
+// saveRecord persists the record; failures are logged rather than returned so
+// they never interrupt work that is already under way in AWS.
+func (p *ResourceProvisioner) saveRecord(ctx context.Context, record *state.Record) {
+	record.UpdatedAt = time.Now().UTC()
+	if err := p.store.Put(ctx, record); err != nil {
+		p.log(ctx).Error("Failed to save state record", logger.Err(err))
+	}
+}
+
+func (p *ResourceProvisioner) recordResource(ctx context.Context, record *state.Record, resourceType, name, arn string) {
+	record.SetResource(state.Resource{
+		Region: p.region,
+		Type:   resourceType,
+		Name:   name,
+		ARN:    arn,
+		Status: state.ResourceCreated,
+	})
+	p.saveRecord(ctx, record)
+}
+
+// finishRecord sets the final status of a run along with the error, if any.
+func (p *ResourceProvisioner) finishRecord(ctx context.Context, record *state.Record, status string, err error) {
+	record.Status = status
+	if err != nil {
+		record.Error = models.ClientMessage(err)
+	}
+	p.saveRecord(ctx, record)
+}
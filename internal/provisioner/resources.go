@@ -0,0 +1,287 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// Node IDs of the client resource graph, which blueprints list as the
+// components of a tier
+const (
+	nodeBucket               = "s3_bucket"
+	nodeRole                 = "iam_role"
+	nodeLogGroup             = "log_group"
+	nodeLambda               = "lambda_function"
+	nodeRule                 = "eventbridge_rule"
+	nodeTopic                = "sns_topic"
+	nodeErrorRateAlarm       = "error_rate_alarm"
+	nodeLogVolumeAlarm       = "log_volume_alarm"
+	nodeLambdaErrorsAlarm    = "lambda_errors_alarm"
+	nodeLambdaThrottlesAlarm = "lambda_throttles_alarm"
+	nodeDashboard            = "dashboard"
+)
+
+// Components lists every component a blueprint can include.
+var Components = []string{
+	nodeBucket,
+	nodeLogGroup,
+	nodeRole,
+	nodeLambda,
+	nodeRule,
+	nodeTopic,
+	nodeErrorRateAlarm,
+	nodeLogVolumeAlarm,
+	nodeLambdaErrorsAlarm,
+	nodeLambdaThrottlesAlarm,
+	nodeDashboard,
+}
+
+// componentDeps lists the components each component depends on. The graph
+// is built from it, and blueprints are checked against it, so a blueprint
+// that includes a component includes what it depends on too.
+var componentDeps = map[string][]string{
+	// The role's inline policy grants access to the bucket, and to the log
+	// group by name whether or not it exists
+	nodeRole:                 {nodeBucket},
+	nodeLambda:               {nodeRole, nodeBucket},
+	nodeRule:                 {nodeLambda, nodeLogGroup},
+	nodeErrorRateAlarm:       {nodeTopic},
+	nodeLogVolumeAlarm:       {nodeTopic, nodeLogGroup},
+	nodeLambdaErrorsAlarm:    {nodeTopic, nodeLambda},
+	nodeLambdaThrottlesAlarm: {nodeTopic, nodeLambda},
+	nodeDashboard:            {nodeLambda, nodeLogGroup},
+}
+
+// clientResources returns the resource graph of a client made of
+// components, sorted so that every resource comes after the resources it
+// depends on. Provisioning, status and teardown all walk this graph; applying
+// and planning use the settings in opts.
+func (p *ResourceProvisioner) clientResources(clientID string, opts models.ResourceOptions, components []string) ([]*resourceNode, error) {
+	nodes := slices.DeleteFunc(p.resourceGraph(clientID, opts), func(n *resourceNode) bool {
+		return !slices.Contains(components, n.id)
+	})
+	return sortNodes(nodes)
+}
+
+// resourceGraph returns a node for every component, unsorted.
+func (p *ResourceProvisioner) resourceGraph(clientID string, opts models.ResourceOptions) []*resourceNode {
+	names := p.namesFor(clientID)
+
+	return []*resourceNode{
+		{
+			id:           nodeBucket,
+			clientID:     clientID,
+			resourceType: "s3_bucket",
+			name:         names.bucket,
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeS3Bucket(ctx, names.bucket)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				outcome, err := p.ensureS3Bucket(ctx, names.bucket, opts)
+				return fmt.Sprintf("arn:aws:s3:::%s", names.bucket), outcome, err
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planS3Bucket(ctx, names.bucket, opts)
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteS3Bucket(ctx, names.bucket)
+			},
+		},
+		{
+			id:           nodeLogGroup,
+			clientID:     clientID,
+			resourceType: "log_group",
+			name:         names.logGroup,
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeLogGroup(ctx, names.logGroup)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				outcome, err := p.ensureLogGroup(ctx, names.logGroup, opts.LogRetentionDays)
+				return "", outcome, err
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planLogGroup(ctx, names.logGroup, opts.LogRetentionDays)
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteLogGroup(ctx, names.logGroup)
+			},
+		},
+		{
+			id:           nodeRole,
+			clientID:     clientID,
+			resourceType: "iam_role",
+			name:         names.role,
+			dependsOn:    componentDeps[nodeRole],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeIAMRole(ctx, names.role)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				return p.ensureIAMRole(ctx, names.role, names.bucket, names.logGroup)
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planIAMRole(ctx, names.role, names.bucket, names.logGroup)
+			},
+			delete: func(ctx context.Context) error {
+				return p.cleanupIAMRole(ctx, names.role)
+			},
+		},
+		{
+			id:           nodeLambda,
+			clientID:     clientID,
+			resourceType: "lambda_function",
+			name:         names.lambda,
+			dependsOn:    componentDeps[nodeLambda],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeLambdaFunction(ctx, names.lambda)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				return p.ensureLambdaFunction(ctx, names.lambda, deps[nodeRole], names.bucket, opts)
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planLambdaFunction(ctx, names.lambda, deps[nodeRole], names.bucket, opts)
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteLambdaFunction(ctx, names.lambda)
+			},
+		},
+		{
+			id:           nodeRule,
+			clientID:     clientID,
+			resourceType: "eventbridge_rule",
+			name:         names.rule,
+			dependsOn:    componentDeps[nodeRule],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeEventRule(ctx, names.rule)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				return p.ensureEventRule(ctx, names.rule, names.logGroup, deps[nodeLambda])
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planEventRule(ctx, names.rule, names.logGroup, deps[nodeLambda])
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteEventRule(ctx, names.rule)
+			},
+		},
+		{
+			id:           nodeTopic,
+			clientID:     clientID,
+			resourceType: "sns_topic",
+			name:         names.topic,
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeSNSTopic(ctx, p.topicARN(names.topic))
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				return p.ensureSNSTopic(ctx, names.topic)
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planSNSTopic(ctx, names.topic)
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteSNSTopic(ctx, p.topicARN(names.topic))
+			},
+		},
+		{
+			id:           nodeErrorRateAlarm,
+			clientID:     clientID,
+			resourceType: "cloudwatch_alarm",
+			name:         names.errorRateAlarm,
+			dependsOn:    componentDeps[nodeErrorRateAlarm],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeAlarm(ctx, names.errorRateAlarm)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				outcome, err := p.ensureAlarm(ctx, p.errorRateAlarm(clientID, deps[nodeTopic], opts))
+				return "", outcome, err
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planAlarm(ctx, p.errorRateAlarm(clientID, deps[nodeTopic], opts))
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteAlarm(ctx, names.errorRateAlarm)
+			},
+		},
+		{
+			id:           nodeLogVolumeAlarm,
+			clientID:     clientID,
+			resourceType: "cloudwatch_alarm",
+			name:         names.logVolumeAlarm,
+			dependsOn:    componentDeps[nodeLogVolumeAlarm],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeAlarm(ctx, names.logVolumeAlarm)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				outcome, err := p.ensureAlarm(ctx, p.logVolumeAlarm(clientID, names.logGroup, deps[nodeTopic], opts))
+				return "", outcome, err
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planAlarm(ctx, p.logVolumeAlarm(clientID, names.logGroup, deps[nodeTopic], opts))
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteAlarm(ctx, names.logVolumeAlarm)
+			},
+		},
+		{
+			id:           nodeLambdaErrorsAlarm,
+			clientID:     clientID,
+			resourceType: "cloudwatch_alarm",
+			name:         names.lambdaErrorsAlarm,
+			dependsOn:    componentDeps[nodeLambdaErrorsAlarm],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeAlarm(ctx, names.lambdaErrorsAlarm)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				outcome, err := p.ensureAlarm(ctx, p.lambdaErrorsAlarm(clientID, deps[nodeTopic]))
+				return "", outcome, err
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planAlarm(ctx, p.lambdaErrorsAlarm(clientID, deps[nodeTopic]))
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteAlarm(ctx, names.lambdaErrorsAlarm)
+			},
+		},
+		{
+			id:           nodeLambdaThrottlesAlarm,
+			clientID:     clientID,
+			resourceType: "cloudwatch_alarm",
+			name:         names.lambdaThrottlesAlarm,
+			dependsOn:    componentDeps[nodeLambdaThrottlesAlarm],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeAlarm(ctx, names.lambdaThrottlesAlarm)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				outcome, err := p.ensureAlarm(ctx, p.lambdaThrottlesAlarm(clientID, deps[nodeTopic]))
+				return "", outcome, err
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planAlarm(ctx, p.lambdaThrottlesAlarm(clientID, deps[nodeTopic]))
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteAlarm(ctx, names.lambdaThrottlesAlarm)
+			},
+		},
+		{
+			id:           nodeDashboard,
+			clientID:     clientID,
+			resourceType: "cloudwatch_dashboard",
+			name:         names.dashboard,
+			dependsOn:    componentDeps[nodeDashboard],
+			read: func(ctx context.Context) (*models.ResourceStatus, error) {
+				return p.describeDashboard(ctx, names.dashboard)
+			},
+			apply: func(ctx context.Context, deps map[string]string) (string, string, error) {
+				return p.ensureDashboard(ctx, names.dashboard, p.dashboardBody(clientID))
+			},
+			plan: func(ctx context.Context, deps map[string]string) (*models.PlannedResource, error) {
+				return p.planDashboard(ctx, names.dashboard, p.dashboardBody(clientID))
+			},
+			delete: func(ctx context.Context) error {
+				return p.deleteDashboard(ctx, names.dashboard)
+			},
+		},
+	}
+}
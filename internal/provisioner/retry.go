@@ -0,0 +1,76 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// retryPolicy bounds how often an operation is retried and how long to wait
+// between attempts. The wait starts at backoff and doubles up to maxBackoff.
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// iamRoleWaitTimeout and lambdaCreatePolicy fall back on config.Default for
+// configurations that leave the IAM propagation settings unset.
+func (p *ResourceProvisioner) iamRoleWaitTimeout() time.Duration {
+	if p.config.IAMRoleWaitTimeout > 0 {
+		return p.config.IAMRoleWaitTimeout
+	}
+	return config.Default().IAMRoleWaitTimeout
+}
+
+func (p *ResourceProvisioner) lambdaCreatePolicy() retryPolicy {
+	defaults := config.Default()
+	policy := retryPolicy{
+		attempts:   p.config.LambdaCreateAttempts,
+		backoff:    p.config.LambdaCreateBackoff,
+		maxBackoff: p.config.LambdaCreateMaxBackoff,
+	}
+	if policy.attempts <= 0 {
+		policy.attempts = defaults.LambdaCreateAttempts
+	}
+	if policy.backoff <= 0 {
+		policy.backoff = defaults.LambdaCreateBackoff
+	}
+	if policy.maxBackoff <= 0 {
+		policy.maxBackoff = defaults.LambdaCreateMaxBackoff
+	}
+	return policy
+}
+
+// retry calls fn until it succeeds, fails with an error retryable rejects or
+// runs out of attempts, and returns its last error. Waiting between attempts
+// stops early if ctx is cancelled.
+func (p *ResourceProvisioner) retry(ctx context.Context, operation string, policy retryPolicy, retryable func(error) bool, fn func() error) error {
+	wait := policy.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !retryable(err) {
+			return err
+		}
+		if attempt >= policy.attempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		p.log(ctx).Warn("Retrying "+operation, "wait", wait.String(), "attempt", attempt, "attempts", policy.attempts, logger.Err(err))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		wait *= 2
+		if wait > policy.maxBackoff {
+			wait = policy.maxBackoff
+		}
+	}
+}
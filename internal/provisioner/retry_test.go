@@ -0,0 +1,147 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+)
+
+func TestLambdaCreatePolicy(t *testing.T) {
+	defaults := config.Default()
+	tests := []struct {
+		name        string
+		attempts    int
+		backoff     time.Duration
+		maxBackoff  time.Duration
+		wantPolicy  retryPolicy
+		waitTimeout time.Duration
+		wantWait    time.Duration
+	}{
+		{
+			name:     "configured",
+			attempts: 3, backoff: 10 * time.Millisecond, maxBackoff: time.Second,
+			wantPolicy:  retryPolicy{attempts: 3, backoff: 10 * time.Millisecond, maxBackoff: time.Second},
+			waitTimeout: time.Minute,
+			wantWait:    time.Minute,
+		},
+		{
+			name:       "unset falls back on the config defaults",
+			wantPolicy: retryPolicy{attempts: defaults.LambdaCreateAttempts, backoff: defaults.LambdaCreateBackoff, maxBackoff: defaults.LambdaCreateMaxBackoff},
+			wantWait:   defaults.IAMRoleWaitTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvisioner(t)
+			p.config.LambdaCreateAttempts = tt.attempts
+			p.config.LambdaCreateBackoff = tt.backoff
+			p.config.LambdaCreateMaxBackoff = tt.maxBackoff
+			p.config.IAMRoleWaitTimeout = tt.waitTimeout
+
+			if got := p.lambdaCreatePolicy(); got != tt.wantPolicy {
+				t.Errorf("lambdaCreatePolicy() = %+v, want %+v", got, tt.wantPolicy)
+			}
+			if got := p.iamRoleWaitTimeout(); got != tt.wantWait {
+				t.Errorf("iamRoleWaitTimeout() = %s, want %s", got, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	errRetryable := errors.New("not yet")
+	errFatal := errors.New("fatal")
+	policy := retryPolicy{attempts: 4, backoff: time.Millisecond, maxBackoff: 2 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		failures  []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"succeeds at once", nil, 1, nil},
+		{"succeeds after retries", []error{errRetryable, errRetryable}, 3, nil},
+		{"stops on a fatal error", []error{errRetryable, errFatal}, 2, errFatal},
+		{"gives up after the last attempt", []error{errRetryable, errRetryable, errRetryable, errRetryable, errRetryable}, 4, errRetryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvisioner(t)
+			calls := 0
+			err := p.retry(context.Background(), "test", policy, func(err error) bool { return errors.Is(err, errRetryable) }, func() error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("retry() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+			if (tt.wantErr == nil) != (err == nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("retry() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	p := newTestProvisioner(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := retryPolicy{attempts: 10, backoff: time.Hour, maxBackoff: time.Hour}
+
+	calls := 0
+	err := p.retry(ctx, "test", policy, func(error) bool { return true }, func() error {
+		calls++
+		cancel()
+		return errors.New("not yet")
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("retry() = %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+}
+
+// Lambda rejects a new role until it has propagated; provisioning retries
+// CreateFunction until the policy's attempts run out.
+func TestProvisionRetriesUnassumableRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"role assumable at once", 0, 1, false},
+		{"role assumable after retries", 2, 3, false},
+		{"role never assumable", 5, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(testAccount, "us-east-1")
+			cloud.Lambda.DelayRoleAssumption(tt.delay)
+			p := newTestProvisioner(t, cloud)
+			p.config.LambdaCreateAttempts = 3
+			p.config.LambdaCreateBackoff = time.Millisecond
+			p.config.LambdaCreateMaxBackoff = time.Millisecond
+
+			_, err := p.ProvisionClientResources(context.Background(), testRequest("acme"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProvisionClientResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			calls := 0
+			for _, op := range cloud.Calls() {
+				if op == "lambda:CreateFunction" {
+					calls++
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("CreateFunction called %d times, want %d", calls, tt.wantCalls)
+			}
+			if _, ok := cloud.Lambda.Function(p.namesIn("acme", cloud.Region).lambda); ok == tt.wantErr {
+				t.Errorf("function exists = %v, want %v", ok, !tt.wantErr)
+			}
+		})
+	}
+}
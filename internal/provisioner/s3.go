@@ -3,22 +3,55 @@ package provisioner
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// ensureS3Bucket creates the bucket if it is missing and brings versioning and
+// lifecycle rules on an existing bucket back in line with what we provision.
+//
+// Like the other ensure functions it returns outcomeCreated even when a later
+// step fails, so that the caller rolls back a partially created resource.
+func (p *ResourceProvisioner) ensureS3Bucket(ctx context.Context, bucketName string, opts models.ResourceOptions) (string, error) {
+	outcome := outcomeUnchanged
+
+	_, err := p.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	switch {
+	case isNotFound(err):
+		outcome = outcomeCreated
+		if err := p.createS3Bucket(ctx, bucketName); err != nil {
+			return outcome, err
+		}
+	case err != nil:
+		return "", fmt.Errorf("failed to check bucket: %w", err)
+	}
+
+	changed, err := p.configureS3Bucket(ctx, bucketName, opts)
+	if err != nil {
+		return outcome, err
+	}
+	if changed && outcome == outcomeUnchanged {
+		outcome = outcomeUpdated
+	}
+	return outcome, nil
+}
+
 func (p *ResourceProvisioner) createS3Bucket(ctx context.Context, bucketName string) error {
-	p.logger.Info(fmt.Sprintf("Creating S3 bucket: %s", bucketName))
+	p.log(ctx).Info("Creating S3 bucket")
 
 	input := &s3.CreateBucketInput{
 		Bucket: aws.String(bucketName),
 	}
 
-	if p.config.AWSRegion != "us-east-1" {
+	if p.region != "us-east-1" {
 		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
-			LocationConstraint: types.BucketLocationConstraint(p.config.AWSRegion),
+			LocationConstraint: types.BucketLocationConstraint(p.region),
 		}
 	}
 
@@ -27,46 +60,156 @@ func (p *ResourceProvisioner) createS3Bucket(ctx context.Context, bucketName str
 		return fmt.Errorf("failed to create bucket: %w", err)
 	}
 
-	// Enable versioning
-	_, err = p.s3Client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
-		Bucket: aws.String(bucketName),
-		VersioningConfiguration: &types.VersioningConfiguration{
-			Status: types.BucketVersioningStatusEnabled,
+	return nil
+}
+
+// lifecycleRuleID names the log archival rule in the bucket's lifecycle.
+const lifecycleRuleID = "log-archival"
+
+// bucketLifecycleRules are the lifecycle rules for log archival, moving logs
+// to Standard-IA and then Glacier after the days set in opts. S3 rejects a
+// rule without a filter, so the rule covers every object with an empty prefix.
+func bucketLifecycleRules(opts models.ResourceOptions) []types.LifecycleRule {
+	return []types.LifecycleRule{
+		{
+			ID:     aws.String(lifecycleRuleID),
+			Filter: &types.LifecycleRuleFilterMemberPrefix{Value: ""},
+			Status: types.ExpirationStatusEnabled,
+			Transitions: []types.Transition{
+				{
+					Days:         aws.Int32(int32(opts.S3InfrequentAccessDays)),
+					StorageClass: types.TransitionStorageClassStandardIa,
+				},
+				{
+					Days:         aws.Int32(int32(opts.S3GlacierDays)),
+					StorageClass: types.TransitionStorageClassGlacier,
+				},
+			},
 		},
+	}
+}
+
+// bucketDrift describes how a bucket's configuration differs from what we
+// provision.
+type bucketDrift struct {
+	versioning bool
+	lifecycle  bool
+	changes    []string
+}
+
+func (p *ResourceProvisioner) s3BucketDrift(ctx context.Context, bucketName string, opts models.ResourceOptions) (*bucketDrift, error) {
+	drift := &bucketDrift{}
+
+	versioning, err := p.s3Client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucketName),
 	})
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to enable versioning: %v", err))
+		return nil, fmt.Errorf("failed to get bucket versioning: %w", err)
 	}
-
-	// Setup lifecycle rules for log archival
-	lifecycleRule := &types.LifecycleRule{
-		Status: types.ExpirationStatusEnabled,
-		Transitions: []types.Transition{
-			{
-				Days:         aws.Int32(30),
-				StorageClass: types.TransitionStorageClassStandardIa,
-			},
-			{
-				Days:         aws.Int32(90),
-				StorageClass: types.TransitionStorageClassGlacier,
-			},
-		},
+	if versioning.Status != types.BucketVersioningStatusEnabled {
+		current := string(versioning.Status)
+		if current == "" {
+			current = "Disabled"
+		}
+		drift.versioning = true
+		drift.changes = append(drift.changes, fmt.Sprintf("versioning: %s -> Enabled", current))
 	}
 
-	_, err = p.s3Client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+	var current []types.LifecycleRule
+	lifecycle, err := p.s3Client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
 		Bucket: aws.String(bucketName),
-		LifecycleConfiguration: &types.BucketLifecycleConfiguration{
-			Rules: []types.LifecycleRule{*lifecycleRule},
-		},
 	})
+	switch {
+	case errorCode(err) == "NoSuchLifecycleConfiguration":
+	case err != nil:
+		return nil, fmt.Errorf("failed to get bucket lifecycle: %w", err)
+	default:
+		current = lifecycle.Rules
+	}
+	if !lifecycleRulesEqual(current, bucketLifecycleRules(opts)) {
+		drift.lifecycle = true
+		drift.changes = append(drift.changes, "lifecycle rules")
+	}
+
+	return drift, nil
+}
+
+// configureS3Bucket enables versioning and sets the lifecycle rules where they
+// differ from what the bucket has, and reports whether anything changed.
+func (p *ResourceProvisioner) configureS3Bucket(ctx context.Context, bucketName string, opts models.ResourceOptions) (bool, error) {
+	drift, err := p.s3BucketDrift(ctx, bucketName, opts)
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to set lifecycle rules: %v", err))
+		return false, err
 	}
 
-	return nil
+	if drift.versioning {
+		p.log(ctx).Info("Enabling versioning on S3 bucket")
+		_, err = p.s3Client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+			Bucket: aws.String(bucketName),
+			VersioningConfiguration: &types.VersioningConfiguration{
+				Status: types.BucketVersioningStatusEnabled,
+			},
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to enable versioning: %w", err)
+		}
+	}
+
+	if drift.lifecycle {
+		p.log(ctx).Info("Setting lifecycle rules on S3 bucket")
+		_, err = p.s3Client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+			Bucket: aws.String(bucketName),
+			LifecycleConfiguration: &types.BucketLifecycleConfiguration{
+				Rules: bucketLifecycleRules(opts),
+			},
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to set lifecycle rules: %w", err)
+		}
+	}
+
+	return len(drift.changes) > 0, nil
+}
+
+// lifecycleRulesEqual compares the ID, filter, status and transitions of each
+// rule, the only parts of a rule we set.
+func lifecycleRulesEqual(a, b []types.LifecycleRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if aws.ToString(a[i].ID) != aws.ToString(b[i].ID) || !lifecycleFiltersEqual(a[i].Filter, b[i].Filter) ||
+			a[i].Status != b[i].Status || len(a[i].Transitions) != len(b[i].Transitions) {
+			return false
+		}
+		for j := range a[i].Transitions {
+			ta, tb := a[i].Transitions[j], b[i].Transitions[j]
+			if aws.ToInt32(ta.Days) != aws.ToInt32(tb.Days) || ta.StorageClass != tb.StorageClass {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lifecycleFiltersEqual compares prefix filters, the only kind we set. Any
+// other kind of filter differs from ours.
+func lifecycleFiltersEqual(a, b types.LifecycleRuleFilter) bool {
+	pa, ok := a.(*types.LifecycleRuleFilterMemberPrefix)
+	if !ok {
+		return false
+	}
+	pb, ok := b.(*types.LifecycleRuleFilterMemberPrefix)
+	return ok && pa.Value == pb.Value
 }
+
 func (p *ResourceProvisioner) deleteS3Bucket(ctx context.Context, bucketName string) error {
-	p.logger.Info(fmt.Sprintf("Deleting S3 bucket: %s", bucketName))
+	p.log(ctx).Info("Deleting S3 bucket")
+
+	// Buckets must be empty, including old versions, before they can be deleted
+	if err := p.emptyS3Bucket(ctx, bucketName); err != nil {
+		return err
+	}
 
 	_, err := p.s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{
 		Bucket: aws.String(bucketName),
@@ -76,3 +219,132 @@ func (p *ResourceProvisioner) deleteS3Bucket(ctx context.Context, bucketName str
 	}
 	return nil
 }
+
+func (p *ResourceProvisioner) emptyS3Bucket(ctx context.Context, bucketName string) error {
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucketName),
+	}
+
+	for {
+		page, err := p.s3Client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to list bucket objects: %w", err)
+		}
+
+		var objects []types.ObjectIdentifier
+		for _, v := range page.Versions {
+			objects = append(objects, types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+		}
+		for _, m := range page.DeleteMarkers {
+			objects = append(objects, types.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+		}
+
+		if len(objects) > 0 {
+			_, err = p.s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+				Bucket: aws.String(bucketName),
+				Delete: &types.Delete{
+					Objects: objects,
+					Quiet:   aws.Bool(true),
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to delete bucket objects: %w", err)
+			}
+		}
+
+		if !aws.ToBool(page.IsTruncated) {
+			return nil
+		}
+		input.KeyMarker = page.NextKeyMarker
+		input.VersionIdMarker = page.NextVersionIdMarker
+	}
+}
+
+func (p *ResourceProvisioner) describeS3Bucket(ctx context.Context, bucketName string) (*models.ResourceStatus, error) {
+	_, err := p.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return &models.ResourceStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to describe bucket: %w", err)
+	}
+
+	status := &models.ResourceStatus{
+		Exists:  true,
+		ARN:     fmt.Sprintf("arn:aws:s3:::%s", bucketName),
+		Details: map[string]string{},
+	}
+
+	versioning, err := p.s3Client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket versioning: %w", err)
+	}
+	status.Details["versioning"] = string(versioning.Status)
+	if versioning.Status == "" {
+		status.Details["versioning"] = "Disabled"
+	}
+
+	lifecycle, err := p.s3Client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+	switch {
+	case errorCode(err) == "NoSuchLifecycleConfiguration":
+		status.Details["lifecycle"] = "none"
+	case err != nil:
+		return nil, fmt.Errorf("failed to get bucket lifecycle: %w", err)
+	default:
+		var transitions []string
+		for _, rule := range lifecycle.Rules {
+			for _, t := range rule.Transitions {
+				transitions = append(transitions, fmt.Sprintf("%dd:%s", aws.ToInt32(t.Days), t.StorageClass))
+			}
+		}
+		status.Details["lifecycle"] = strings.Join(transitions, ",")
+	}
+
+	return status, nil
+}
+
+// planS3Bucket reports what ensureS3Bucket would do, without changing anything.
+func (p *ResourceProvisioner) planS3Bucket(ctx context.Context, bucketName string, opts models.ResourceOptions) (*models.PlannedResource, error) {
+	var lifecycle []string
+	for _, rule := range bucketLifecycleRules(opts) {
+		for _, t := range rule.Transitions {
+			lifecycle = append(lifecycle, fmt.Sprintf("%dd:%s", aws.ToInt32(t.Days), t.StorageClass))
+		}
+	}
+	planned := &models.PlannedResource{
+		ARN:    fmt.Sprintf("arn:aws:s3:::%s", bucketName),
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"region":     p.region,
+			"versioning": string(types.BucketVersioningStatusEnabled),
+			"lifecycle":  lifecycle,
+		},
+	}
+
+	_, err := p.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	switch {
+	case isNotFound(err):
+		planned.Action = models.PlanActionCreate
+		return planned, nil
+	case err != nil:
+		return planned, fmt.Errorf("failed to check bucket: %w", err)
+	}
+
+	drift, err := p.s3BucketDrift(ctx, bucketName, opts)
+	if err != nil {
+		return planned, err
+	}
+	if len(drift.changes) > 0 {
+		planned.Action = models.PlanActionUpdate
+		planned.Changes = drift.changes
+	}
+	return planned, nil
+}
@@ -2,15 +2,44 @@ package provisioner
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sns/types"
 )
 
+// ensureSNSTopic creates the topic if it is missing and restores the topic
+// policy that lets CloudWatch publish to it.
+func (p *ResourceProvisioner) ensureSNSTopic(ctx context.Context, topicName string) (string, string, error) {
+	topicARN := p.topicARN(topicName)
+
+	attributes, err := p.snsClient.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{
+		TopicArn: aws.String(topicARN),
+	})
+	if isNotFound(err) {
+		topicARN, err := p.createSNSTopic(ctx, topicName)
+		return topicARN, outcomeCreated, err
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check SNS topic: %w", err)
+	}
+
+	if documentsEqual(attributes.Attributes["Policy"], topicPolicy(topicARN)) {
+		return topicARN, outcomeUnchanged, nil
+	}
+	p.log(ctx).Info("Updating SNS topic policy")
+	if err := p.setTopicPolicy(ctx, topicARN); err != nil {
+		return "", "", err
+	}
+	return topicARN, outcomeUpdated, nil
+}
+
 func (p *ResourceProvisioner) createSNSTopic(ctx context.Context, topicName string) (string, error) {
-	p.logger.Info(fmt.Sprintf("Creating SNS topic: %s", topicName))
+	p.log(ctx).Info("Creating SNS topic")
 
 	// Create SNS topic with correct Tag type
 	result, err := p.snsClient.CreateTopic(ctx, &sns.CreateTopicInput{
@@ -31,7 +60,16 @@ func (p *ResourceProvisioner) createSNSTopic(ctx context.Context, topicName stri
 	}
 
 	// Set up topic policy
-	policy := fmt.Sprintf(`{
+	if err := p.setTopicPolicy(ctx, *result.TopicArn); err != nil {
+		return "", err
+	}
+
+	return *result.TopicArn, nil
+}
+
+// topicPolicy allows CloudWatch alarms to publish to the topic.
+func topicPolicy(topicARN string) string {
+	return fmt.Sprintf(`{
         "Version": "2012-10-17",
         "Statement": [
             {
@@ -43,21 +81,23 @@ func (p *ResourceProvisioner) createSNSTopic(ctx context.Context, topicName stri
                 "Resource": "%s"
             }
         ]
-    }`, *result.TopicArn)
+    }`, topicARN)
+}
 
-	_, err = p.snsClient.SetTopicAttributes(ctx, &sns.SetTopicAttributesInput{
-		TopicArn:       result.TopicArn,
+func (p *ResourceProvisioner) setTopicPolicy(ctx context.Context, topicARN string) error {
+	_, err := p.snsClient.SetTopicAttributes(ctx, &sns.SetTopicAttributesInput{
+		TopicArn:       aws.String(topicARN),
 		AttributeName:  aws.String("Policy"),
-		AttributeValue: aws.String(policy),
+		AttributeValue: aws.String(topicPolicy(topicARN)),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to set topic policy: %w", err)
+		return fmt.Errorf("failed to set topic policy: %w", err)
 	}
-
-	return *result.TopicArn, nil
+	return nil
 }
+
 func (p *ResourceProvisioner) deleteSNSTopic(ctx context.Context, topicARN string) error {
-	p.logger.Info(fmt.Sprintf("Deleting SNS topic: %s", topicARN))
+	p.log(ctx).Info("Deleting SNS topic")
 
 	_, err := p.snsClient.DeleteTopic(ctx, &sns.DeleteTopicInput{
 		TopicArn: aws.String(topicARN),
@@ -68,3 +108,56 @@ func (p *ResourceProvisioner) deleteSNSTopic(ctx context.Context, topicARN strin
 
 	return nil
 }
+
+func (p *ResourceProvisioner) describeSNSTopic(ctx context.Context, topicARN string) (*models.ResourceStatus, error) {
+	result, err := p.snsClient.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{
+		TopicArn: aws.String(topicARN),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return &models.ResourceStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to describe SNS topic: %w", err)
+	}
+
+	details := map[string]string{}
+	for _, attribute := range []string{"SubscriptionsConfirmed", "SubscriptionsPending"} {
+		if value, ok := result.Attributes[attribute]; ok {
+			details[attribute] = value
+		}
+	}
+	_, hasPolicy := result.Attributes["Policy"]
+	details["policy"] = strconv.FormatBool(hasPolicy)
+
+	return &models.ResourceStatus{
+		Exists:  true,
+		ARN:     topicARN,
+		Details: details,
+	}, nil
+}
+
+// planSNSTopic reports what ensureSNSTopic would do, without changing anything.
+func (p *ResourceProvisioner) planSNSTopic(ctx context.Context, topicName string) (*models.PlannedResource, error) {
+	topicARN := p.topicARN(topicName)
+	planned := &models.PlannedResource{
+		ARN:    topicARN,
+		Action: models.PlanActionNone,
+		Config: map[string]interface{}{
+			"policy": json.RawMessage(topicPolicy(topicARN)),
+		},
+	}
+
+	attributes, err := p.snsClient.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{
+		TopicArn: aws.String(topicARN),
+	})
+	switch {
+	case isNotFound(err):
+		planned.Action = models.PlanActionCreate
+	case err != nil:
+		return planned, fmt.Errorf("failed to check SNS topic: %w", err)
+	case !documentsEqual(attributes.Attributes["Policy"], topicPolicy(topicARN)):
+		planned.Action = models.PlanActionUpdate
+		planned.Changes = []string{"policy"}
+	}
+	return planned, nil
+}
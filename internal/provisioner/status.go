@@ -0,0 +1,83 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// DescribeClientResources looks up every resource of the client's tier, and
+// any it has left from an earlier tier, in the account it is recorded in and
+// in region, or else every region it is recorded in. It reports whether each
+// resource exists along with its key configuration. The overall status is
+// "provisioned" when everything exists, "partial" when only some resources
+// do, "not_found" when none do and "unknown" when a lookup failed.
+func (p *ResourceProvisioner) DescribeClientResources(ctx context.Context, clientID, region string) (_ *models.StatusResponse, err error) {
+	ctx, span := startSpan(ctx, "DescribeClientResources", clientID)
+	defer func() { endSpan(span, err) }()
+	p, record, err := p.forClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = p.withClient(ctx, clientID)
+
+	regions := p.clientRegions(record, region)
+	response := &models.StatusResponse{ClientID: clientID, AccountID: p.accountID, Regions: regions}
+	if record != nil {
+		response.Tier = record.Request.Tier
+	}
+
+	existing, failed := 0, 0
+	var firstErr error
+	var firstFailed *resourceNode
+	for _, region := range regions {
+		rp, regionCtx, err := p.inRegion(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		// Reading does not depend on the options resources were created with
+		nodes, err := rp.clientResources(clientID, DefaultOptions(), rp.recordedComponents(record, clientID))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, n := range nodes {
+			nodeCtx := rp.withNode(regionCtx, n)
+			status, err := n.read(nodeCtx)
+			if err != nil {
+				rp.log(nodeCtx).Error("Failed to describe resource", logger.Err(err))
+				status = &models.ResourceStatus{Error: classifyError(n.id, n.name, fmt.Sprintf("failed to describe %s %s", n.resourceType, n.name), err).ClientMessage()}
+				if failed == 0 {
+					firstErr, firstFailed = err, n
+				}
+				failed++
+			}
+			status.Region = region
+			status.Type = n.resourceType
+			status.Name = n.name
+			if status.Exists {
+				existing++
+			}
+
+			response.Resources = append(response.Resources, *status)
+		}
+	}
+
+	switch {
+	case failed > 0:
+		response.Status = "unknown"
+		// The error is classified by the first failure
+		message := fmt.Sprintf("failed to describe %d of %d resources for client %s", failed, len(response.Resources), clientID)
+		return response, classifyError(firstFailed.id, firstFailed.name, message, firstErr)
+	case existing == len(response.Resources):
+		response.Status = "provisioned"
+	case existing == 0:
+		response.Status = "not_found"
+	default:
+		response.Status = "partial"
+	}
+
+	return response, nil
+}
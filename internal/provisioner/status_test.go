@@ -0,0 +1,110 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+)
+
+func TestDescribeClientResources(t *testing.T) {
+	tests := []struct {
+		name       string
+		prepare    func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud)
+		wantStatus string
+		wantExist  int
+		wantErr    bool
+	}{
+		{
+			name:       "unknown client",
+			wantStatus: "not_found",
+		},
+		{
+			name: "provisioned client",
+			prepare: func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud) {
+				provisionRegions(t, p, "acme", cloud)
+			},
+			wantStatus: "provisioned",
+			wantExist:  len(builtinComponents),
+		},
+		{
+			name: "resource deleted out of band",
+			prepare: func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud) {
+				provisionRegions(t, p, "acme", cloud)
+				inRegion, ctx, err := p.inRegion(context.Background(), cloud.Region)
+				if err != nil {
+					t.Fatalf("inRegion() error = %v", err)
+				}
+				if err := inRegion.deleteAlarm(ctx, "acme-error-rate-alarm"); err != nil {
+					t.Fatalf("deleteAlarm() error = %v", err)
+				}
+			},
+			wantStatus: "partial",
+			wantExist:  len(builtinComponents) - 1,
+		},
+		{
+			name: "lookup fails",
+			prepare: func(t *testing.T, p *ResourceProvisioner, cloud *fake.Cloud) {
+				provisionRegions(t, p, "acme", cloud)
+				cloud.FailOn("lambda:GetFunction", errors.New("injected failure"))
+			},
+			wantStatus: "unknown",
+			wantExist:  len(builtinComponents) - 1,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloud(testAccount, "us-east-1")
+			p := newTestProvisioner(t, cloud)
+			if tt.prepare != nil {
+				tt.prepare(t, p, cloud)
+			}
+
+			resp, err := p.DescribeClientResources(context.Background(), "acme", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DescribeClientResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resp.Status != tt.wantStatus || len(resp.Resources) != len(builtinComponents) {
+				t.Errorf("status = %s with %d resources, want %s with %d", resp.Status, len(resp.Resources), tt.wantStatus, len(builtinComponents))
+			}
+			existing := 0
+			for _, status := range resp.Resources {
+				if status.Exists {
+					existing++
+					if status.ARN == "" {
+						t.Errorf("%s %s exists without an ARN", status.Type, status.Name)
+					}
+				}
+				if status.Region != "us-east-1" {
+					t.Errorf("%s %s is in %q, want us-east-1", status.Type, status.Name, status.Region)
+				}
+			}
+			if existing != tt.wantExist {
+				t.Errorf("%d resources exist, want %d", existing, tt.wantExist)
+			}
+		})
+	}
+}
+
+func TestDescribeClientRegions(t *testing.T) {
+	home := newTestCloud(testAccount, "us-east-1")
+	abroad := newTestCloud(testAccount, "eu-west-1")
+	p := newTestProvisioner(t, home, abroad)
+	provisionRegions(t, p, "acme", home, abroad)
+
+	for _, region := range []string{"", "eu-west-1"} {
+		resp, err := p.DescribeClientResources(context.Background(), "acme", region)
+		if err != nil {
+			t.Fatalf("DescribeClientResources(%q) error = %v", region, err)
+		}
+		want := 2 * len(builtinComponents)
+		if region != "" {
+			want = len(builtinComponents)
+		}
+		if resp.Status != "provisioned" || len(resp.Resources) != want {
+			t.Errorf("DescribeClientResources(%q) = %s with %d resources, want provisioned with %d", region, resp.Status, len(resp.Resources), want)
+		}
+	}
+}
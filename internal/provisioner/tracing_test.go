@@ -0,0 +1,75 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanRecorder records the spans of every test in the package. The tracer of
+// the package binds to the first provider set globally, so the provider is
+// set once rather than per test.
+var (
+	spanRecorder      = tracetest.NewSpanRecorder()
+	setTracerProvider sync.Once
+)
+
+// recordSpans returns a function listing the spans ended since it was called.
+func recordSpans() func() []sdktrace.ReadOnlySpan {
+	setTracerProvider.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	before := len(spanRecorder.Ended())
+	return func() []sdktrace.ReadOnlySpan { return spanRecorder.Ended()[before:] }
+}
+
+func TestProvisionTracesSteps(t *testing.T) {
+	ended := recordSpans()
+
+	cloud := newTestCloud(testAccount, "us-east-1")
+	// The role depends on the bucket, so the bucket is always created, and
+	// rolled back
+	cloud.FailOn("iam:CreateRole", errors.New("injected failure"))
+	p := newTestProvisioner(t, cloud)
+	if _, err := p.ProvisionClientResources(context.Background(), testRequest("acme")); err == nil {
+		t.Fatal("ProvisionClientResources() error = nil, want the injected failure")
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range ended() {
+		spans[span.Name()] = span
+	}
+	root, ok := spans["ProvisionClientResources"]
+	if !ok {
+		t.Fatal("no ProvisionClientResources span")
+	}
+	if root.Status().Code != codes.Error {
+		t.Errorf("ProvisionClientResources span status = %v, want an error", root.Status())
+	}
+
+	tests := []struct {
+		step       string
+		wantStatus codes.Code
+	}{
+		{"step " + nodeBucket, codes.Unset},
+		{"step " + nodeRole, codes.Error},
+		{"step rollback", codes.Unset},
+	}
+	for _, tt := range tests {
+		span, ok := spans[tt.step]
+		if !ok {
+			t.Errorf("no %q span", tt.step)
+			continue
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() || span.Status().Code != tt.wantStatus {
+			t.Errorf("%q span has parent %v and status %v, want a child of the run with status %v",
+				tt.step, span.Parent().SpanID(), span.Status(), tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package server builds the HTTP server from the service configuration: its
+// address, timeouts and, if a certificate is configured, TLS with optional
+// client certificate verification.
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// New returns a server for handler. Errors the server itself hits, such as
+// failed TLS handshakes, are logged as warnings.
+func New(cfg *config.Config, handler http.Handler, log *logger.Logger) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), logger.LevelWarn),
+	}
+	if cfg.TLSCertFile == "" {
+		return srv, nil
+	}
+
+	certs, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, log)
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in client CA file %s", cfg.TLSClientCAFile)
+		}
+		srv.TLSConfig.ClientCAs = pool
+		srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return srv, nil
+}
+
+// ListenAndServe serves HTTPS if srv was given a certificate and HTTP
+// otherwise. Like http.Server's, it returns http.ErrServerClosed after
+// Shutdown.
+func ListenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		// The certificate comes from TLSConfig.GetCertificate
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// testCert is a certificate and key for localhost, signed by its parent or,
+// without one, a self-signed CA.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// write writes the certificate and key to dir and returns their paths.
+func (c *testCert) write(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, c.certPEM)
+	writeFile(t, keyFile, c.keyPEM)
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func testLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.New("error", "text", io.Discard)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	return log
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test CA", nil)
+	certFile, keyFile := newTestCert(t, "localhost", ca).write(t, dir)
+	caFile, notPEM := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.txt")
+	writeFile(t, caFile, ca.certPEM)
+	writeFile(t, notPEM, []byte("not a certificate"))
+
+	tests := []struct {
+		name           string
+		cert, key, ca  string
+		wantTLS        bool
+		wantClientAuth tls.ClientAuthType
+		wantErr        string
+	}{
+		{name: "plain HTTP"},
+		{name: "TLS", cert: certFile, key: keyFile, wantTLS: true},
+		{name: "mutual TLS", cert: certFile, key: keyFile, ca: caFile, wantTLS: true, wantClientAuth: tls.RequireAndVerifyClientCert},
+		{name: "missing certificate", cert: filepath.Join(dir, "missing.crt"), key: keyFile, wantErr: "failed to stat TLS certificate"},
+		{name: "key not matching", cert: certFile, key: notPEM, wantErr: "failed to load TLS certificate"},
+		{name: "missing client CA", cert: certFile, key: keyFile, ca: filepath.Join(dir, "missing.crt"), wantErr: "failed to read client CA file"},
+		{name: "client CA without certificates", cert: certFile, key: keyFile, ca: notPEM, wantErr: "no PEM certificates found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.ListenAddr = "127.0.0.1:9443"
+			cfg.ReadHeaderTimeout = time.Second
+			cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile = tt.cert, tt.key, tt.ca
+
+			srv, err := New(cfg, http.NotFoundHandler(), testLogger(t))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("New() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if srv.Addr != cfg.ListenAddr || srv.ReadHeaderTimeout != cfg.ReadHeaderTimeout || srv.ReadTimeout != cfg.ReadTimeout ||
+				srv.WriteTimeout != cfg.WriteTimeout || srv.IdleTimeout != cfg.IdleTimeout {
+				t.Errorf("server = %+v, want the configured address and timeouts", srv)
+			}
+			if (srv.TLSConfig != nil) != tt.wantTLS {
+				t.Fatalf("TLSConfig = %v, want TLS %v", srv.TLSConfig, tt.wantTLS)
+			}
+			if tt.wantTLS && (srv.TLSConfig.MinVersion != tls.VersionTLS12 || srv.TLSConfig.ClientAuth != tt.wantClientAuth) {
+				t.Errorf("TLSConfig min version %x, client auth %v, want TLS 1.2 and %v",
+					srv.TLSConfig.MinVersion, srv.TLSConfig.ClientAuth, tt.wantClientAuth)
+			}
+		})
+	}
+}
+
+func TestServeMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test CA", nil)
+	certFile, keyFile := newTestCert(t, "localhost", ca).write(t, dir)
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, caFile, ca.certPEM)
+
+	cfg := config.Default()
+	cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile = certFile, keyFile, caFile
+	srv, err := New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}), testLogger(t))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.ServeTLS(ln, "", "") }()
+	defer func() {
+		srv.Close()
+		if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("ServeTLS() error = %v, want http.ErrServerClosed", err)
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := newTestCert(t, "ci", ca)
+	clientPair, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair() error = %v", err)
+	}
+	stranger := newTestCert(t, "stranger", newTestCert(t, "other CA", nil))
+	strangerPair, err := tls.X509KeyPair(stranger.certPEM, stranger.keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		certs    []tls.Certificate
+		wantBody string
+	}{
+		{name: "client certificate signed by the CA", certs: []tls.Certificate{clientPair}, wantBody: "ci"},
+		{name: "no client certificate"},
+		{name: "client certificate signed by another CA", certs: []tls.Certificate{strangerPair}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: tt.certs},
+			}}
+			defer c.CloseIdleConnections()
+
+			resp, err := c.Get("https://" + ln.Addr().String())
+			if tt.wantBody == "" {
+				if err == nil {
+					resp.Body.Close()
+					t.Error("request succeeded without a trusted client certificate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.wantBody {
+				t.Errorf("server saw client %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test CA", nil)
+	first, second := newTestCert(t, "first", ca), newTestCert(t, "second", ca)
+	certFile, keyFile := first.write(t, dir)
+
+	r, err := newCertReloader(certFile, keyFile, testLogger(t))
+	if err != nil {
+		t.Fatalf("newCertReloader() error = %v", err)
+	}
+	served := func() string {
+		t.Helper()
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetCertificate() error = %v", err)
+		}
+		return cert.Leaf.Subject.CommonName
+	}
+	// replace rewrites the files as modified later, so the change is seen
+	// regardless of the file system's timestamp resolution
+	mod := time.Now()
+	replace := func(certPEM, keyPEM []byte) {
+		t.Helper()
+		mod = mod.Add(time.Minute)
+		for path, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+			writeFile(t, path, data)
+			if err := os.Chtimes(path, mod, mod); err != nil {
+				t.Fatalf("Chtimes() error = %v", err)
+			}
+		}
+	}
+
+	if got := served(); got != "first" {
+		t.Fatalf("serving %q, want first", got)
+	}
+
+	replace(second.certPEM, second.keyPEM)
+	if got := served(); got != "first" {
+		t.Errorf("serving %q before the check interval passed, want first", got)
+	}
+	r.checked = time.Time{}
+	if got := served(); got != "second" {
+		t.Errorf("serving %q after rotation, want second", got)
+	}
+
+	// A certificate and key that do not match leave the previous one served
+	replace(first.certPEM, second.keyPEM)
+	r.checked = time.Time{}
+	if got := served(); got != "second" {
+		t.Errorf("serving %q after a broken rotation, want second", got)
+	}
+}
@@ -0,0 +1,144 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileStore keeps each record as a JSON document in a directory. Writes go to a
+// temporary file that is renamed into place, so a crash never leaves a
+// partially written record behind.
+type FileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path escapes the client ID so it is always a single file name inside dir.
+func (s *FileStore) path(clientID string) string {
+	return filepath.Join(s.dir, url.PathEscape(clientID)+".json")
+}
+
+func (s *FileStore) Get(ctx context.Context, clientID string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.read(s.path(clientID))
+}
+
+func (s *FileStore) read(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read state record: %w", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode state record %s: %w", filepath.Base(path), err)
+	}
+	return &record, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, record *Record) error {
+	if record.ClientID == "" {
+		return errors.New("state: record has no client_id")
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, ".record-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state record: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state record: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state record: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state record: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(record.ClientID)); err != nil {
+		return fmt.Errorf("failed to write state record: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(clientID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete state record: %w", err)
+	}
+	return nil
+}
+
+// List returns all records ordered by client ID.
+func (s *FileStore) List(ctx context.Context) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state records: %w", err)
+	}
+
+	var records []*Record
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		record, err := s.read(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].ClientID < records[j].ClientID })
+	return records, nil
+}
+
+// Ping creates and removes a temporary file in the state directory.
+func (s *FileStore) Ping(ctx context.Context) error {
+	tmp, err := os.CreateTemp(s.dir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("state directory is not writable: %w", err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
@@ -0,0 +1,85 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps records in memory. It is meant for tests and for running
+// the service without a state directory; nothing survives a restart. Records
+// are stored encoded so callers never share memory with the store.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, clientID string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.records[clientID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, record *Record) error {
+	if record.ClientID == "" {
+		return errors.New("state: record has no client_id")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ClientID] = data
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[clientID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records, clientID)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*Record, 0, len(s.records))
+	for _, data := range s.records {
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].ClientID < records[j].ClientID })
+	return records, nil
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
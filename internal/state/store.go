@@ -0,0 +1,126 @@
+// Package state persists what the provisioner has done for each client, so
+// that the outcome of a provisioning run outlives the HTTP request that
+// started it.
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+var ErrNotFound = errors.New("state: record not found")
+
+// Record statuses
+const (
+	StatusProvisioning   = "provisioning"
+	StatusProvisioned    = "provisioned"
+	StatusFailed         = "failed"
+	StatusDeprovisioning = "deprovisioning"
+	StatusDeprovisioned  = "deprovisioned"
+	// StatusInterrupted marks a run stopped by shutdown. The record keeps the
+	// resources it got to, and running it again carries on from there.
+	StatusInterrupted = "interrupted"
+)
+
+// Resource statuses
+const (
+	ResourceCreated    = "created"
+	ResourceRolledBack = "rolled_back"
+	ResourceDeleted    = "deleted"
+	ResourceFailed     = "failed"
+)
+
+// Store persists one Record per client.
+type Store interface {
+	// Get returns ErrNotFound if no record exists for the client.
+	Get(ctx context.Context, clientID string) (*Record, error)
+	Put(ctx context.Context, record *Record) error
+	Delete(ctx context.Context, clientID string) error
+	List(ctx context.Context) ([]*Record, error)
+	// Ping reports whether records can currently be written.
+	Ping(ctx context.Context) error
+}
+
+// New returns the store named by kind: "file" (the default) keeps records under
+// dir, "memory" keeps them for the lifetime of the process.
+func New(kind, dir string) (Store, error) {
+	switch kind {
+	case "", "file":
+		return NewFileStore(dir)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown state store %q", kind)
+	}
+}
+
+type Record struct {
+	ClientID    string                  `json:"client_id"`
+	ClientName  string                  `json:"client_name"`
+	Environment string                  `json:"environment"`
+	AccountID   string                  `json:"account_id,omitempty"`
+	Regions     []string                `json:"regions,omitempty"`
+	Status      string                  `json:"status"`
+	Request     models.ProvisionRequest `json:"request"`
+	Resources   []Resource              `json:"resources"`
+	Error       string                  `json:"error,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+type Resource struct {
+	Region    string    `json:"region,omitempty"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	ARN       string    `json:"arn,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AddRegions adds the regions not yet in the record's regions.
+func (r *Record) AddRegions(regions ...string) {
+	for _, region := range regions {
+		if !slices.Contains(r.Regions, region) {
+			r.Regions = append(r.Regions, region)
+		}
+	}
+}
+
+// RemoveRegion removes region from the record's regions.
+func (r *Record) RemoveRegion(region string) {
+	r.Regions = slices.DeleteFunc(r.Regions, func(s string) bool { return s == region })
+}
+
+// SetResource adds the resource to the record, replacing any existing entry
+// with the same region, type and name.
+func (r *Record) SetResource(res Resource) {
+	if res.UpdatedAt.IsZero() {
+		res.UpdatedAt = time.Now().UTC()
+	}
+	for i := range r.Resources {
+		if r.Resources[i].Region == res.Region && r.Resources[i].Type == res.Type && r.Resources[i].Name == res.Name {
+			if res.ARN == "" {
+				res.ARN = r.Resources[i].ARN
+			}
+			r.Resources[i] = res
+			return
+		}
+	}
+	r.Resources = append(r.Resources, res)
+}
+
+// Resource returns the entry for the given region, type and name.
+func (r *Record) Resource(region, resourceType, name string) (Resource, bool) {
+	for _, res := range r.Resources {
+		if res.Region == region && res.Type == resourceType && res.Name == name {
+			return res, true
+		}
+	}
+	return Resource{}, false
+}
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+)
+
+// stores returns a store of each kind, empty.
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	file, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	return map[string]Store{"file": file, "memory": NewMemoryStore()}
+}
+
+func TestStore(t *testing.T) {
+	for kind, store := range stores(t) {
+		t.Run(kind, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := store.Get(ctx, "acme"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a missing record: error = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, "acme"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete() of a missing record: error = %v, want ErrNotFound", err)
+			}
+			if err := store.Put(ctx, &Record{}); err == nil {
+				t.Error("Put() of a record without client_id succeeded")
+			}
+
+			record := &Record{
+				ClientID: "acme",
+				Status:   StatusProvisioned,
+				Regions:  []string{"us-east-1"},
+				Request:  models.ProvisionRequest{ClientID: "acme", Tier: "standard"},
+			}
+			record.SetResource(Resource{Region: "us-east-1", Type: "s3_bucket", Name: "dev-acme-bucket", ARN: "arn:aws:s3:::dev-acme-bucket", Status: ResourceCreated})
+			for _, r := range []*Record{record, {ClientID: "beta"}, {ClientID: "a/../b"}} {
+				if err := store.Put(ctx, r); err != nil {
+					t.Fatalf("Put(%s) error = %v", r.ClientID, err)
+				}
+			}
+
+			got, err := store.Get(ctx, "acme")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.Status != StatusProvisioned || got.Request.Tier != "standard" || len(got.Resources) != 1 || got.Resources[0].ARN == "" {
+				t.Errorf("Get() = %+v, want the record put", got)
+			}
+			// Records are copies, so changing one does not change the store
+			got.Status = StatusFailed
+			if again, _ := store.Get(ctx, "acme"); again.Status != StatusProvisioned {
+				t.Errorf("record changed in the store to %s", again.Status)
+			}
+
+			list, err := store.List(ctx)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var ids []string
+			for _, r := range list {
+				ids = append(ids, r.ClientID)
+			}
+			if want := []string{"a/../b", "acme", "beta"}; !slices.Equal(ids, want) {
+				t.Errorf("List() = %v, want %v", ids, want)
+			}
+
+			if err := store.Delete(ctx, "acme"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Get(ctx, "acme"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() after Delete(): error = %v, want ErrNotFound", err)
+			}
+			if err := store.Ping(ctx); err != nil {
+				t.Errorf("Ping() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStoreSurvivesReopening(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if err := store.Put(ctx, &Record{ClientID: "acme", Status: StatusProvisioned}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	// Leftover temporary files and unrelated entries are not records
+	if err := os.WriteFile(filepath.Join(dir, ".record-123"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	list, err := reopened.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].ClientID != "acme" || list[0].Status != StatusProvisioned {
+		t.Errorf("List() = %+v, want the one record", list)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		kind    string
+		wantErr bool
+	}{
+		{"", false},
+		{"file", false},
+		{"memory", false},
+		{"dynamodb", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			_, err := New(tt.kind, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New(%q) error = %v, wantErr %v", tt.kind, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordResources(t *testing.T) {
+	var r Record
+	r.AddRegions("us-east-1", "eu-west-1", "us-east-1")
+	if want := []string{"us-east-1", "eu-west-1"}; !slices.Equal(r.Regions, want) {
+		t.Errorf("Regions = %v, want %v", r.Regions, want)
+	}
+	r.RemoveRegion("us-east-1")
+	if want := []string{"eu-west-1"}; !slices.Equal(r.Regions, want) {
+		t.Errorf("Regions = %v, want %v", r.Regions, want)
+	}
+
+	r.SetResource(Resource{Region: "eu-west-1", Type: "iam_role", Name: "r", ARN: "arn:role", Status: ResourceCreated})
+	// An update without an ARN keeps the one recorded
+	r.SetResource(Resource{Region: "eu-west-1", Type: "iam_role", Name: "r", Status: ResourceDeleted})
+	r.SetResource(Resource{Region: "us-east-1", Type: "iam_role", Name: "r", Status: ResourceCreated})
+
+	if len(r.Resources) != 2 {
+		t.Fatalf("got %d resources, want 2", len(r.Resources))
+	}
+	res, ok := r.Resource("eu-west-1", "iam_role", "r")
+	if !ok || res.Status != ResourceDeleted || res.ARN != "arn:role" || res.UpdatedAt.IsZero() {
+		t.Errorf("Resource() = %+v, %v", res, ok)
+	}
+	if _, ok := r.Resource("ap-south-1", "iam_role", "r"); ok {
+		t.Error("Resource() found a resource in another region")
+	}
+}
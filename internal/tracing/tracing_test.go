@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		kind    string
+		wantErr bool
+	}{
+		{"none", false},
+		{"stdout", false},
+		{"jaeger", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.kind, "", "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup(%q) error = %v, wantErr %v", tt.kind, err, tt.wantErr)
+			}
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	shutdown, err := Setup(context.Background(), "file", path, "test")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "provision acme")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{`"Name":"provision acme"`, ServiceName, "test"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("trace file does not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package awsclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Call is an AWS API operation that was made.
+type Call struct {
+	Service   string // IAM action prefix, such as "iam" or "logs"
+	Operation string
+	RequestID string
+	Err       error
+	Duration  time.Duration // including retries
+}
+
+// Mutating reports whether the operation can change AWS resources, judging by
+// its name.
+func (c Call) Mutating() bool {
+	for _, prefix := range []string{"Get", "Describe", "List", "Head"} {
+		if strings.HasPrefix(c.Operation, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// CallRecorder is told about every AWS operation made with a context that
+// carries it, or made by a client it was given to.
+type CallRecorder func(Call)
+
+type callRecorderKey struct{}
+
+// WithCallRecorder returns a copy of ctx that reports the AWS operations made
+// with it to rec.
+func WithCallRecorder(ctx context.Context, rec CallRecorder) context.Context {
+	return context.WithValue(ctx, callRecorderKey{}, rec)
+}
+
+// RecordCall reports call to the recorder in ctx, if there is one. Clients
+// built by NewAWSClient do this for every operation.
+func RecordCall(ctx context.Context, call Call) {
+	if rec, ok := ctx.Value(callRecorderKey{}).(CallRecorder); ok {
+		rec(call)
+	}
+}
+
+// servicePrefixes maps SDK service IDs to IAM action prefixes.
+var servicePrefixes = map[string]string{
+	"CloudWatch Logs": "logs",
+	"EventBridge":     "events",
+}
+
+// callRecorder returns the middleware that reports each operation, once it
+// has finished including any retries, to the recorder in its context and to
+// recorders.
+func callRecorder(recorders []CallRecorder) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return addCallRecorder(stack, recorders)
+	}
+}
+
+func addCallRecorder(stack *middleware.Stack, recorders []CallRecorder) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CallRecorder",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, metadata, err := next.HandleInitialize(ctx, in)
+
+			service := awsmiddleware.GetServiceID(ctx)
+			if prefix, ok := servicePrefixes[service]; ok {
+				service = prefix
+			} else {
+				service = strings.ToLower(service)
+			}
+			call := Call{
+				Service:   service,
+				Operation: awsmiddleware.GetOperationName(ctx),
+				RequestID: requestID(metadata, err),
+				Err:       err,
+				Duration:  time.Since(start),
+			}
+			RecordCall(ctx, call)
+			for _, rec := range recorders {
+				rec(call)
+			}
+
+			return out, metadata, err
+		}), middleware.After)
+}
+
+// requestID returns the AWS request ID of an operation, from its response
+// metadata or, if it failed before that was set, from its error.
+func requestID(metadata middleware.Metadata, err error) string {
+	if id, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok && id != "" {
+		return id
+	}
+	var requestErr interface{ ServiceRequestID() string }
+	if errors.As(err, &requestErr) {
+		return requestErr.ServiceRequestID()
+	}
+	return ""
+}
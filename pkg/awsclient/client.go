@@ -3,7 +3,9 @@ package awsclient
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs" // Fixed this import
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
@@ -11,31 +13,125 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 type AWSClient struct {
-	S3Client             *s3.Client
-	IAMClient            *iam.Client
-	CloudWatchClient     *cloudwatch.Client
-	CloudWatchLogsClient *cloudwatchlogs.Client
-	EventBridgeClient    *eventbridge.Client
-	LambdaClient         *lambda.Client
-	SNSClient            *sns.Client
+	S3Client             S3API
+	IAMClient            IAMAPI
+	CloudWatchClient     CloudWatchAPI
+	CloudWatchLogsClient CloudWatchLogsAPI
+	EventBridgeClient    EventBridgeAPI
+	LambdaClient         LambdaAPI
+	SNSClient            SNSAPI
+	STSClient            STSAPI
 }
 
-func NewAWSClient(ctx context.Context) (*AWSClient, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+// Options changes where the service clients connect and as whom, e.g. to
+// run against LocalStack. The zero value uses the default AWS configuration.
+type Options struct {
+	// Region overrides the region of the default configuration.
+	Region string
+
+	// EndpointURL replaces the endpoint of every service, and Endpoints,
+	// written as described at ParseEndpoints, that of single services.
+	EndpointURL string
+	Endpoints   string
+
+	// S3UsePathStyle addresses buckets as http://host/bucket instead of
+	// http://bucket.host, which emulators without wildcard DNS need.
+	S3UsePathStyle bool
+
+	// AccessKeyID, SecretAccessKey and, for temporary credentials,
+	// SessionToken are used instead of the default credential chain when set.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Credentials, when set, is used instead of the static or default
+	// credentials, e.g. to act in another account through an assumed role.
+	Credentials aws.CredentialsProvider
+}
+
+// NewAWSClient builds the service clients from the default AWS configuration
+// and opts. Every operation they make is traced and reported to recorders,
+// e.g. for metrics.
+func NewAWSClient(ctx context.Context, opts Options, recorders ...CallRecorder) (*AWSClient, error) {
+	endpoints, err := ParseEndpoints(opts.Endpoints)
 	if err != nil {
 		return nil, err
 	}
 
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	switch {
+	case opts.Credentials != nil:
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(opts.Credentials))
+	case opts.AccessKeyID != "":
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, opts.SessionToken)))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.EndpointURL != "" {
+		cfg.BaseEndpoint = aws.String(opts.EndpointURL)
+	}
+	cfg.APIOptions = append(cfg.APIOptions, addTracing, callRecorder(recorders))
+
+	// endpoint is the override of service, or nil to keep the endpoint
+	// resolved from cfg
+	endpoint := func(service string) *string {
+		if url, ok := endpoints[service]; ok {
+			return aws.String(url)
+		}
+		return nil
+	}
+
 	return &AWSClient{
-		S3Client:             s3.NewFromConfig(cfg),
-		IAMClient:            iam.NewFromConfig(cfg),
-		CloudWatchClient:     cloudwatch.NewFromConfig(cfg),
-		CloudWatchLogsClient: cloudwatchlogs.NewFromConfig(cfg),
-		EventBridgeClient:    eventbridge.NewFromConfig(cfg),
-		LambdaClient:         lambda.NewFromConfig(cfg),
-		SNSClient:            sns.NewFromConfig(cfg),
+		S3Client: s3.NewFromConfig(cfg, func(o *s3.Options) {
+			if url := endpoint("s3"); url != nil {
+				o.BaseEndpoint = url
+			}
+			o.UsePathStyle = opts.S3UsePathStyle
+		}),
+		IAMClient: iam.NewFromConfig(cfg, func(o *iam.Options) {
+			if url := endpoint("iam"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
+		CloudWatchClient: cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
+			if url := endpoint("cloudwatch"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
+		CloudWatchLogsClient: cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			if url := endpoint("cloudwatch_logs"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
+		EventBridgeClient: eventbridge.NewFromConfig(cfg, func(o *eventbridge.Options) {
+			if url := endpoint("eventbridge"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
+		LambdaClient: lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+			if url := endpoint("lambda"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
+		SNSClient: sns.NewFromConfig(cfg, func(o *sns.Options) {
+			if url := endpoint("sns"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
+		STSClient: sts.NewFromConfig(cfg, func(o *sts.Options) {
+			if url := endpoint("sts"); url != nil {
+				o.BaseEndpoint = url
+			}
+		}),
 	}, nil
 }
@@ -0,0 +1,106 @@
+package awsclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// emulator stands in for an AWS emulator, recording the requests it gets.
+type emulator struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newEmulator(t *testing.T) *emulator {
+	t.Helper()
+	e := &emulator{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		e.requests = append(e.requests, r)
+		e.mu.Unlock()
+		if strings.Contains(r.Header.Get("Authorization"), "/sts/") {
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(`<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">` +
+				`<GetCallerIdentityResult><Arn>arn:aws:iam::000000000000:root</Arn><UserId>000000000000</UserId>` +
+				`<Account>000000000000</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`))
+		}
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+// services returns the service each request was signed for, in order.
+func (e *emulator) services() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var services []string
+	for _, r := range e.requests {
+		// Credential=<key>/<date>/<region>/<service>/aws4_request
+		scope := strings.Split(r.Header.Get("Authorization"), "/")
+		if len(scope) > 3 {
+			services = append(services, scope[3])
+		}
+	}
+	return services
+}
+
+func TestNewAWSClientEndpoints(t *testing.T) {
+	// Keep the developer's AWS configuration out of the test
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+
+	everything, stsOnly := newEmulator(t), newEmulator(t)
+	client, err := NewAWSClient(context.Background(), Options{
+		Region:          "eu-west-1",
+		EndpointURL:     everything.URL,
+		Endpoints:       "sts=" + stsOnly.URL,
+		S3UsePathStyle:  true,
+		AccessKeyID:     "AKIDTEST",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewAWSClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.S3Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String("dev-acme-bucket")}); err != nil {
+		t.Fatalf("HeadBucket() error = %v", err)
+	}
+	if _, err := client.STSClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("GetCallerIdentity() error = %v", err)
+	}
+
+	if got := everything.services(); len(got) != 1 || got[0] != "s3" {
+		t.Errorf("common endpoint got requests for %v, want s3", got)
+	}
+	if got := stsOnly.services(); len(got) != 1 || got[0] != "sts" {
+		t.Errorf("STS endpoint got requests for %v, want sts", got)
+	}
+	r := everything.requests[0]
+	if r.URL.Path != "/dev-acme-bucket" {
+		t.Errorf("S3 request path = %q, want the bucket in the path", r.URL.Path)
+	}
+	if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKIDTEST/") || !strings.Contains(auth, "/eu-west-1/") {
+		t.Errorf("Authorization = %q, want it signed with the static key in eu-west-1", auth)
+	}
+}
+
+func TestNewAWSClientRejectsEndpoints(t *testing.T) {
+	_, err := NewAWSClient(context.Background(), Options{Endpoints: "s3=localhost"})
+	if err == nil || !strings.Contains(err.Error(), "endpoint of s3") {
+		t.Errorf("NewAWSClient() error = %v, want the endpoint rejected", err)
+	}
+}
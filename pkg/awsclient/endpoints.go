@@ -0,0 +1,66 @@
+package awsclient
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// services are the names endpoint overrides can be given for. They match the
+// SDK's AWS_ENDPOINT_URL_<SERVICE> variables, lowercased.
+var services = map[string]bool{
+	"s3":              true,
+	"iam":             true,
+	"cloudwatch":      true,
+	"cloudwatch_logs": true,
+	"eventbridge":     true,
+	"lambda":          true,
+	"sns":             true,
+	"sts":             true,
+}
+
+// ParseEndpoints parses a comma-separated list of per-service endpoint
+// overrides, each written as service=url, for example
+//
+//	s3=http://localhost:4566,iam=http://localhost:4593
+func ParseEndpoints(spec string) (map[string]string, error) {
+	endpoints := map[string]string{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		service, endpoint, ok := strings.Cut(entry, "=")
+		if !ok || service == "" || endpoint == "" {
+			return nil, fmt.Errorf("awsclient: endpoint entry %q must be service=url", entry)
+		}
+		if !services[service] {
+			return nil, fmt.Errorf("awsclient: unknown service %q in endpoint overrides, expected one of %s", service, serviceNames())
+		}
+		if err := ValidateEndpoint(endpoint); err != nil {
+			return nil, fmt.Errorf("awsclient: endpoint of %s: %w", service, err)
+		}
+		endpoints[service] = endpoint
+	}
+	return endpoints, nil
+}
+
+// ValidateEndpoint checks that endpoint is an absolute http or https URL.
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q must be an http or https URL", endpoint)
+	}
+	return nil
+}
+
+func serviceNames() string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
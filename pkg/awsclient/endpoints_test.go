@@ -0,0 +1,63 @@
+package awsclient
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "empty", spec: "", want: map[string]string{}},
+		{
+			name: "several services",
+			spec: "s3=http://localhost:4566, cloudwatch_logs=https://logs.example.com:8443,",
+			want: map[string]string{"s3": "http://localhost:4566", "cloudwatch_logs": "https://logs.example.com:8443"},
+		},
+		{name: "missing url", spec: "s3=", wantErr: "must be service=url"},
+		{name: "missing separator", spec: "http://localhost:4566", wantErr: "must be service=url"},
+		{name: "unknown service", spec: "dynamodb=http://localhost:4566", wantErr: `unknown service "dynamodb"`},
+		{name: "not a url", spec: "s3=localhost:4566", wantErr: "endpoint of s3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEndpoints(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseEndpoints(%q) error = %v, want one containing %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEndpoints(%q) error = %v", tt.spec, err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("ParseEndpoints(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{"http://localhost:4566", false},
+		{"https://s3.eu-west-1.amazonaws.com", false},
+		{"localhost:4566", true},
+		{"ftp://localhost", true},
+		{"http://", true},
+		{"://bad", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateEndpoint(tt.endpoint); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateEndpoint(%q) error = %v, want error %v", tt.endpoint, err, tt.wantErr)
+		}
+	}
+}
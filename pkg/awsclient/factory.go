@@ -0,0 +1,51 @@
+package awsclient
+
+import (
+	"context"
+	"sync"
+)
+
+// Factory builds the service clients of each region on first use and keeps
+// them for later use.
+type Factory struct {
+	newClient func(ctx context.Context, region string) (*AWSClient, error)
+
+	mu      sync.Mutex
+	clients map[string]*AWSClient
+}
+
+// NewFactory returns a Factory building clients like NewAWSClient, from opts
+// with the region replaced.
+func NewFactory(opts Options, recorders ...CallRecorder) *Factory {
+	return NewFactoryFunc(func(ctx context.Context, region string) (*AWSClient, error) {
+		regionOpts := opts
+		regionOpts.Region = region
+		return NewAWSClient(ctx, regionOpts, recorders...)
+	})
+}
+
+// NewFactoryFunc returns a Factory building clients with newClient, e.g. to
+// serve fakes.
+func NewFactoryFunc(newClient func(ctx context.Context, region string) (*AWSClient, error)) *Factory {
+	return &Factory{
+		newClient: newClient,
+		clients:   make(map[string]*AWSClient),
+	}
+}
+
+// Client returns the clients of region, building them if this is the first
+// time they are asked for.
+func (f *Factory) Client(ctx context.Context, region string) (*AWSClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if client, ok := f.clients[region]; ok {
+		return client, nil
+	}
+	client, err := f.newClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	f.clients[region] = client
+	return client, nil
+}
@@ -0,0 +1,47 @@
+package awsclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFactory(t *testing.T) {
+	built := map[string]int{}
+	factory := NewFactoryFunc(func(ctx context.Context, region string) (*AWSClient, error) {
+		built[region]++
+		if region == "mars-1" {
+			return nil, errors.New("no such region")
+		}
+		return &AWSClient{}, nil
+	})
+
+	ctx := context.Background()
+	clients := map[string]*AWSClient{}
+	for _, region := range []string{"us-east-1", "eu-west-1", "us-east-1", "eu-west-1"} {
+		client, err := factory.Client(ctx, region)
+		if err != nil {
+			t.Fatalf("Client(%s) error = %v", region, err)
+		}
+		if previous, ok := clients[region]; ok && previous != client {
+			t.Errorf("Client(%s) built new clients, want the ones built before", region)
+		}
+		clients[region] = client
+	}
+	if clients["us-east-1"] == clients["eu-west-1"] {
+		t.Error("regions share their clients")
+	}
+
+	// A region that failed is tried again
+	for range 2 {
+		if _, err := factory.Client(ctx, "mars-1"); err == nil {
+			t.Error("Client(mars-1) error = nil")
+		}
+	}
+	want := map[string]int{"us-east-1": 1, "eu-west-1": 1, "mars-1": 2}
+	for region, n := range want {
+		if built[region] != n {
+			t.Errorf("clients of %s built %d times, want %d", region, built[region], n)
+		}
+	}
+}
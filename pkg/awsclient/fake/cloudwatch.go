@@ -0,0 +1,253 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+type Alarm struct {
+	Name               string
+	ARN                string
+	Description        string
+	MetricName         string
+	Namespace          string
+	Statistic          types.Statistic
+	Period             int32
+	EvaluationPeriods  int32
+	Threshold          float64
+	ComparisonOperator types.ComparisonOperator
+	AlarmActions       []string
+	Dimensions         map[string]string
+	State              types.StateValue
+}
+
+type Dashboard struct {
+	Name string
+	ARN  string
+	Body string
+}
+
+type CloudWatch struct {
+	cloud *Cloud
+
+	mu         sync.Mutex
+	alarms     map[string]*Alarm
+	dashboards map[string]*Dashboard
+}
+
+func newCloudWatch(cloud *Cloud) *CloudWatch {
+	return &CloudWatch{cloud: cloud, alarms: make(map[string]*Alarm), dashboards: make(map[string]*Dashboard)}
+}
+
+// Alarm returns a copy of the named alarm.
+func (f *CloudWatch) Alarm(name string) (Alarm, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, ok := f.alarms[name]
+	if !ok {
+		return Alarm{}, false
+	}
+	out := *a
+	out.AlarmActions = append([]string(nil), a.AlarmActions...)
+	out.Dimensions = make(map[string]string, len(a.Dimensions))
+	for k, v := range a.Dimensions {
+		out.Dimensions[k] = v
+	}
+	return out, true
+}
+
+func (f *CloudWatch) PutMetricAlarm(ctx context.Context, params *cloudwatch.PutMetricAlarmInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	if err := f.cloud.call(ctx, "cloudwatch:PutMetricAlarm"); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.AlarmName)
+	if name == "" {
+		return nil, apiError("MissingParameter", "The parameter AlarmName is required.")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// PutMetricAlarm creates or fully replaces the alarm; new alarms start
+	// with insufficient data.
+	state := types.StateValueInsufficientData
+	if existing, ok := f.alarms[name]; ok {
+		state = existing.State
+	}
+
+	a := &Alarm{
+		Name:               name,
+		ARN:                arn("cloudwatch", f.cloud.Region, f.cloud.AccountID, "alarm:"+name),
+		Description:        aws.ToString(params.AlarmDescription),
+		MetricName:         aws.ToString(params.MetricName),
+		Namespace:          aws.ToString(params.Namespace),
+		Statistic:          params.Statistic,
+		Period:             aws.ToInt32(params.Period),
+		EvaluationPeriods:  aws.ToInt32(params.EvaluationPeriods),
+		Threshold:          aws.ToFloat64(params.Threshold),
+		ComparisonOperator: params.ComparisonOperator,
+		AlarmActions:       append([]string(nil), params.AlarmActions...),
+		Dimensions:         make(map[string]string),
+		State:              state,
+	}
+	for _, d := range params.Dimensions {
+		a.Dimensions[aws.ToString(d.Name)] = aws.ToString(d.Value)
+	}
+	f.alarms[name] = a
+
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (f *CloudWatch) DeleteAlarms(ctx context.Context, params *cloudwatch.DeleteAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	if err := f.cloud.call(ctx, "cloudwatch:DeleteAlarms"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, name := range params.AlarmNames {
+		if _, ok := f.alarms[name]; !ok {
+			return nil, &types.ResourceNotFound{Message: aws.String(fmt.Sprintf("Alarm %s does not exist", name))}
+		}
+	}
+	for _, name := range params.AlarmNames {
+		delete(f.alarms, name)
+	}
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+// Dashboard returns a copy of the named dashboard.
+func (f *CloudWatch) Dashboard(name string) (Dashboard, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, ok := f.dashboards[name]
+	if !ok {
+		return Dashboard{}, false
+	}
+	return *d, true
+}
+
+// PutDashboard creates or replaces the dashboard. Like CloudWatch it rejects
+// a body that is not a JSON object.
+func (f *CloudWatch) PutDashboard(ctx context.Context, params *cloudwatch.PutDashboardInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutDashboardOutput, error) {
+	if err := f.cloud.call(ctx, "cloudwatch:PutDashboard"); err != nil {
+		return nil, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(aws.ToString(params.DashboardBody)), &body); err != nil {
+		return nil, &types.DashboardInvalidInputError{Message: aws.String("The dashboard body is invalid")}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.DashboardName)
+	f.dashboards[name] = &Dashboard{
+		Name: name,
+		// Dashboards are global, so their ARNs have no region
+		ARN:  arn("cloudwatch", "", f.cloud.AccountID, "dashboard/"+name),
+		Body: aws.ToString(params.DashboardBody),
+	}
+	return &cloudwatch.PutDashboardOutput{}, nil
+}
+
+func (f *CloudWatch) GetDashboard(ctx context.Context, params *cloudwatch.GetDashboardInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetDashboardOutput, error) {
+	if err := f.cloud.call(ctx, "cloudwatch:GetDashboard"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, ok := f.dashboards[aws.ToString(params.DashboardName)]
+	if !ok {
+		return nil, &types.DashboardNotFoundError{Message: aws.String("Dashboard does not exist")}
+	}
+	return &cloudwatch.GetDashboardOutput{
+		DashboardName: aws.String(d.Name),
+		DashboardArn:  aws.String(d.ARN),
+		DashboardBody: aws.String(d.Body),
+	}, nil
+}
+
+func (f *CloudWatch) DeleteDashboards(ctx context.Context, params *cloudwatch.DeleteDashboardsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DeleteDashboardsOutput, error) {
+	if err := f.cloud.call(ctx, "cloudwatch:DeleteDashboards"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, name := range params.DashboardNames {
+		if _, ok := f.dashboards[name]; !ok {
+			return nil, &types.DashboardNotFoundError{Message: aws.String(fmt.Sprintf("Dashboard %s does not exist", name))}
+		}
+	}
+	for _, name := range params.DashboardNames {
+		delete(f.dashboards, name)
+	}
+	return &cloudwatch.DeleteDashboardsOutput{}, nil
+}
+
+// DescribeAlarms supports filtering by AlarmNames and AlarmNamePrefix and
+// returns every match in a single page.
+func (f *CloudWatch) DescribeAlarms(ctx context.Context, params *cloudwatch.DescribeAlarmsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	if err := f.cloud.call(ctx, "cloudwatch:DescribeAlarms"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	if len(params.AlarmNames) > 0 {
+		names = params.AlarmNames
+	} else {
+		for name := range f.alarms {
+			if strings.HasPrefix(name, aws.ToString(params.AlarmNamePrefix)) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	out := &cloudwatch.DescribeAlarmsOutput{}
+	for _, name := range names {
+		a, ok := f.alarms[name]
+		if !ok {
+			continue
+		}
+		alarm := types.MetricAlarm{
+			AlarmName:          aws.String(a.Name),
+			AlarmArn:           aws.String(a.ARN),
+			AlarmDescription:   aws.String(a.Description),
+			MetricName:         aws.String(a.MetricName),
+			Namespace:          aws.String(a.Namespace),
+			Statistic:          a.Statistic,
+			Period:             aws.Int32(a.Period),
+			EvaluationPeriods:  aws.Int32(a.EvaluationPeriods),
+			Threshold:          aws.Float64(a.Threshold),
+			ComparisonOperator: a.ComparisonOperator,
+			AlarmActions:       append([]string(nil), a.AlarmActions...),
+			StateValue:         a.State,
+		}
+		for k, v := range a.Dimensions {
+			alarm.Dimensions = append(alarm.Dimensions, types.Dimension{Name: aws.String(k), Value: aws.String(v)})
+		}
+		out.MetricAlarms = append(out.MetricAlarms, alarm)
+	}
+	return out, nil
+}
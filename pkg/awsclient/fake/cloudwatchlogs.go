@@ -0,0 +1,167 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+type LogGroup struct {
+	Name            string
+	ARN             string
+	RetentionInDays int32
+	Tags            map[string]string
+}
+
+type CloudWatchLogs struct {
+	cloud *Cloud
+
+	mu        sync.Mutex
+	logGroups map[string]*LogGroup
+}
+
+func newCloudWatchLogs(cloud *Cloud) *CloudWatchLogs {
+	return &CloudWatchLogs{cloud: cloud, logGroups: make(map[string]*LogGroup)}
+}
+
+// LogGroup returns a copy of the named log group.
+func (f *CloudWatchLogs) LogGroup(name string) (LogGroup, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lg, ok := f.logGroups[name]
+	if !ok {
+		return LogGroup{}, false
+	}
+	out := *lg
+	out.Tags = make(map[string]string, len(lg.Tags))
+	for k, v := range lg.Tags {
+		out.Tags[k] = v
+	}
+	return out, true
+}
+
+func (f *CloudWatchLogs) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	if err := f.cloud.call(ctx, "logs:CreateLogGroup"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := f.logGroups[name]; ok {
+		return nil, &types.ResourceAlreadyExistsException{Message: aws.String("The specified log group already exists")}
+	}
+
+	lg := &LogGroup{
+		Name: name,
+		ARN:  arn("logs", f.cloud.Region, f.cloud.AccountID, fmt.Sprintf("log-group:%s:*", name)),
+		Tags: make(map[string]string),
+	}
+	for k, v := range params.Tags {
+		lg.Tags[k] = v
+	}
+	f.logGroups[name] = lg
+
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (f *CloudWatchLogs) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	if err := f.cloud.call(ctx, "logs:DeleteLogGroup"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.LogGroupName)
+	if _, ok := f.logGroups[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+	delete(f.logGroups, name)
+	return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
+}
+
+func (f *CloudWatchLogs) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	if err := f.cloud.call(ctx, "logs:PutRetentionPolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lg, ok := f.logGroups[aws.ToString(params.LogGroupName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+	lg.RetentionInDays = aws.ToInt32(params.RetentionInDays)
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+func (f *CloudWatchLogs) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+	if err := f.cloud.call(ctx, "logs:DeleteRetentionPolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lg, ok := f.logGroups[aws.ToString(params.LogGroupName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")}
+	}
+	lg.RetentionInDays = 0
+	return &cloudwatchlogs.DeleteRetentionPolicyOutput{}, nil
+}
+
+// DescribeLogGroups supports LogGroupNamePrefix and returns the matches in
+// name order, in pages of Limit groups (50 by default) chained by NextToken.
+func (f *CloudWatchLogs) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	if err := f.cloud.call(ctx, "logs:DescribeLogGroups"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for name := range f.logGroups {
+		if strings.HasPrefix(name, aws.ToString(params.LogGroupNamePrefix)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// The token is the name of the first group of the next page
+	if token := aws.ToString(params.NextToken); token != "" {
+		names = names[sort.SearchStrings(names, token):]
+	}
+	limit := int(aws.ToInt32(params.Limit))
+	if limit <= 0 {
+		limit = 50
+	}
+	out := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	if len(names) > limit {
+		out.NextToken = aws.String(names[limit])
+		names = names[:limit]
+	}
+	for _, name := range names {
+		lg := f.logGroups[name]
+		group := types.LogGroup{
+			LogGroupName: aws.String(lg.Name),
+			Arn:          aws.String(lg.ARN),
+		}
+		if lg.RetentionInDays > 0 {
+			group.RetentionInDays = aws.Int32(lg.RetentionInDays)
+		}
+		out.LogGroups = append(out.LogGroups, group)
+	}
+	return out, nil
+}
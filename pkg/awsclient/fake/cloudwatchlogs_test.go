@@ -0,0 +1,63 @@
+package fake_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+func TestDescribeLogGroupsPages(t *testing.T) {
+	ctx := context.Background()
+	cloud := fake.New("123456789012", "us-east-1")
+	var want []string
+	for i := range 7 {
+		name := fmt.Sprintf("/aws/client/dev/c%d", i)
+		if _, err := cloud.CloudWatchLogs.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(name)}); err != nil {
+			t.Fatalf("CreateLogGroup(%s) error = %v", name, err)
+		}
+		want = append(want, name)
+	}
+	if _, err := cloud.CloudWatchLogs.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String("/aws/other")}); err != nil {
+		t.Fatalf("CreateLogGroup() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		limit     int32
+		wantPages int
+	}{
+		{"default limit", 0, 1},
+		{"exact pages", 7, 1},
+		{"several pages", 3, 3},
+		{"one per page", 1, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String("/aws/client/dev/")}
+			if tt.limit > 0 {
+				input.Limit = aws.Int32(tt.limit)
+			}
+			pages := cloudwatchlogs.NewDescribeLogGroupsPaginator(cloud.CloudWatchLogs, input)
+			var got []string
+			n := 0
+			for pages.HasMorePages() {
+				page, err := pages.NextPage(ctx)
+				if err != nil {
+					t.Fatalf("NextPage() error = %v", err)
+				}
+				n++
+				for _, group := range page.LogGroups {
+					got = append(got, aws.ToString(group.LogGroupName))
+				}
+			}
+			if !slices.Equal(got, want) || n != tt.wantPages {
+				t.Errorf("got %v in %d pages, want %v in %d pages", got, n, want, tt.wantPages)
+			}
+		})
+	}
+}
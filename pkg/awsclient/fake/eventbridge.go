@@ -0,0 +1,229 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+type Rule struct {
+	Name         string
+	ARN          string
+	Description  string
+	EventPattern string
+	State        types.RuleState
+	Targets      map[string]string // target ID -> ARN
+}
+
+type EventBridge struct {
+	cloud *Cloud
+
+	mu    sync.Mutex
+	rules map[string]*Rule
+	// rejectCode, if set, is the error code PutTargets reports every target
+	// as failed with
+	rejectCode string
+}
+
+func newEventBridge(cloud *Cloud) *EventBridge {
+	return &EventBridge{cloud: cloud, rules: make(map[string]*Rule)}
+}
+
+// Rule returns a copy of the named rule.
+func (f *EventBridge) Rule(name string) (Rule, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, ok := f.rules[name]
+	if !ok {
+		return Rule{}, false
+	}
+	out := *r
+	out.Targets = make(map[string]string, len(r.Targets))
+	for k, v := range r.Targets {
+		out.Targets[k] = v
+	}
+	return out, true
+}
+
+// RejectTargets makes PutTargets report every target as a failed entry with
+// code, as EventBridge does for targets it cannot add, until it is called
+// again with "".
+func (f *EventBridge) RejectTargets(code string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejectCode = code
+}
+
+func ruleNotFound(name string) error {
+	return &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Rule %s does not exist on EventBus default.", name))}
+}
+
+func (f *EventBridge) PutRule(ctx context.Context, params *eventbridge.PutRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutRuleOutput, error) {
+	if err := f.cloud.call(ctx, "eventbridge:PutRule"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Name)
+	r, ok := f.rules[name]
+	if !ok {
+		r = &Rule{
+			Name:    name,
+			ARN:     arn("events", f.cloud.Region, f.cloud.AccountID, "rule/"+name),
+			Targets: make(map[string]string),
+		}
+		f.rules[name] = r
+	}
+	r.Description = aws.ToString(params.Description)
+	r.EventPattern = aws.ToString(params.EventPattern)
+	r.State = params.State
+	if r.State == "" {
+		r.State = types.RuleStateEnabled
+	}
+
+	return &eventbridge.PutRuleOutput{RuleArn: aws.String(r.ARN)}, nil
+}
+
+func (f *EventBridge) PutTargets(ctx context.Context, params *eventbridge.PutTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutTargetsOutput, error) {
+	if err := f.cloud.call(ctx, "eventbridge:PutTargets"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Rule)
+	r, ok := f.rules[name]
+	if !ok {
+		return nil, ruleNotFound(name)
+	}
+	if f.rejectCode != "" {
+		out := &eventbridge.PutTargetsOutput{FailedEntryCount: int32(len(params.Targets))}
+		for _, t := range params.Targets {
+			out.FailedEntries = append(out.FailedEntries, types.PutTargetsResultEntry{
+				TargetId:     t.Id,
+				ErrorCode:    aws.String(f.rejectCode),
+				ErrorMessage: aws.String("The target was rejected."),
+			})
+		}
+		return out, nil
+	}
+	for _, t := range params.Targets {
+		r.Targets[aws.ToString(t.Id)] = aws.ToString(t.Arn)
+	}
+	return &eventbridge.PutTargetsOutput{}, nil
+}
+
+func (f *EventBridge) DeleteRule(ctx context.Context, params *eventbridge.DeleteRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.DeleteRuleOutput, error) {
+	if err := f.cloud.call(ctx, "eventbridge:DeleteRule"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Deleting a rule that does not exist succeeds, as it does in EventBridge.
+	name := aws.ToString(params.Name)
+	r, ok := f.rules[name]
+	if !ok {
+		return &eventbridge.DeleteRuleOutput{}, nil
+	}
+	if len(r.Targets) > 0 {
+		return nil, apiError("ValidationException", "Rule can't be deleted since it has targets.")
+	}
+	delete(f.rules, name)
+	return &eventbridge.DeleteRuleOutput{}, nil
+}
+
+func (f *EventBridge) ListTargetsByRule(ctx context.Context, params *eventbridge.ListTargetsByRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTargetsByRuleOutput, error) {
+	if err := f.cloud.call(ctx, "eventbridge:ListTargetsByRule"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Rule)
+	r, ok := f.rules[name]
+	if !ok {
+		return nil, ruleNotFound(name)
+	}
+
+	out := &eventbridge.ListTargetsByRuleOutput{}
+	for id, targetARN := range r.Targets {
+		out.Targets = append(out.Targets, types.Target{Id: aws.String(id), Arn: aws.String(targetARN)})
+	}
+	return out, nil
+}
+
+func (f *EventBridge) RemoveTargets(ctx context.Context, params *eventbridge.RemoveTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.RemoveTargetsOutput, error) {
+	if err := f.cloud.call(ctx, "eventbridge:RemoveTargets"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Rule)
+	r, ok := f.rules[name]
+	if !ok {
+		return nil, ruleNotFound(name)
+	}
+	for _, id := range params.Ids {
+		delete(r.Targets, id)
+	}
+	return &eventbridge.RemoveTargetsOutput{FailedEntryCount: 0}, nil
+}
+
+func (f *EventBridge) DescribeRule(ctx context.Context, params *eventbridge.DescribeRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.DescribeRuleOutput, error) {
+	if err := f.cloud.call(ctx, "eventbridge:DescribeRule"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Name)
+	r, ok := f.rules[name]
+	if !ok {
+		return nil, ruleNotFound(name)
+	}
+	return &eventbridge.DescribeRuleOutput{
+		Name:         aws.String(r.Name),
+		Arn:          aws.String(r.ARN),
+		Description:  aws.String(r.Description),
+		EventPattern: aws.String(r.EventPattern),
+		EventBusName: aws.String("default"),
+		State:        r.State,
+	}, nil
+}
+
+func (f *EventBridge) ListRules(ctx context.Context, params *eventbridge.ListRulesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListRulesOutput, error) {
+	if err := f.cloud.call(ctx, "events:ListRules"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.rules))
+	for name := range f.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := &eventbridge.ListRulesOutput{}
+	for _, name := range names {
+		r := f.rules[name]
+		out.Rules = append(out.Rules, types.Rule{Name: aws.String(r.Name), Arn: aws.String(r.ARN), State: r.State})
+	}
+	return out, nil
+}
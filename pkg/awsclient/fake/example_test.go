@@ -0,0 +1,42 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/arkishshah/go-infra-provisioner/internal/audit"
+	"github.com/arkishshah/go-infra-provisioner/internal/config"
+	"github.com/arkishshah/go-infra-provisioner/internal/models"
+	"github.com/arkishshah/go-infra-provisioner/internal/provisioner"
+	"github.com/arkishshah/go-infra-provisioner/internal/state"
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/arkishshah/go-infra-provisioner/pkg/logger"
+)
+
+// A failure injected into one call rolls back what the run created, and
+// provisioning again once it clears creates everything.
+func Example() {
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.AWSAccountID = "123456789012"
+	log, _ := logger.New("error", "text", io.Discard)
+
+	cloud := fake.New("123456789012", "us-east-1")
+	cloud.IAM.AddPolicy("go-infra-policy")
+	p := provisioner.NewResourceProvisioner(cfg, fake.NewFactory(cloud), nil, nil, state.NewMemoryStore(), audit.NewMemoryLog(), nil, log)
+	req := &models.ProvisionRequest{ClientID: "acme", ClientName: "Acme"}
+
+	cloud.FailOn("lambda:CreateFunction", errors.New("boom"))
+	_, err := p.ProvisionClientResources(ctx, req)
+	_, bucketExists := cloud.S3.Bucket("dev-acme-bucket")
+	fmt.Println(err != nil, bucketExists)
+
+	cloud.ClearFailures()
+	resp, err := p.ProvisionClientResources(ctx, req)
+	fmt.Println(err, resp.Status, resp.BucketName)
+	// Output:
+	// true false
+	// <nil> success dev-acme-bucket
+}
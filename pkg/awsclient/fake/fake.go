@@ -0,0 +1,154 @@
+// Package fake provides stateful in-memory implementations of the AWS service
+// interfaces in pkg/awsclient. The fakes keep enough state to emulate the
+// AlreadyExists/NotFound/conflict errors the real services return, so
+// provisioning and rollback paths can be exercised without an AWS account:
+//
+//	cloud := fake.New("123456789012", "us-east-1")
+//	cloud.IAM.AddPolicy("go-infra-policy")
+//	p := provisioner.NewResourceProvisioner(cfg, fake.NewFactory(cloud), nil, nil, state.NewMemoryStore(), audit.NewMemoryLog(), nil, log)
+//
+//	cloud.FailOn("lambda:CreateFunction", errors.New("boom"))
+//	_, err := p.ProvisionClientResources(ctx, req)
+//	// cloud.S3.Bucket(...) / cloud.IAM.Role(...) now report what was rolled back.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient"
+	"github.com/aws/smithy-go"
+)
+
+// Cloud groups the fake services of a single account and region and records
+// every call made against them.
+type Cloud struct {
+	AccountID string
+	Region    string
+
+	S3             *S3
+	IAM            *IAM
+	CloudWatch     *CloudWatch
+	CloudWatchLogs *CloudWatchLogs
+	EventBridge    *EventBridge
+	Lambda         *Lambda
+	SNS            *SNS
+	STS            *STS
+
+	mu        sync.Mutex
+	calls     []string
+	failures  map[string]error
+	requests  int
+	recorders []awsclient.CallRecorder
+}
+
+func New(accountID, region string) *Cloud {
+	c := &Cloud{
+		AccountID: accountID,
+		Region:    region,
+		failures:  make(map[string]error),
+	}
+
+	c.S3 = newS3(c)
+	c.IAM = newIAM(c)
+	c.CloudWatch = newCloudWatch(c)
+	c.CloudWatchLogs = newCloudWatchLogs(c)
+	c.EventBridge = newEventBridge(c)
+	c.Lambda = newLambda(c)
+	c.SNS = newSNS(c)
+	c.STS = newSTS(c)
+
+	return c
+}
+
+// Client returns an AWSClient backed by the fake services.
+func (c *Cloud) Client() *awsclient.AWSClient {
+	return &awsclient.AWSClient{
+		S3Client:             c.S3,
+		IAMClient:            c.IAM,
+		CloudWatchClient:     c.CloudWatch,
+		CloudWatchLogsClient: c.CloudWatchLogs,
+		EventBridgeClient:    c.EventBridge,
+		LambdaClient:         c.Lambda,
+		SNSClient:            c.SNS,
+		STSClient:            c.STS,
+	}
+}
+
+// NewFactory returns a Factory serving the clients of each cloud in its
+// region, e.g. the clouds of one account in several regions. Other regions
+// fail to connect.
+func NewFactory(clouds ...*Cloud) *awsclient.Factory {
+	return awsclient.NewFactoryFunc(func(ctx context.Context, region string) (*awsclient.AWSClient, error) {
+		for _, c := range clouds {
+			if c.Region == region {
+				return c.Client(), nil
+			}
+		}
+		return nil, fmt.Errorf("fake: no cloud in region %s", region)
+	})
+}
+
+// AddCallRecorder reports every call to rec, like the recorders given to
+// awsclient.NewAWSClient.
+func (c *Cloud) AddCallRecorder(rec awsclient.CallRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorders = append(c.recorders, rec)
+}
+
+// FailOn makes every call to operation return err until ClearFailures is
+// called. Operations are named "<service>:<Operation>", e.g. "iam:CreateRole".
+func (c *Cloud) FailOn(operation string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[operation] = err
+}
+
+func (c *Cloud) ClearFailures() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = make(map[string]error)
+}
+
+// Calls returns the operations invoked so far, in order.
+func (c *Cloud) Calls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+// call records operation and returns the failure injected for it, if any.
+// The call is also reported to the context's awsclient.CallRecorder, with the
+// injected failure as its outcome.
+func (c *Cloud) call(ctx context.Context, operation string) error {
+	c.mu.Lock()
+	c.calls = append(c.calls, operation)
+	err := c.failures[operation]
+	c.requests++
+	requestID := fmt.Sprintf("fake-%08d", c.requests)
+	recorders := c.recorders
+	c.mu.Unlock()
+
+	service, op, _ := strings.Cut(operation, ":")
+	call := awsclient.Call{Service: service, Operation: op, RequestID: requestID, Err: err}
+	awsclient.RecordCall(ctx, call)
+	for _, rec := range recorders {
+		rec(call)
+	}
+	return err
+}
+
+func apiError(code, format string, args ...interface{}) error {
+	return &smithy.GenericAPIError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Fault:   smithy.FaultClient,
+	}
+}
+
+func arn(service, region, accountID, resource string) string {
+	return fmt.Sprintf("arn:aws:%s:%s:%s:%s", service, region, accountID, resource)
+}
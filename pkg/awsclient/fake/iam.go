@@ -0,0 +1,384 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+type Role struct {
+	Name                     string
+	ARN                      string
+	AssumeRolePolicyDocument string
+	Description              string
+	Tags                     map[string]string
+	AttachedPolicies         []string
+	InlinePolicies           map[string]string
+	CreatedAt                time.Time
+}
+
+type IAM struct {
+	cloud *Cloud
+
+	mu       sync.Mutex
+	roles    map[string]*Role
+	policies map[string]bool
+}
+
+func newIAM(cloud *Cloud) *IAM {
+	return &IAM{
+		cloud:    cloud,
+		roles:    make(map[string]*Role),
+		policies: make(map[string]bool),
+	}
+}
+
+// AddPolicy registers a customer managed policy in the account and returns its
+// ARN. AWS managed policies (arn:aws:iam::aws:policy/...) always exist.
+func (f *IAM) AddPolicy(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policyARN := fmt.Sprintf("arn:aws:iam::%s:policy/%s", f.cloud.AccountID, name)
+	f.policies[policyARN] = true
+	return policyARN
+}
+
+// Role returns a copy of the named role.
+func (f *IAM) Role(name string) (Role, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, ok := f.roles[name]
+	if !ok {
+		return Role{}, false
+	}
+	return copyRole(r), true
+}
+
+// roleByARN is used by the Lambda fake to check the execution role exists.
+func (f *IAM) roleByARN(roleARN string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range f.roles {
+		if r.ARN == roleARN {
+			return true
+		}
+	}
+	return false
+}
+
+func copyRole(r *Role) Role {
+	out := *r
+	out.AttachedPolicies = append([]string(nil), r.AttachedPolicies...)
+	out.Tags = make(map[string]string, len(r.Tags))
+	for k, v := range r.Tags {
+		out.Tags[k] = v
+	}
+	out.InlinePolicies = make(map[string]string, len(r.InlinePolicies))
+	for k, v := range r.InlinePolicies {
+		out.InlinePolicies[k] = v
+	}
+	return out
+}
+
+func (f *IAM) policyExists(policyARN string) bool {
+	return strings.HasPrefix(policyARN, "arn:aws:iam::aws:policy/") || f.policies[policyARN]
+}
+
+func noSuchRole(name string) error {
+	return &types.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role with name %s cannot be found.", name))}
+}
+
+func (f *IAM) CreateRole(ctx context.Context, params *iam.CreateRoleInput, optFns ...func(*iam.Options)) (*iam.CreateRoleOutput, error) {
+	if err := f.cloud.call(ctx, "iam:CreateRole"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	if _, ok := f.roles[name]; ok {
+		return nil, &types.EntityAlreadyExistsException{Message: aws.String(fmt.Sprintf("Role with name %s already exists.", name))}
+	}
+
+	r := &Role{
+		Name:                     name,
+		ARN:                      fmt.Sprintf("arn:aws:iam::%s:role/%s", f.cloud.AccountID, name),
+		AssumeRolePolicyDocument: aws.ToString(params.AssumeRolePolicyDocument),
+		Description:              aws.ToString(params.Description),
+		Tags:                     make(map[string]string),
+		InlinePolicies:           make(map[string]string),
+		CreatedAt:                time.Now(),
+	}
+	for _, t := range params.Tags {
+		r.Tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	f.roles[name] = r
+
+	return &iam.CreateRoleOutput{Role: &types.Role{
+		RoleName:                 aws.String(r.Name),
+		Arn:                      aws.String(r.ARN),
+		AssumeRolePolicyDocument: aws.String(r.AssumeRolePolicyDocument),
+		Description:              aws.String(r.Description),
+		CreateDate:               aws.Time(r.CreatedAt),
+		Path:                     aws.String("/"),
+		RoleId:                   aws.String("AROA" + strings.ToUpper(name)),
+	}}, nil
+}
+
+func (f *IAM) AttachRolePolicy(ctx context.Context, params *iam.AttachRolePolicyInput, optFns ...func(*iam.Options)) (*iam.AttachRolePolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:AttachRolePolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	policyARN := aws.ToString(params.PolicyArn)
+	if !f.policyExists(policyARN) {
+		return nil, &types.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Policy %s does not exist or is not attachable.", policyARN))}
+	}
+	for _, attached := range r.AttachedPolicies {
+		if attached == policyARN {
+			return &iam.AttachRolePolicyOutput{}, nil
+		}
+	}
+	r.AttachedPolicies = append(r.AttachedPolicies, policyARN)
+	sort.Strings(r.AttachedPolicies)
+	return &iam.AttachRolePolicyOutput{}, nil
+}
+
+func (f *IAM) DetachRolePolicy(ctx context.Context, params *iam.DetachRolePolicyInput, optFns ...func(*iam.Options)) (*iam.DetachRolePolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:DetachRolePolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	policyARN := aws.ToString(params.PolicyArn)
+	for i, attached := range r.AttachedPolicies {
+		if attached == policyARN {
+			r.AttachedPolicies = append(r.AttachedPolicies[:i], r.AttachedPolicies[i+1:]...)
+			return &iam.DetachRolePolicyOutput{}, nil
+		}
+	}
+	return nil, &types.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Policy %s was not found.", policyARN))}
+}
+
+func (f *IAM) PutRolePolicy(ctx context.Context, params *iam.PutRolePolicyInput, optFns ...func(*iam.Options)) (*iam.PutRolePolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:PutRolePolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	r.InlinePolicies[aws.ToString(params.PolicyName)] = aws.ToString(params.PolicyDocument)
+	return &iam.PutRolePolicyOutput{}, nil
+}
+
+func (f *IAM) DeleteRolePolicy(ctx context.Context, params *iam.DeleteRolePolicyInput, optFns ...func(*iam.Options)) (*iam.DeleteRolePolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:DeleteRolePolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	policyName := aws.ToString(params.PolicyName)
+	if _, ok := r.InlinePolicies[policyName]; !ok {
+		return nil, &types.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role policy with name %s cannot be found.", policyName))}
+	}
+	delete(r.InlinePolicies, policyName)
+	return &iam.DeleteRolePolicyOutput{}, nil
+}
+
+func (f *IAM) DeleteRole(ctx context.Context, params *iam.DeleteRoleInput, optFns ...func(*iam.Options)) (*iam.DeleteRoleOutput, error) {
+	if err := f.cloud.call(ctx, "iam:DeleteRole"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	if len(r.AttachedPolicies) > 0 || len(r.InlinePolicies) > 0 {
+		return nil, &types.DeleteConflictException{Message: aws.String("Cannot delete entity, must detach all policies first.")}
+	}
+	delete(f.roles, name)
+	return &iam.DeleteRoleOutput{}, nil
+}
+
+// ListAttachedRolePolicies returns all attached policies in a single page.
+func (f *IAM) ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+	if err := f.cloud.call(ctx, "iam:ListAttachedRolePolicies"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+
+	out := &iam.ListAttachedRolePoliciesOutput{}
+	for _, policyARN := range r.AttachedPolicies {
+		out.AttachedPolicies = append(out.AttachedPolicies, types.AttachedPolicy{
+			PolicyArn:  aws.String(policyARN),
+			PolicyName: aws.String(policyARN[strings.LastIndex(policyARN, "/")+1:]),
+		})
+	}
+	return out, nil
+}
+
+// ListRolePolicies returns all inline policy names in a single page.
+func (f *IAM) ListRolePolicies(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error) {
+	if err := f.cloud.call(ctx, "iam:ListRolePolicies"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+
+	out := &iam.ListRolePoliciesOutput{}
+	for policyName := range r.InlinePolicies {
+		out.PolicyNames = append(out.PolicyNames, policyName)
+	}
+	sort.Strings(out.PolicyNames)
+	return out, nil
+}
+
+func (f *IAM) GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	if err := f.cloud.call(ctx, "iam:GetRole"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+
+	role := &types.Role{
+		RoleName:                 aws.String(r.Name),
+		Arn:                      aws.String(r.ARN),
+		AssumeRolePolicyDocument: aws.String(url.QueryEscape(r.AssumeRolePolicyDocument)),
+		Description:              aws.String(r.Description),
+		CreateDate:               aws.Time(r.CreatedAt),
+		Path:                     aws.String("/"),
+		RoleId:                   aws.String("AROA" + strings.ToUpper(name)),
+	}
+	for k, v := range r.Tags {
+		role.Tags = append(role.Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return &iam.GetRoleOutput{Role: role}, nil
+}
+
+// GetRolePolicy returns the policy document URL-encoded, as IAM does.
+func (f *IAM) GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:GetRolePolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	policyName := aws.ToString(params.PolicyName)
+	document, ok := r.InlinePolicies[policyName]
+	if !ok {
+		return nil, &types.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role policy with name %s cannot be found.", policyName))}
+	}
+	return &iam.GetRolePolicyOutput{
+		RoleName:       aws.String(name),
+		PolicyName:     aws.String(policyName),
+		PolicyDocument: aws.String(url.QueryEscape(document)),
+	}, nil
+}
+
+func (f *IAM) UpdateAssumeRolePolicy(ctx context.Context, params *iam.UpdateAssumeRolePolicyInput, optFns ...func(*iam.Options)) (*iam.UpdateAssumeRolePolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:UpdateAssumeRolePolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.RoleName)
+	r, ok := f.roles[name]
+	if !ok {
+		return nil, noSuchRole(name)
+	}
+	r.AssumeRolePolicyDocument = aws.ToString(params.PolicyDocument)
+	return &iam.UpdateAssumeRolePolicyOutput{}, nil
+}
+
+func (f *IAM) GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error) {
+	if err := f.cloud.call(ctx, "iam:GetPolicy"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policyARN := aws.ToString(params.PolicyArn)
+	if !f.policyExists(policyARN) {
+		return nil, &types.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Policy %s was not found.", policyARN))}
+	}
+	name := policyARN[strings.LastIndex(policyARN, "/")+1:]
+	return &iam.GetPolicyOutput{
+		Policy: &types.Policy{PolicyName: aws.String(name), Arn: aws.String(policyARN)},
+	}, nil
+}
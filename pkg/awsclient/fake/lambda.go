@@ -0,0 +1,281 @@
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+type Function struct {
+	Name        string
+	ARN         string
+	Role        string
+	Handler     string
+	Runtime     types.Runtime
+	Timeout     int32
+	MemorySize  int32
+	Environment map[string]string
+	ZipFile     []byte
+}
+
+type Lambda struct {
+	cloud *Cloud
+
+	mu        sync.Mutex
+	functions map[string]*Function
+	// roleDelay is how many more CreateFunction calls reject their role as
+	// not yet assumable
+	roleDelay int
+}
+
+func newLambda(cloud *Cloud) *Lambda {
+	return &Lambda{cloud: cloud, functions: make(map[string]*Function)}
+}
+
+// Function returns a copy of the named function.
+func (f *Lambda) Function(name string) (Function, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fn, ok := f.functions[name]
+	if !ok {
+		return Function{}, false
+	}
+	out := *fn
+	out.Environment = make(map[string]string, len(fn.Environment))
+	for k, v := range fn.Environment {
+		out.Environment[k] = v
+	}
+	return out, true
+}
+
+// DelayRoleAssumption makes the next n CreateFunction calls reject their
+// execution role as not assumable, the way Lambda does while a new role is
+// still propagating through IAM.
+func (f *Lambda) DelayRoleAssumption(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.roleDelay = n
+}
+
+// codeSha256 is how Lambda reports a deployment package's hash.
+func codeSha256(zipFile []byte) string {
+	sum := sha256.Sum256(zipFile)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func functionNotFound(name string, cloud *Cloud) error {
+	return &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Function not found: %s", arn("lambda", cloud.Region, cloud.AccountID, "function:"+name)))}
+}
+
+func roleNotAssumable() error {
+	return &types.InvalidParameterValueException{Message: aws.String("The role defined for the function cannot be assumed by Lambda.")}
+}
+
+func (f *Lambda) CreateFunction(ctx context.Context, params *lambda.CreateFunctionInput, optFns ...func(*lambda.Options)) (*lambda.CreateFunctionOutput, error) {
+	if err := f.cloud.call(ctx, "lambda:CreateFunction"); err != nil {
+		return nil, err
+	}
+
+	// The execution role must exist before Lambda will accept it.
+	role := aws.ToString(params.Role)
+	if !f.cloud.IAM.roleByARN(role) {
+		return nil, roleNotAssumable()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.roleDelay > 0 {
+		f.roleDelay--
+		return nil, roleNotAssumable()
+	}
+
+	name := aws.ToString(params.FunctionName)
+	if _, ok := f.functions[name]; ok {
+		return nil, &types.ResourceConflictException{Message: aws.String(fmt.Sprintf("Function already exist: %s", name))}
+	}
+
+	fn := &Function{
+		Name:        name,
+		ARN:         arn("lambda", f.cloud.Region, f.cloud.AccountID, "function:"+name),
+		Role:        role,
+		Handler:     aws.ToString(params.Handler),
+		Runtime:     params.Runtime,
+		Timeout:     aws.ToInt32(params.Timeout),
+		MemorySize:  aws.ToInt32(params.MemorySize),
+		Environment: make(map[string]string),
+	}
+	if params.Code != nil {
+		fn.ZipFile = params.Code.ZipFile
+	}
+	if params.Environment != nil {
+		for k, v := range params.Environment.Variables {
+			fn.Environment[k] = v
+		}
+	}
+	f.functions[name] = fn
+
+	return &lambda.CreateFunctionOutput{
+		FunctionName: aws.String(fn.Name),
+		FunctionArn:  aws.String(fn.ARN),
+		Role:         aws.String(fn.Role),
+		Handler:      aws.String(fn.Handler),
+		Runtime:      fn.Runtime,
+		Timeout:      aws.Int32(fn.Timeout),
+		MemorySize:   aws.Int32(fn.MemorySize),
+		CodeSha256:   aws.String(codeSha256(fn.ZipFile)),
+		State:        types.StateActive,
+	}, nil
+}
+
+func (f *Lambda) DeleteFunction(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
+	if err := f.cloud.call(ctx, "lambda:DeleteFunction"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	if _, ok := f.functions[name]; !ok {
+		return nil, functionNotFound(name, f.cloud)
+	}
+	delete(f.functions, name)
+	return &lambda.DeleteFunctionOutput{}, nil
+}
+
+func (f *Lambda) GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+	if err := f.cloud.call(ctx, "lambda:GetFunction"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	fn, ok := f.functions[name]
+	if !ok {
+		return nil, functionNotFound(name, f.cloud)
+	}
+
+	env := make(map[string]string, len(fn.Environment))
+	for k, v := range fn.Environment {
+		env[k] = v
+	}
+	return &lambda.GetFunctionOutput{
+		Configuration: &types.FunctionConfiguration{
+			FunctionName:     aws.String(fn.Name),
+			FunctionArn:      aws.String(fn.ARN),
+			Role:             aws.String(fn.Role),
+			Handler:          aws.String(fn.Handler),
+			Runtime:          fn.Runtime,
+			Timeout:          aws.Int32(fn.Timeout),
+			MemorySize:       aws.Int32(fn.MemorySize),
+			Environment:      &types.EnvironmentResponse{Variables: env},
+			CodeSha256:       aws.String(codeSha256(fn.ZipFile)),
+			State:            types.StateActive,
+			LastUpdateStatus: types.LastUpdateStatusSuccessful,
+		},
+	}, nil
+}
+
+// UpdateFunctionConfiguration changes only the settings present in params.
+// Updates apply immediately, so the function is never left InProgress.
+func (f *Lambda) UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+	if err := f.cloud.call(ctx, "lambda:UpdateFunctionConfiguration"); err != nil {
+		return nil, err
+	}
+
+	if params.Role != nil && !f.cloud.IAM.roleByARN(aws.ToString(params.Role)) {
+		return nil, &types.InvalidParameterValueException{Message: aws.String("The role defined for the function cannot be assumed by Lambda.")}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	fn, ok := f.functions[name]
+	if !ok {
+		return nil, functionNotFound(name, f.cloud)
+	}
+	if params.Role != nil {
+		fn.Role = aws.ToString(params.Role)
+	}
+	if params.Handler != nil {
+		fn.Handler = aws.ToString(params.Handler)
+	}
+	if params.Runtime != "" {
+		fn.Runtime = params.Runtime
+	}
+	if params.Timeout != nil {
+		fn.Timeout = aws.ToInt32(params.Timeout)
+	}
+	if params.MemorySize != nil {
+		fn.MemorySize = aws.ToInt32(params.MemorySize)
+	}
+	if params.Environment != nil {
+		fn.Environment = make(map[string]string, len(params.Environment.Variables))
+		for k, v := range params.Environment.Variables {
+			fn.Environment[k] = v
+		}
+	}
+
+	return &lambda.UpdateFunctionConfigurationOutput{
+		FunctionName:     aws.String(fn.Name),
+		FunctionArn:      aws.String(fn.ARN),
+		LastUpdateStatus: types.LastUpdateStatusSuccessful,
+	}, nil
+}
+
+func (f *Lambda) UpdateFunctionCode(ctx context.Context, params *lambda.UpdateFunctionCodeInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionCodeOutput, error) {
+	if err := f.cloud.call(ctx, "lambda:UpdateFunctionCode"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	fn, ok := f.functions[name]
+	if !ok {
+		return nil, functionNotFound(name, f.cloud)
+	}
+	fn.ZipFile = params.ZipFile
+
+	return &lambda.UpdateFunctionCodeOutput{
+		FunctionName:     aws.String(fn.Name),
+		FunctionArn:      aws.String(fn.ARN),
+		CodeSha256:       aws.String(codeSha256(fn.ZipFile)),
+		LastUpdateStatus: types.LastUpdateStatusSuccessful,
+	}, nil
+}
+
+func (f *Lambda) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	if err := f.cloud.call(ctx, "lambda:ListFunctions"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.functions))
+	for name := range f.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := &lambda.ListFunctionsOutput{}
+	for _, name := range names {
+		fn := f.functions[name]
+		out.Functions = append(out.Functions, types.FunctionConfiguration{FunctionName: aws.String(fn.Name), FunctionArn: aws.String(fn.ARN)})
+	}
+	return out, nil
+}
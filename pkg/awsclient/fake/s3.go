@@ -0,0 +1,274 @@
+package fake
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type Bucket struct {
+	Name       string
+	Region     string
+	Versioning types.BucketVersioningStatus
+	Lifecycle  []types.LifecycleRule
+	Objects    map[string][]byte
+}
+
+type S3 struct {
+	cloud *Cloud
+
+	mu      sync.Mutex
+	buckets map[string]*Bucket
+}
+
+func newS3(cloud *Cloud) *S3 {
+	return &S3{cloud: cloud, buckets: make(map[string]*Bucket)}
+}
+
+// Bucket returns a copy of the named bucket.
+func (f *S3) Bucket(name string) (Bucket, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[name]
+	if !ok {
+		return Bucket{}, false
+	}
+	out := *b
+	out.Lifecycle = append([]types.LifecycleRule(nil), b.Lifecycle...)
+	out.Objects = make(map[string][]byte, len(b.Objects))
+	for k, v := range b.Objects {
+		out.Objects[k] = v
+	}
+	return out, true
+}
+
+// PutObject seeds an object into an existing bucket.
+func (f *S3) PutObject(bucket, key string, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if b, ok := f.buckets[bucket]; ok {
+		b.Objects[key] = body
+	}
+}
+
+func (f *S3) CreateBucket(ctx context.Context, params *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+	if err := f.cloud.call(ctx, "s3:CreateBucket"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Bucket)
+	if _, ok := f.buckets[name]; ok {
+		return nil, &types.BucketAlreadyOwnedByYou{Message: aws.String("Your previous request to create the named bucket succeeded and you already own it.")}
+	}
+
+	region := "us-east-1"
+	if params.CreateBucketConfiguration != nil && params.CreateBucketConfiguration.LocationConstraint != "" {
+		region = string(params.CreateBucketConfiguration.LocationConstraint)
+	}
+	if region != f.cloud.Region {
+		return nil, apiError("IllegalLocationConstraintException", "The %s location constraint is incompatible for the region specific endpoint this request was sent to.", region)
+	}
+
+	f.buckets[name] = &Bucket{
+		Name:    name,
+		Region:  region,
+		Objects: make(map[string][]byte),
+	}
+	return &s3.CreateBucketOutput{Location: aws.String("/" + name)}, nil
+}
+
+func (f *S3) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
+	if err := f.cloud.call(ctx, "s3:PutBucketVersioning"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+	if params.VersioningConfiguration != nil {
+		b.Versioning = params.VersioningConfiguration.Status
+	}
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+func (f *S3) PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	if err := f.cloud.call(ctx, "s3:PutBucketLifecycleConfiguration"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+	if params.LifecycleConfiguration != nil {
+		// Like S3, reject rules that say neither which objects they cover
+		for _, rule := range params.LifecycleConfiguration.Rules {
+			if rule.Filter == nil && rule.Prefix == nil {
+				return nil, apiError("MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema")
+			}
+		}
+	}
+	b.Lifecycle = nil
+	if params.LifecycleConfiguration != nil {
+		b.Lifecycle = append(b.Lifecycle, params.LifecycleConfiguration.Rules...)
+	}
+	return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+}
+
+func (f *S3) DeleteBucket(ctx context.Context, params *s3.DeleteBucketInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketOutput, error) {
+	if err := f.cloud.call(ctx, "s3:DeleteBucket"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Bucket)
+	b, ok := f.buckets[name]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+	if len(b.Objects) > 0 {
+		return nil, apiError("BucketNotEmpty", "The bucket you tried to delete is not empty")
+	}
+	delete(f.buckets, name)
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+// ListObjectVersions reports every object as a single "null" version and
+// returns all of them in one page.
+func (f *S3) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	if err := f.cloud.call(ctx, "s3:ListObjectVersions"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+
+	out := &s3.ListObjectVersionsOutput{Name: params.Bucket, IsTruncated: aws.Bool(false)}
+	for key, body := range b.Objects {
+		out.Versions = append(out.Versions, types.ObjectVersion{
+			Key:       aws.String(key),
+			VersionId: aws.String("null"),
+			IsLatest:  aws.Bool(true),
+			Size:      aws.Int64(int64(len(body))),
+		})
+	}
+	return out, nil
+}
+
+func (f *S3) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	if err := f.cloud.call(ctx, "s3:DeleteObjects"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+
+	out := &s3.DeleteObjectsOutput{}
+	if params.Delete == nil {
+		return out, nil
+	}
+	for _, obj := range params.Delete.Objects {
+		delete(b.Objects, aws.ToString(obj.Key))
+		out.Deleted = append(out.Deleted, types.DeletedObject{Key: obj.Key, VersionId: obj.VersionId})
+	}
+	return out, nil
+}
+
+func (f *S3) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	if err := f.cloud.call(ctx, "s3:HeadBucket"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadBucketOutput{BucketRegion: aws.String(b.Region)}, nil
+}
+
+func (f *S3) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	if err := f.cloud.call(ctx, "s3:GetBucketVersioning"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+	return &s3.GetBucketVersioningOutput{Status: b.Versioning}, nil
+}
+
+func (f *S3) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	if err := f.cloud.call(ctx, "s3:GetBucketLifecycleConfiguration"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.buckets[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &types.NoSuchBucket{Message: aws.String("The specified bucket does not exist")}
+	}
+	if len(b.Lifecycle) == 0 {
+		return nil, apiError("NoSuchLifecycleConfiguration", "The lifecycle configuration does not exist")
+	}
+	return &s3.GetBucketLifecycleConfigurationOutput{
+		Rules: append([]types.LifecycleRule(nil), b.Lifecycle...),
+	}, nil
+}
+
+func (f *S3) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	if err := f.cloud.call(ctx, "s3:ListBuckets"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.buckets))
+	for name := range f.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := &s3.ListBucketsOutput{}
+	for _, name := range names {
+		out.Buckets = append(out.Buckets, types.Bucket{Name: aws.String(name)})
+	}
+	return out, nil
+}
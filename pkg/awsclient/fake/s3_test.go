@@ -0,0 +1,56 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arkishshah/go-infra-provisioner/pkg/awsclient/fake"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestPutBucketLifecycleConfiguration(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     types.LifecycleRule
+		wantCode string
+	}{
+		{name: "prefix filter", rule: types.LifecycleRule{Filter: &types.LifecycleRuleFilterMemberPrefix{Value: ""}}},
+		{name: "legacy prefix", rule: types.LifecycleRule{Prefix: aws.String("logs/")}},
+		{name: "no filter", rule: types.LifecycleRule{}, wantCode: "MalformedXML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cloud := fake.New("123456789012", "us-east-1")
+			if _, err := cloud.S3.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String("logs")}); err != nil {
+				t.Fatalf("CreateBucket() error = %v", err)
+			}
+
+			tt.rule.ID, tt.rule.Status = aws.String("archive"), types.ExpirationStatusEnabled
+			_, err := cloud.S3.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+				Bucket:                 aws.String("logs"),
+				LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: []types.LifecycleRule{tt.rule}},
+			})
+			var apiErr smithy.APIError
+			if tt.wantCode != "" {
+				if !errors.As(err, &apiErr) || apiErr.ErrorCode() != tt.wantCode {
+					t.Errorf("PutBucketLifecycleConfiguration() error = %v, want %s", err, tt.wantCode)
+				}
+				if bucket, _ := cloud.S3.Bucket("logs"); len(bucket.Lifecycle) != 0 {
+					t.Errorf("rejected rules were kept: %+v", bucket.Lifecycle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PutBucketLifecycleConfiguration() error = %v", err)
+			}
+			if bucket, _ := cloud.S3.Bucket("logs"); len(bucket.Lifecycle) != 1 {
+				t.Errorf("bucket has %d rules, want 1", len(bucket.Lifecycle))
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package fake
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+type Topic struct {
+	Name       string
+	ARN        string
+	Attributes map[string]string
+	Tags       map[string]string
+}
+
+type SNS struct {
+	cloud *Cloud
+
+	mu     sync.Mutex
+	topics map[string]*Topic // keyed by ARN
+}
+
+func newSNS(cloud *Cloud) *SNS {
+	return &SNS{cloud: cloud, topics: make(map[string]*Topic)}
+}
+
+// Topic returns a copy of the topic with the given ARN.
+func (f *SNS) Topic(topicARN string) (Topic, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.topics[topicARN]
+	if !ok {
+		return Topic{}, false
+	}
+	out := *t
+	out.Attributes = make(map[string]string, len(t.Attributes))
+	for k, v := range t.Attributes {
+		out.Attributes[k] = v
+	}
+	out.Tags = make(map[string]string, len(t.Tags))
+	for k, v := range t.Tags {
+		out.Tags[k] = v
+	}
+	return out, true
+}
+
+// TopicARN returns the ARN a topic with the given name has in this account.
+func (f *SNS) TopicARN(name string) string {
+	return arn("sns", f.cloud.Region, f.cloud.AccountID, name)
+}
+
+func (f *SNS) CreateTopic(ctx context.Context, params *sns.CreateTopicInput, optFns ...func(*sns.Options)) (*sns.CreateTopicOutput, error) {
+	if err := f.cloud.call(ctx, "sns:CreateTopic"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// CreateTopic is idempotent: an existing topic's ARN is returned.
+	topicARN := f.TopicARN(aws.ToString(params.Name))
+	if _, ok := f.topics[topicARN]; !ok {
+		t := &Topic{
+			Name:       aws.ToString(params.Name),
+			ARN:        topicARN,
+			Attributes: make(map[string]string),
+			Tags:       make(map[string]string),
+		}
+		for k, v := range params.Attributes {
+			t.Attributes[k] = v
+		}
+		for _, tag := range params.Tags {
+			t.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		f.topics[topicARN] = t
+	}
+
+	return &sns.CreateTopicOutput{TopicArn: aws.String(topicARN)}, nil
+}
+
+func (f *SNS) SetTopicAttributes(ctx context.Context, params *sns.SetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.SetTopicAttributesOutput, error) {
+	if err := f.cloud.call(ctx, "sns:SetTopicAttributes"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.topics[aws.ToString(params.TopicArn)]
+	if !ok {
+		return nil, &types.NotFoundException{Message: aws.String("Topic does not exist")}
+	}
+	t.Attributes[aws.ToString(params.AttributeName)] = aws.ToString(params.AttributeValue)
+	return &sns.SetTopicAttributesOutput{}, nil
+}
+
+func (f *SNS) DeleteTopic(ctx context.Context, params *sns.DeleteTopicInput, optFns ...func(*sns.Options)) (*sns.DeleteTopicOutput, error) {
+	if err := f.cloud.call(ctx, "sns:DeleteTopic"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Like SNS, deleting a topic that does not exist is not an error.
+	delete(f.topics, aws.ToString(params.TopicArn))
+	return &sns.DeleteTopicOutput{}, nil
+}
+
+func (f *SNS) GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error) {
+	if err := f.cloud.call(ctx, "sns:GetTopicAttributes"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.topics[aws.ToString(params.TopicArn)]
+	if !ok {
+		return nil, &types.NotFoundException{Message: aws.String("Topic does not exist")}
+	}
+
+	attributes := map[string]string{"TopicArn": t.ARN}
+	for k, v := range t.Attributes {
+		attributes[k] = v
+	}
+	return &sns.GetTopicAttributesOutput{Attributes: attributes}, nil
+}
+
+func (f *SNS) ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error) {
+	if err := f.cloud.call(ctx, "sns:ListTopics"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	arns := make([]string, 0, len(f.topics))
+	for topicARN := range f.topics {
+		arns = append(arns, topicARN)
+	}
+	sort.Strings(arns)
+
+	out := &sns.ListTopicsOutput{}
+	for _, topicARN := range arns {
+		out.Topics = append(out.Topics, types.Topic{TopicArn: aws.String(topicARN)})
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// STS answers as the IAM user "fake" of the cloud's account.
+type STS struct {
+	cloud *Cloud
+}
+
+func newSTS(cloud *Cloud) *STS {
+	return &STS{cloud: cloud}
+}
+
+func (f *STS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if err := f.cloud.call(ctx, "sts:GetCallerIdentity"); err != nil {
+		return nil, err
+	}
+
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(f.cloud.AccountID),
+		Arn:     aws.String(fmt.Sprintf("arn:aws:iam::%s:user/fake", f.cloud.AccountID)),
+		UserId:  aws.String("AIDAFAKE"),
+	}, nil
+}
+
+// AssumeRole hands out hour-long credentials for any role. The credentials are
+// not accepted anywhere; use a Cloud per account to emulate several accounts.
+func (f *STS) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	if err := f.cloud.call(ctx, "sts:AssumeRole"); err != nil {
+		return nil, err
+	}
+
+	roleARN := aws.ToString(params.RoleArn)
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || !strings.HasPrefix(parts[5], "role/") {
+		return nil, apiError("ValidationError", "invalid role ARN %s", roleARN)
+	}
+	accountID, roleName := parts[4], parts[5][strings.LastIndex(parts[5], "/")+1:]
+
+	return &sts.AssumeRoleOutput{
+		AssumedRoleUser: &types.AssumedRoleUser{
+			Arn:           aws.String(fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", accountID, roleName, aws.ToString(params.RoleSessionName))),
+			AssumedRoleId: aws.String("AROAFAKE:" + aws.ToString(params.RoleSessionName)),
+		},
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("ASIAFAKE"),
+			SecretAccessKey: aws.String("fake"),
+			SessionToken:    aws.String("fake"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
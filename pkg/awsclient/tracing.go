@@ -0,0 +1,44 @@
+package awsclient
+
+import (
+	"context"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/arkishshah/go-infra-provisioner/pkg/awsclient")
+
+// addTracing wraps each operation, including any retries, in a client span
+// named after the service and operation, such as "IAM.CreateRole".
+func addTracing(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Tracing",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			service := awsmiddleware.GetServiceID(ctx)
+			operation := awsmiddleware.GetOperationName(ctx)
+			ctx, span := tracer.Start(ctx, service+"."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("rpc.system", "aws-api"),
+					attribute.String("rpc.service", service),
+					attribute.String("rpc.method", operation),
+					attribute.String("cloud.region", awsmiddleware.GetRegion(ctx)),
+				))
+			defer span.End()
+
+			out, metadata, err := next.HandleInitialize(ctx, in)
+
+			if id := requestID(metadata, err); id != "" {
+				span.SetAttributes(attribute.String("aws.request_id", id))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return out, metadata, err
+		}), middleware.After)
+}
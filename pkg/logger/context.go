@@ -0,0 +1,19 @@
+package logger
+
+import "context"
+
+type contextKey struct{}
+
+// WithContext returns a context carrying l, typically a child logger with the
+// request, client or job it is working for.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the context's logger, or fallback if it has none.
+func FromContext(ctx context.Context, fallback *Logger) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return fallback
+}
@@ -1,38 +1,202 @@
+// Package logger is the service's structured, leveled logger. It is built on
+// log/slog: every entry has a level, a message and key-value fields, and is
+// written as JSON or as logfmt-style text.
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"runtime"
+	"strings"
 	"time"
 )
 
+// Levels, from most to least verbose. LevelFatal is only used by Fatal.
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
+	LevelFatal = slog.Level(12)
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Field keys shared across the service, so that the same thing is always
+// logged under the same name
+const (
+	RequestID    = "request_id"
+	TraceID      = "trace_id"
+	ClientID     = "client_id"
+	AccountID    = "account_id"
+	Region       = "region"
+	JobID        = "job_id"
+	Step         = "step"
+	ResourceType = "resource_type"
+	Resource     = "resource"
+	ErrorKey     = "error"
+)
+
 type Logger struct {
-	infoLog  *log.Logger
-	errorLog *log.Logger
+	handler slog.Handler
 }
 
+// NewLogger returns an info level text logger writing to stdout.
 func NewLogger() *Logger {
-	flags := log.Ldate | log.Ltime | log.Lshortfile
+	return &Logger{handler: newHandler(os.Stdout, FormatText, LevelInfo)}
+}
+
+// New returns a logger writing entries at level and above to w in format,
+// which is json or text. level is one of debug, info, warn or error.
+func New(level, format string, w io.Writer) (*Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		format = FormatText
+	case FormatJSON, FormatText:
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be json or text", format)
+	}
 
-	return &Logger{
-		infoLog:  log.New(os.Stdout, "INFO: ", flags),
-		errorLog: log.New(os.Stderr, "ERROR: ", flags),
+	return &Logger{handler: newHandler(w, format, lvl)}, nil
+}
+
+// NewFromHandler returns a logger writing to an existing slog handler.
+func NewFromHandler(h slog.Handler) *Logger {
+	return &Logger{handler: h}
+}
+
+// ParseLevel parses a level name as used by LOG_LEVEL.
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
 	}
+	return 0, fmt.Errorf("unknown log level %q, must be debug, info, warn or error", level)
 }
 
-func (l *Logger) Info(v ...interface{}) {
-	msg := fmt.Sprint(v...)
-	l.infoLog.Output(2, fmt.Sprintf("[%s] %s", time.Now().Format(time.RFC3339), msg))
+func newHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.LevelKey:
+				if lvl, ok := a.Value.Any().(slog.Level); ok && lvl >= LevelFatal {
+					a.Value = slog.StringValue("FATAL")
+				}
+			case slog.SourceKey:
+				// file:line is enough to find the call
+				if src, ok := a.Value.Any().(*slog.Source); ok {
+					a.Value = slog.StringValue(fmt.Sprintf("%s:%d", shortPath(src.File), src.Line))
+				}
+			}
+			return a
+		},
+	}
+
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// shortPath trims a source path to its package directory and file name.
+func shortPath(path string) string {
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return path
+	}
+	if j := strings.LastIndexByte(path[:i], '/'); j >= 0 {
+		return path[j+1:]
+	}
+	return path
 }
 
-func (l *Logger) Error(v ...interface{}) {
-	msg := fmt.Sprint(v...)
-	l.errorLog.Output(2, fmt.Sprintf("[%s] %s", time.Now().Format(time.RFC3339), msg))
+// Handler returns the logger's slog handler, for libraries that take one.
+func (l *Logger) Handler() slog.Handler {
+	return l.handler
 }
 
-func (l *Logger) Fatal(v ...interface{}) {
-	msg := fmt.Sprint(v...)
-	l.errorLog.Output(2, fmt.Sprintf("[%s] FATAL: %s", time.Now().Format(time.RFC3339), msg))
+// Slog returns a *slog.Logger writing through the same handler, with the same
+// fields.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(l.handler)
+}
+
+// With returns a child logger that adds the key-value fields to every entry.
+func (l *Logger) With(args ...any) *Logger {
+	if len(args) == 0 {
+		return l
+	}
+	return &Logger{handler: l.Slog().With(args...).Handler()}
+}
+
+// Enabled reports whether entries at level are written.
+func (l *Logger) Enabled(level slog.Level) bool {
+	return l.handler.Enabled(context.Background(), level)
+}
+
+// Debug, Info, Warn and Error write msg with the key-value fields in args,
+// as in slog: alternating keys and values, or slog.Attr values.
+func (l *Logger) Debug(msg string, args ...any) {
+	l.log(LevelDebug, msg, args)
+}
+
+func (l *Logger) Info(msg string, args ...any) {
+	l.log(LevelInfo, msg, args)
+}
+
+func (l *Logger) Warn(msg string, args ...any) {
+	l.log(LevelWarn, msg, args)
+}
+
+func (l *Logger) Error(msg string, args ...any) {
+	l.log(LevelError, msg, args)
+}
+
+// Fatal writes msg and exits the process.
+func (l *Logger) Fatal(msg string, args ...any) {
+	l.log(LevelFatal, msg, args)
 	os.Exit(1)
 }
+
+// log writes the entry with the source of the caller of the exported method.
+func (l *Logger) log(level slog.Level, msg string, args []any) {
+	ctx := context.Background()
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.handler.Handle(ctx, r)
+}
+
+// Err is the field for an error.
+func Err(err error) slog.Attr {
+	return slog.Any(ErrorKey, err)
+}
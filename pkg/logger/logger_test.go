@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		wantErr bool
+	}{
+		{"defaults", "", "", false},
+		{"json", "debug", "json", false},
+		{"upper case", "WARN", "TEXT", false},
+		{"warning", "warning", "text", false},
+		{"unknown level", "verbose", "text", true},
+		{"unknown format", "info", "xml", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.level, tt.format, &bytes.Buffer{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New(%q, %q) error = %v, wantErr %v", tt.level, tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New("warn", FormatJSON, &buf)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	var levels []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("entry %q is not JSON: %v", line, err)
+		}
+		levels = append(levels, entry["level"].(string))
+	}
+	if strings.Join(levels, ",") != "WARN,ERROR" {
+		t.Errorf("wrote levels %v, want WARN and ERROR", levels)
+	}
+	if l.Enabled(LevelInfo) || !l.Enabled(LevelError) {
+		t.Error("Enabled() does not follow the logger's level")
+	}
+}
+
+func TestFields(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New("info", FormatJSON, &buf)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	child := l.With(RequestID, "req-1")
+	ctx := WithContext(context.Background(), child.With(ClientID, "acme"))
+	FromContext(ctx, l).Error("Failed", Step, "s3_bucket", Err(errors.New("boom")))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("entry %q is not JSON: %v", buf.String(), err)
+	}
+	want := map[string]string{
+		"msg":     "Failed",
+		RequestID: "req-1",
+		ClientID:  "acme",
+		Step:      "s3_bucket",
+		ErrorKey:  "boom",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("entry[%q] = %v, want %q", key, entry[key], value)
+		}
+	}
+	if source, _ := entry["source"].(string); !strings.HasPrefix(source, "logger/logger_test.go:") {
+		t.Errorf("entry source = %q, want the caller's file and line", source)
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New("info", FormatText, &buf)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	l.Info("Provisioned", ClientID, "acme")
+
+	line := buf.String()
+	for _, want := range []string{"level=INFO", `msg=Provisioned`, "client_id=acme"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("entry %q does not contain %q", line, want)
+		}
+	}
+}
+
+func TestFromContextFallback(t *testing.T) {
+	fallback := NewLogger()
+	if got := FromContext(context.Background(), fallback); got != fallback {
+		t.Error("FromContext() of a context without a logger did not return the fallback")
+	}
+}
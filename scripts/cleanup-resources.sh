@@ -4,19 +4,40 @@ echo "🧹 Cleaning up resources..."
 
 # Get client ID from argument or use default
 CLIENT_ID=${1:-"test-client-001"}
-ENVIRONMENT=${ENVIRONMENT:-"dev"}
+BASE_URL=${BASE_URL:-"http://localhost:8080"}
+# API key with provision:write
+API_KEY=${API_KEY:?API_KEY must be set}
 
-# Construct resource names
-BUCKET_NAME="${ENVIRONMENT}-${CLIENT_ID}-bucket"
-ROLE_NAME="${ENVIRONMENT}-${CLIENT_ID}-role"
+# The service tears down every resource it provisioned for the client
+# (alarms, SNS topic, EventBridge rule, Lambda, log group, IAM role, S3 bucket)
+echo "Deprovisioning client: $CLIENT_ID"
+response=$(curl -s -w "\n%{http_code}" -X DELETE -H "X-API-Key: $API_KEY" "$BASE_URL/api/v1/provision/$CLIENT_ID")
+status=$(echo "$response" | tail -n1)
+body=$(echo "$response" | sed '$d')
 
-# Delete S3 bucket
-echo "Deleting S3 bucket: $BUCKET_NAME"
-aws s3 rb "s3://$BUCKET_NAME" --force
+if [[ $status != "202" ]]; then
+    echo "Response: $body"
+    echo "❌ Cleanup failed (HTTP $status)"
+    exit 1
+fi
 
-# Delete IAM role
-echo "Deleting IAM role: $ROLE_NAME"
-aws iam delete-role-policy --role-name "$ROLE_NAME" --policy-name "${ROLE_NAME}-policy"
-aws iam delete-role --role-name "$ROLE_NAME"
+# Teardown runs as a job; poll it until it finishes
+job_id=$(echo "$body" | sed -n 's/.*"job_id":"\([^"]*\)".*/\1/p')
+echo "Waiting for teardown job $job_id..."
+for i in $(seq 1 60); do
+    job_response=$(curl -s -H "X-API-Key: $API_KEY" "$BASE_URL/api/v1/jobs/$job_id")
+    if [[ $job_response == *'"status":"succeeded"'* ]]; then
+        echo "Response: $job_response"
+        echo "Cleanup complete!"
+        exit 0
+    fi
+    if [[ $job_response == *'"status":"failed"'* ]]; then
+        echo "Response: $job_response"
+        echo "❌ Cleanup failed"
+        exit 1
+    fi
+    sleep 2
+done
 
-echo "Cleanup complete!"
+echo "❌ Teardown job did not finish in time"
+exit 1
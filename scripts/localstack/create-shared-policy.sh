@@ -0,0 +1,20 @@
+#!/bin/bash
+# Creates go-infra-policy, the shared policy every client role is attached
+# to, which in AWS is created outside the service. LocalStack runs this once
+# it is ready.
+set -e
+
+awslocal iam get-policy --policy-arn arn:aws:iam::000000000000:policy/go-infra-policy >/dev/null 2>&1 && exit 0
+
+awslocal iam create-policy \
+    --policy-name go-infra-policy \
+    --policy-document '{
+        "Version": "2012-10-17",
+        "Statement": [
+            {
+                "Effect": "Allow",
+                "Action": ["logs:CreateLogStream", "logs:PutLogEvents"],
+                "Resource": "*"
+            }
+        ]
+    }'
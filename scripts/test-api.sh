@@ -3,6 +3,8 @@
 echo "🔨 Testing API endpoints..."
 
 BASE_URL="http://localhost:8080"
+# API key with provision:read and provision:write
+AUTH_HEADER="X-API-Key: ${API_KEY:?API_KEY must be set}"
 
 # Test health endpoint
 echo "Testing health endpoint..."
@@ -17,17 +19,37 @@ fi
 # Test provision endpoint
 echo "Testing provision endpoint..."
 provision_response=$(curl -s -X POST "$BASE_URL/api/v1/provision" \
+    -H "$AUTH_HEADER" \
     -H "Content-Type: application/json" \
     -d '{
         "client_id": "test-client-001",
         "client_name": "Test Client"
     }')
 
-if [[ $provision_response == *"success"* ]]; then
-    echo "✅ Provision endpoint working"
-    echo "Response: $provision_response"
-else
+job_id=$(echo "$provision_response" | sed -n 's/.*"job_id":"\([^"]*\)".*/\1/p')
+if [[ -z $job_id ]]; then
     echo "❌ Provision endpoint failed"
     echo "Response: $provision_response"
     exit 1
 fi
+echo "✅ Provisioning job queued: $job_id"
+
+# Poll the job until it finishes
+echo "Waiting for provisioning job..."
+for i in $(seq 1 60); do
+    job_response=$(curl -s -H "$AUTH_HEADER" "$BASE_URL/api/v1/jobs/$job_id")
+    if [[ $job_response == *'"status":"succeeded"'* ]]; then
+        echo "✅ Provision endpoint working"
+        echo "Response: $job_response"
+        exit 0
+    fi
+    if [[ $job_response == *'"status":"failed"'* ]]; then
+        echo "❌ Provisioning job failed"
+        echo "Response: $job_response"
+        exit 1
+    fi
+    sleep 2
+done
+
+echo "❌ Provisioning job did not finish in time"
+exit 1
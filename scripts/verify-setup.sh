@@ -25,7 +25,7 @@ echo "AWS_ACCESS_KEY_ID: ${AWS_ACCESS_KEY_ID:0:5}..."
 echo "AWS_SECRET_ACCESS_KEY: ${AWS_SECRET_ACCESS_KEY:0:5}..."
 echo "AWS_REGION: $AWS_REGION"
 echo "AWS_ACCOUNT_ID: $AWS_ACCOUNT_ID"
-echo "ENVIRONMENT: $ENVIRONMENT"
+echo "APP_ENV: $APP_ENV"
 
 # Verify required variables are set
 echo -e "\nVerifying required variables..."
@@ -34,7 +34,7 @@ required_vars=(
     "AWS_SECRET_ACCESS_KEY"
     "AWS_REGION"
     "AWS_ACCOUNT_ID"
-    "ENVIRONMENT"
+    "APP_ENV"
 )
 
 # Check all required variables
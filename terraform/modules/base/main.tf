@@ -94,7 +94,7 @@ resource "aws_iam_role_policy" "service_policy" {
           "logs:TagLogGroup"
         ]
         Resource = [
-          "arn:aws:logs:${var.aws_region}:${var.aws_account_id}:log-group:/aws/client/${var.environment}/*"
+          "arn:aws:logs:*:${var.aws_account_id}:log-group:/aws/client/${var.environment}/*"
         ]
       },
       {
@@ -111,7 +111,7 @@ resource "aws_iam_role_policy" "service_policy" {
           "lambda:TagResource"
         ]
         Resource = [
-          "arn:aws:lambda:${var.aws_region}:${var.aws_account_id}:function:${var.environment}-*"
+          "arn:aws:lambda:*:${var.aws_account_id}:function:${var.environment}-*"
         ]
       },
       {
@@ -126,7 +126,7 @@ resource "aws_iam_role_policy" "service_policy" {
           "events:TagResource"
         ]
         Resource = [
-          "arn:aws:events:${var.aws_region}:${var.aws_account_id}:rule/${var.environment}-*"
+          "arn:aws:events:*:${var.aws_account_id}:rule/${var.environment}-*"
         ]
       },
       {
@@ -142,7 +142,7 @@ resource "aws_iam_role_policy" "service_policy" {
           "sns:Unsubscribe"
         ]
         Resource = [
-          "arn:aws:sns:${var.aws_region}:${var.aws_account_id}:${var.environment}-*"
+          "arn:aws:sns:*:${var.aws_account_id}:${var.environment}-*"
         ]
       },
       {
@@ -155,9 +155,38 @@ resource "aws_iam_role_policy" "service_policy" {
           "cloudwatch:TagResource"
         ]
         Resource = [
-          "arn:aws:cloudwatch:${var.aws_region}:${var.aws_account_id}:alarm:${var.environment}-*"
+          "arn:aws:cloudwatch:*:${var.aws_account_id}:alarm:${var.environment}-*"
         ]
       },
+      {
+        # CloudWatch Dashboards permissions; dashboards have no region
+        Effect = "Allow"
+        Action = [
+          "cloudwatch:PutDashboard",
+          "cloudwatch:GetDashboard",
+          "cloudwatch:DeleteDashboards"
+        ]
+        Resource = [
+          "arn:aws:cloudwatch::${var.aws_account_id}:dashboard/${var.environment}-*"
+        ]
+      },
+      {
+        # Readiness check for the shared policy attached to client roles
+        Effect = "Allow"
+        Action = [
+          "iam:GetPolicy"
+        ]
+        Resource = "arn:aws:iam::${var.aws_account_id}:policy/go-infra-policy"
+      },
+      {
+        # Cross-account provisioning through the role in each target account
+        Effect = "Allow"
+        Action = [
+          "sts:AssumeRole",
+          "sts:TagSession"
+        ]
+        Resource = "arn:aws:iam::*:role/go-infra-provisioner"
+      },
       {
         # List/Describe permissions that don't support resource-level restrictions
         Effect = "Allow"